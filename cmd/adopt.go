@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/env"
+	"github.com/protocollar/fr8/internal/filesync"
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/port"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+var adoptAll bool
+var adoptName string
+var adoptSync bool
+var adoptSetup bool
+
+func init() {
+	adoptCmd.Flags().BoolVar(&adoptAll, "all", false, "adopt every worktree of the repo that fr8 doesn't manage")
+	adoptCmd.Flags().StringVar(&adoptName, "name", "", "workspace name (default: the worktree directory name)")
+	adoptCmd.Flags().BoolVar(&adoptSync, "sync", false, "copy .worktreeinclude files into the worktree")
+	adoptCmd.Flags().BoolVar(&adoptSetup, "setup", false, "run the setup script in the worktree")
+	workspaceCmd.AddCommand(adoptCmd)
+}
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [path]",
+	Short: "Register existing git worktrees as workspaces",
+	Long: `Registers worktrees created outside fr8 (with git worktree add or another
+tool) as workspaces, so they get a port block and work with every fr8 ws
+command. The worktree stays where it is and keeps its branch.
+
+Without arguments, adopts the worktree containing the current directory.
+With --all, adopts every worktree listed by git worktree list that fr8
+doesn't manage yet; worktrees whose name is already taken are skipped.
+
+Files are only synced and the setup script only run when --sync and
+--setup are given. fr8 config doctor lists the worktrees that can be
+adopted.`,
+	Example: `  fr8 ws adopt ../myapp-hotfix
+  fr8 ws adopt ../myapp-hotfix --name hotfix --sync --setup
+  fr8 ws adopt --all`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAdopt,
+}
+
+// adoptSkip is a worktree fr8 ws adopt --all left alone.
+type adoptSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	if adoptAll && len(args) > 0 {
+		return fmt.Errorf("cannot combine a path with --all")
+	}
+	if adoptAll && adoptName != "" {
+		return fmt.Errorf("cannot combine --name with --all")
+	}
+
+	target := ""
+	if len(args) > 0 {
+		abs, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+		target = abs
+	} else if !adoptAll {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		target = cwd
+	}
+
+	rootPath, err := adoptRootPath(target)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(rootPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	worktrees, err := git.WorktreeList(rootPath)
+	if err != nil {
+		return fmt.Errorf("listing worktrees: %w", err)
+	}
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return fmt.Errorf("finding state path: %w", err)
+	}
+
+	var adopted []registry.Workspace
+	var skipped []adoptSkip
+	err = registry.Update(regPath, func(reg *registry.Registry) error {
+		repo := reg.FindByPath(rootPath)
+		if repo == nil {
+			// Auto-register the repo
+			if err := reg.Add(registry.Repo{Name: filepath.Base(rootPath), Path: rootPath}); err != nil {
+				return fmt.Errorf("registering repo: %w", err)
+			}
+			repo = reg.FindByPath(rootPath)
+		}
+
+		unmanaged, _ := worktreeDrift(rootPath, worktrees, repo.Workspaces)
+		candidates := unmanaged
+		if !adoptAll {
+			wt := findWorktree(worktrees, target)
+			switch {
+			case wt == nil:
+				return fmt.Errorf("%s is not a worktree of %s", target, rootPath)
+			case canonicalPath(wt.Path) == canonicalPath(rootPath):
+				return fmt.Errorf("%s is the repo's main worktree, not a workspace", wt.Path)
+			case findWorktree(unmanaged, wt.Path) == nil:
+				return fmt.Errorf("%s is already a workspace", wt.Path)
+			}
+			candidates = []git.Worktree{*wt}
+		}
+
+		now := time.Now().UTC()
+		for _, wt := range candidates {
+			name := filepath.Base(wt.Path)
+			if adoptName != "" {
+				name = adoptName
+			}
+			if repo.FindWorkspace(name) != nil {
+				if !adoptAll {
+					return fmt.Errorf("workspace %q already exists (choose another name with --name)", name)
+				}
+				skipped = append(skipped, adoptSkip{Path: wt.Path, Reason: fmt.Sprintf("workspace %q already exists", name)})
+				continue
+			}
+
+			p, err := port.Allocate(reg.AllAllocatedPorts(), cfg.BasePort, cfg.PortRange)
+			if err != nil {
+				return fmt.Errorf("allocating port: %w", err)
+			}
+			ws := registry.Workspace{
+				Name:      name,
+				Path:      wt.Path,
+				Port:      p,
+				CreatedAt: now,
+				Origin: &registry.Origin{
+					Branch:     wt.Branch,
+					BaseCommit: wt.HEAD,
+					Source:     registry.SourceAdopted,
+					CreatedBy:  commandLine(),
+					ConfigHash: cfg.Hash(),
+				},
+			}
+			if err := repo.AddWorkspace(ws); err != nil {
+				return fmt.Errorf("saving workspace: %w", err)
+			}
+			adopted = append(adopted, ws)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	defaultBranch, _ := git.DefaultBranch(rootPath)
+	for i := range adopted {
+		ws := &adopted[i]
+		if adoptSync {
+			_, _ = fmt.Fprintf(jsonout.MsgOut(), "Syncing files into %s...\n", ws.Name)
+			changes, err := filesync.Sync(rootPath, ws.Path, filesync.Options{})
+			printSyncChanges(jsonout.MsgOut(), changes, false)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: file sync failed: %v\n", err)
+			}
+		}
+		if adoptSetup && cfg.Scripts.Setup != "" {
+			_, _ = fmt.Fprintf(jsonout.MsgOut(), "Running setup script in %s: %s\n", ws.Name, cfg.Scripts.Setup)
+			envVars, envErr := env.Build(ws, rootPath, defaultBranch, cfg)
+			warnEnv(envErr)
+			if err := runScript(cfg.Scripts.Setup, ws.Path, envVars); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: setup script failed: %v\n", err)
+				fmt.Fprintf(os.Stderr, "You can re-run setup with: cd %s && %s\n", ws.Path, cfg.Scripts.Setup)
+			}
+		}
+	}
+
+	if jsonout.Enabled {
+		type adoptedItem struct {
+			Name   string `json:"name"`
+			Path   string `json:"path"`
+			Branch string `json:"branch"`
+			Port   int    `json:"port"`
+		}
+		items := make([]adoptedItem, len(adopted))
+		for i, ws := range adopted {
+			items[i] = adoptedItem{Name: ws.Name, Path: ws.Path, Branch: ws.Origin.Branch, Port: ws.Port}
+		}
+		if skipped == nil {
+			skipped = []adoptSkip{}
+		}
+		return jsonout.Write(struct {
+			Action     string        `json:"action"`
+			Workspaces []adoptedItem `json:"workspaces"`
+			Skipped    []adoptSkip   `json:"skipped"`
+		}{Action: "adopted", Workspaces: items, Skipped: skipped})
+	}
+
+	if len(adopted) == 0 && len(skipped) == 0 {
+		fmt.Println("No unmanaged worktrees found.")
+		return nil
+	}
+	for _, ws := range adopted {
+		branch := ws.Origin.Branch
+		if branch == "" {
+			branch = "detached HEAD"
+		}
+		fmt.Printf("Adopted %s as workspace %q (%s, ports %d-%d)\n", shortenHomePath(ws.Path), ws.Name, branch, ws.Port, ws.Port+cfg.PortRange-1)
+	}
+	for _, s := range skipped {
+		fmt.Printf("Skipped %s: %s\n", shortenHomePath(s.Path), s.Reason)
+	}
+	return nil
+}
+
+// adoptRootPath returns the root worktree of the repo to adopt from: the
+// --repo repo, or the repo containing target (the working directory with
+// --all).
+func adoptRootPath(target string) (string, error) {
+	if resolveRepo != "" {
+		repo, err := currentRepo()
+		if err != nil {
+			return "", err
+		}
+		if rootPath, err := git.RootWorktreePath(repo.Path); err == nil {
+			return rootPath, nil
+		}
+		return repo.Path, nil
+	}
+
+	dir := target
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		dir = cwd
+	}
+	if !git.IsInsideWorkTree(dir) {
+		return "", fmt.Errorf("%s is not inside a git worktree", dir)
+	}
+	rootPath, err := git.RootWorktreePath(dir)
+	if err != nil {
+		return "", fmt.Errorf("finding root worktree: %w", err)
+	}
+	return rootPath, nil
+}
+
+// worktreeDrift compares the worktrees git knows about with a repo's
+// workspaces. It returns the worktrees that aren't workspaces (other than the
+// main worktree and worktrees whose directory is gone) and the workspaces git
+// has no worktree for.
+func worktreeDrift(rootPath string, worktrees []git.Worktree, workspaces []registry.Workspace) (unmanaged []git.Worktree, missing []registry.Workspace) {
+	known := make(map[string]bool, len(worktrees))
+	for _, wt := range worktrees {
+		known[canonicalPath(wt.Path)] = true
+	}
+	managed := make(map[string]bool, len(workspaces))
+	for _, ws := range workspaces {
+		p := canonicalPath(ws.Path)
+		managed[p] = true
+		if !known[p] {
+			missing = append(missing, ws)
+		}
+	}
+
+	root := canonicalPath(rootPath)
+	for _, wt := range worktrees {
+		p := canonicalPath(wt.Path)
+		if wt.Bare || p == root || managed[p] {
+			continue
+		}
+		if _, err := os.Stat(wt.Path); err != nil {
+			continue
+		}
+		unmanaged = append(unmanaged, wt)
+	}
+	return unmanaged, missing
+}
+
+// findWorktree returns the worktree containing path, or nil.
+func findWorktree(worktrees []git.Worktree, path string) *git.Worktree {
+	p := canonicalPath(path)
+	var found *git.Worktree
+	for i, wt := range worktrees {
+		wp := canonicalPath(wt.Path)
+		if p != wp && !strings.HasPrefix(p, wp+string(filepath.Separator)) {
+			continue
+		}
+		// Worktrees may be nested inside the main worktree; the deepest wins.
+		if found == nil || len(wp) > len(canonicalPath(found.Path)) {
+			found = &worktrees[i]
+		}
+	}
+	return found
+}
+
+// canonicalPath resolves symlinks in path so that worktree paths reported by
+// git compare equal to the ones in the registry.
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
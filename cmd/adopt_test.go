@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func TestWorktreeDrift(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "myapp")
+	managed := filepath.Join(dir, "ws", "managed")
+	adoptable := filepath.Join(dir, "hotfix")
+	for _, p := range []string{root, managed, adoptable} {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	worktrees := []git.Worktree{
+		{Path: root, Branch: "main"},
+		{Path: managed, Branch: "managed"},
+		{Path: adoptable, Branch: "hotfix"},
+		{Path: filepath.Join(dir, "deleted"), Branch: "old"},
+		{Path: filepath.Join(dir, "bare"), Bare: true},
+	}
+	workspaces := []registry.Workspace{
+		{Name: "managed", Path: managed},
+		{Name: "gone", Path: filepath.Join(dir, "ws", "gone")},
+	}
+
+	unmanaged, missing := worktreeDrift(root, worktrees, workspaces)
+	if len(unmanaged) != 1 || unmanaged[0].Path != adoptable {
+		t.Errorf("unmanaged = %+v, want only %s", unmanaged, adoptable)
+	}
+	if len(missing) != 1 || missing[0].Name != "gone" {
+		t.Errorf("missing = %+v, want only gone", missing)
+	}
+}
+
+func TestFindWorktree(t *testing.T) {
+	worktrees := []git.Worktree{
+		{Path: "/src/myapp"},
+		{Path: "/src/myapp/.worktrees/feature"},
+		{Path: "/src/myapp-hotfix"},
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/src/myapp", "/src/myapp"},
+		{"/src/myapp/cmd", "/src/myapp"},
+		{"/src/myapp/.worktrees/feature/cmd", "/src/myapp/.worktrees/feature"},
+		{"/src/myapp-hotfix", "/src/myapp-hotfix"},
+		{"/src/other", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if wt := findWorktree(worktrees, tt.path); wt != nil {
+			got = wt.Path
+		}
+		if got != tt.want {
+			t.Errorf("findWorktree(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
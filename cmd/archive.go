@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/config"
@@ -10,28 +11,43 @@ import (
 	"github.com/protocollar/fr8/internal/exitcode"
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/logfile"
 	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
+	"github.com/protocollar/fr8/internal/snapshot"
 	"github.com/protocollar/fr8/internal/tmux"
 )
 
 var archiveForce bool
 var archiveIfExists bool
 var archiveDryRun bool
+var archiveKeepLogs bool
+var archiveSoft bool
 
 func init() {
 	archiveCmd.Flags().BoolVarP(&archiveForce, "force", "f", false, "skip confirmation and uncommitted changes check")
 	archiveCmd.Flags().BoolVar(&archiveIfExists, "if-exists", false, "succeed silently if workspace not found")
 	archiveCmd.Flags().BoolVar(&archiveDryRun, "dry-run", false, "show what would be done without doing it")
+	archiveCmd.Flags().BoolVar(&archiveKeepLogs, "keep-logs", false, "keep the workspace's session logs")
+	archiveCmd.Flags().BoolVar(&archiveSoft, "soft", false, "save uncommitted changes so the workspace can be restored with fr8 ws restore")
 	workspaceCmd.AddCommand(archiveCmd)
 }
 
 var archiveCmd = &cobra.Command{
 	Use:   "archive [name]",
 	Short: "Tear down a workspace",
-	Long:  "Runs the archive script, removes the git worktree, and frees the port allocation.",
+	Long: `Runs the archive script, removes the git worktree, frees the port allocation,
+and deletes the session logs (unless --keep-logs).
+
+With --soft, the workspace's uncommitted changes (staged, unstaged and
+untracked files) are saved first and its logs are kept, so that fr8 ws restore
+can bring it back. Soft-archived workspaces are listed by fr8 ws archived list
+and deleted for good by fr8 ws archived purge.`,
 	Example: `  fr8 ws archive
   fr8 ws archive my-feature
-  fr8 ws archive --force`,
+  fr8 ws archive --force
+  fr8 ws archive my-feature --keep-logs
+  fr8 ws archive my-feature --soft`,
 	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: workspaceNameCompletion,
 	RunE:              runArchive,
@@ -56,7 +72,7 @@ func runArchive(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	cfg, err := config.Load(rootPath)
+	cfg, err := loadConfig(rootPath)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
@@ -74,9 +90,10 @@ func runArchive(cmd *cobra.Command, args []string) error {
 				Path   string `json:"path"`
 			} `json:"workspace"`
 			Dirty      bool   `json:"dirty"`
+			Soft       bool   `json:"soft,omitempty"`
 			HasScript  bool   `json:"has_archive_script"`
 			ScriptName string `json:"archive_script,omitempty"`
-		}{Action: "dry_run"}
+		}{Action: "dry_run", Soft: archiveSoft}
 		result.Workspace.Name = ws.Name
 		result.Workspace.Branch = branch
 		result.Workspace.Port = ws.Port
@@ -99,13 +116,17 @@ func runArchive(cmd *cobra.Command, args []string) error {
 		if cfg.Scripts.Archive != "" {
 			fmt.Printf("  Script:   %s\n", cfg.Scripts.Archive)
 		}
+		if archiveSoft {
+			fmt.Printf("  Mode:     soft (restorable with fr8 ws restore)\n")
+		}
 		return nil
 	}
 
 	// Safety checks
 	if !archiveForce {
+		// A soft archive keeps uncommitted changes, so they don't block it
 		dirty, _ := git.HasUncommittedChanges(ws.Path)
-		if dirty {
+		if dirty && !archiveSoft {
 			if jsonout.Enabled {
 				return &exitcode.ExitError{
 					Err:      fmt.Errorf("workspace %q has uncommitted changes (use --force to override)", ws.Name),
@@ -119,9 +140,15 @@ func runArchive(cmd *cobra.Command, args []string) error {
 		// Skip interactive confirmation when --json or non-TTY
 		if isInteractive() {
 			fmt.Printf("Archive workspace %q? This will:\n", ws.Name)
+			if archiveSoft {
+				fmt.Printf("  - Save uncommitted changes for fr8 ws restore\n")
+			}
 			fmt.Printf("  - Run archive script\n")
 			fmt.Printf("  - Remove worktree at %s\n", ws.Path)
 			fmt.Printf("  - Free port %d\n", ws.Port)
+			if !archiveKeepLogs && !archiveSoft {
+				fmt.Printf("  - Delete session logs\n")
+			}
 			fmt.Printf("\nContinue? [y/N] ")
 
 			var response string
@@ -136,22 +163,101 @@ func runArchive(cmd *cobra.Command, args []string) error {
 	// Capture branch before worktree removal
 	branch, _ := git.CurrentBranch(ws.Path)
 
-	// Auto-stop tmux session if running
-	if tmux.Available() == nil {
+	archived, err := archiveWorkspace(cfg, ws, rootPath, archiveOptions{keepLogs: archiveKeepLogs, soft: archiveSoft})
+	if err != nil {
+		return err
+	}
+	var snapshotDir string
+	if archived != nil {
+		snapshotDir = archived.Snapshot
+	}
+
+	if jsonout.Enabled {
+		return jsonout.Write(struct {
+			Action    string `json:"action"`
+			Workspace struct {
+				Name   string `json:"name"`
+				Branch string `json:"branch"`
+				Port   int    `json:"port"`
+				Path   string `json:"path"`
+			} `json:"workspace"`
+			Snapshot string `json:"snapshot,omitempty"`
+		}{
+			Action: "archived",
+			Workspace: struct {
+				Name   string `json:"name"`
+				Branch string `json:"branch"`
+				Port   int    `json:"port"`
+				Path   string `json:"path"`
+			}{Name: ws.Name, Branch: branch, Port: ws.Port, Path: ws.Path},
+			Snapshot: snapshotDir,
+		})
+	}
+
+	if archived != nil {
+		fmt.Printf("Workspace %q archived (restore with: fr8 ws restore %s).\n", ws.Name, ws.Name)
+		return nil
+	}
+	fmt.Printf("Workspace %q archived.\n", ws.Name)
+	return nil
+}
+
+// archiveOptions controls how archiveWorkspace tears down a workspace.
+type archiveOptions struct {
+	keepLogs bool // keep the session logs
+	soft     bool // save uncommitted changes and record the workspace for fr8 ws restore
+}
+
+// archiveWorkspace tears down a workspace: it stops its session, runs the
+// archive script, removes the worktree and (unless keepLogs) the session
+// logs, and removes the workspace from the registry. Failures between
+// stopping the session and the registry update are reported as warnings.
+//
+// A soft archive first saves the workspace's uncommitted changes, failing if
+// they can't be saved or an archived workspace of the same name exists,
+// keeps its logs and records it in the registry as archived, which is
+// returned.
+func archiveWorkspace(cfg *config.Config, ws *registry.Workspace, rootPath string, opts archiveOptions) (*registry.ArchivedWorkspace, error) {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return nil, fmt.Errorf("finding state path: %w", err)
+	}
+	if opts.soft {
+		reg, err := registry.Load(regPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading registry: %w", err)
+		}
+		if repo := reg.FindByPath(rootPath); repo != nil && repo.FindArchived(ws.Name) != nil {
+			return nil, registry.ArchivedExistsError(ws.Name)
+		}
+	}
+
+	// Auto-stop running session
+	if rn := runner.Default(); rn.Available() == nil {
 		sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
-		if tmux.IsRunning(sessionName) {
+		if rn.IsRunning(sessionName) {
 			_, _ = fmt.Fprintf(jsonout.MsgOut(), "Stopping background session...\n")
-			if err := tmux.Stop(sessionName); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to stop tmux session: %v\n", err)
+			if err := rn.Stop(sessionName); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to stop session: %v\n", err)
 			}
 		}
 	}
 
+	// Save uncommitted changes before anything is removed
+	var archived *registry.ArchivedWorkspace
+	if opts.soft {
+		var err error
+		if archived, err = snapshotWorkspace(ws, rootPath); err != nil {
+			return nil, err
+		}
+	}
+
 	// Run archive script
 	defaultBranch, _ := git.DefaultBranch(rootPath)
 	if cfg.Scripts.Archive != "" {
 		_, _ = fmt.Fprintf(jsonout.MsgOut(), "Running archive script: %s\n", cfg.Scripts.Archive)
-		envVars := env.Build(ws, rootPath, defaultBranch)
+		envVars, envErr := env.Build(ws, rootPath, defaultBranch, cfg)
+		warnEnv(envErr)
 		if err := runScript(cfg.Scripts.Archive, ws.Path, envVars); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: archive script failed: %v\n", err)
 		}
@@ -164,43 +270,60 @@ func runArchive(cmd *cobra.Command, args []string) error {
 		fmt.Fprintln(os.Stderr, "You may need to remove it manually: git worktree remove", ws.Path)
 	}
 
+	// Purge session logs
+	if !opts.keepLogs && !opts.soft {
+		if logDir, err := logfile.Dir(tmux.RepoName(rootPath), ws.Name); err == nil {
+			if err := logfile.Remove(logDir); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+
 	// Update state
-	regPath, err := registry.DefaultPath()
+	err = registry.Update(regPath, func(reg *registry.Registry) error {
+		if repo := reg.FindByPath(rootPath); repo != nil {
+			if archived != nil {
+				if err := repo.AddArchived(*archived); err != nil {
+					return err
+				}
+			}
+			_ = repo.RemoveWorkspace(ws.Name)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("finding state path: %w", err)
+		if archived != nil {
+			return nil, fmt.Errorf("saving state (the uncommitted changes are kept in %s): %w", archived.Snapshot, err)
+		}
+		return nil, fmt.Errorf("saving state: %w", err)
 	}
-	reg, err := registry.Load(regPath)
+	return archived, nil
+}
+
+// snapshotWorkspace saves a workspace's uncommitted changes for a soft
+// archive and returns its record as an archived workspace.
+func snapshotWorkspace(ws *registry.Workspace, rootPath string) (*registry.ArchivedWorkspace, error) {
+	now := time.Now().UTC()
+	dir, err := snapshot.Dir(tmux.RepoName(rootPath), ws.Name, now)
 	if err != nil {
-		return fmt.Errorf("loading registry: %w", err)
-	}
-	repo := reg.FindByPath(rootPath)
-	if repo != nil {
-		_ = repo.RemoveWorkspace(ws.Name)
-		if err := reg.Save(regPath); err != nil {
-			return fmt.Errorf("saving state: %w", err)
-		}
+		return nil, fmt.Errorf("finding snapshot path: %w", err)
 	}
 
-	if jsonout.Enabled {
-		return jsonout.Write(struct {
-			Action    string `json:"action"`
-			Workspace struct {
-				Name   string `json:"name"`
-				Branch string `json:"branch"`
-				Port   int    `json:"port"`
-				Path   string `json:"path"`
-			} `json:"workspace"`
-		}{
-			Action: "archived",
-			Workspace: struct {
-				Name   string `json:"name"`
-				Branch string `json:"branch"`
-				Port   int    `json:"port"`
-				Path   string `json:"path"`
-			}{Name: ws.Name, Branch: branch, Port: ws.Port, Path: ws.Path},
-		})
+	_, _ = fmt.Fprintf(jsonout.MsgOut(), "Saving uncommitted changes...\n")
+	info, err := snapshot.Save(dir, ws.Path)
+	if err != nil {
+		_ = snapshot.Remove(dir)
+		return nil, fmt.Errorf("saving uncommitted changes (nothing was archived): %w", err)
 	}
 
-	fmt.Printf("Workspace %q archived.\n", ws.Name)
-	return nil
+	branch, _ := git.CurrentBranch(ws.Path)
+	head, _ := git.HeadCommit(ws.Path)
+	return &registry.ArchivedWorkspace{
+		Workspace:  *ws,
+		Branch:     branch,
+		HeadCommit: head,
+		Dirty:      !info.Empty(),
+		ArchivedAt: now,
+		Snapshot:   dir,
+	}, nil
 }
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/logfile"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/snapshot"
+	"github.com/protocollar/fr8/internal/tmux"
+)
+
+var archivedPurgeOlderThan string
+var archivedPurgeDryRun bool
+var archivedPurgeForce bool
+
+func init() {
+	archivedPurgeCmd.Flags().StringVar(&archivedPurgeOlderThan, "older-than", "30d", "purge workspaces archived longer ago than this")
+	archivedPurgeCmd.Flags().BoolVar(&archivedPurgeDryRun, "dry-run", false, "show what would be purged without doing it")
+	archivedPurgeCmd.Flags().BoolVarP(&archivedPurgeForce, "force", "f", false, "skip confirmation")
+	archivedCmd.AddCommand(archivedListCmd)
+	archivedCmd.AddCommand(archivedPurgeCmd)
+	workspaceCmd.AddCommand(archivedCmd)
+}
+
+var archivedCmd = &cobra.Command{
+	Use:   "archived",
+	Short: "List or purge soft-archived workspaces",
+	Long:  "Manage the workspaces archived with fr8 ws archive --soft, which fr8 ws restore can bring back.",
+}
+
+var archivedListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List soft-archived workspaces",
+	Args:    cobra.NoArgs,
+	RunE:    runArchivedList,
+}
+
+var archivedPurgeCmd = &cobra.Command{
+	Use:   "purge [name]",
+	Short: "Delete soft-archived workspaces for good",
+	Long: `Deletes the saved changes and session logs of soft-archived workspaces, after
+which they can no longer be restored. Without a name, purges the workspaces
+archived longer ago than --older-than (default 30 days). Asks for
+confirmation when run interactively, unless --force is given.`,
+	Example: `  fr8 ws archived purge
+  fr8 ws archived purge --older-than 7d --dry-run
+  fr8 ws archived purge my-feature`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: archivedNameCompletion,
+	RunE:              runArchivedPurge,
+}
+
+func runArchivedList(cmd *cobra.Command, args []string) error {
+	repo, err := currentRepo()
+	if err != nil {
+		return err
+	}
+
+	if jsonout.Enabled {
+		archived := repo.Archived
+		if archived == nil {
+			archived = []registry.ArchivedWorkspace{}
+		}
+		return jsonout.Write(archived)
+	}
+
+	if len(repo.Archived) == 0 {
+		fmt.Println("No archived workspaces.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tBRANCH\tARCHIVED\tCHANGES\tNOTE")
+	for _, a := range repo.Archived {
+		changes := "-"
+		if a.Dirty {
+			changes = "saved"
+		}
+		archivedAt := a.ArchivedAt.Local().Format("2006-01-02 15:04")
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.Name, a.Branch, archivedAt, changes, shortNote(a.Note))
+	}
+	return w.Flush()
+}
+
+func runArchivedPurge(cmd *cobra.Command, args []string) error {
+	repo, err := currentRepo()
+	if err != nil {
+		return err
+	}
+
+	var purge []registry.ArchivedWorkspace
+	if len(args) > 0 {
+		a := repo.FindArchived(args[0])
+		if a == nil {
+			return fmt.Errorf("archived workspace %q not found (see available: fr8 ws archived list)", args[0])
+		}
+		purge = append(purge, *a)
+	} else {
+		olderThan, err := config.ParseDuration(archivedPurgeOlderThan)
+		if err != nil || olderThan < 0 {
+			return fmt.Errorf("invalid --older-than %q: must be a duration (e.g. 30d, 36h)", archivedPurgeOlderThan)
+		}
+		cutoff := time.Now().Add(-olderThan)
+		for _, a := range repo.Archived {
+			if a.ArchivedAt.Before(cutoff) {
+				purge = append(purge, a)
+			}
+		}
+	}
+
+	if len(purge) > 0 && !archivedPurgeDryRun && !archivedPurgeForce && isInteractive() {
+		fmt.Printf("Purge %d archived workspace(s)? Their saved changes will be deleted for good.\n", len(purge))
+		for _, a := range purge {
+			fmt.Printf("  - %s (archived %s)\n", a.Name, a.ArchivedAt.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Printf("\nContinue? [y/N] ")
+
+		var response string
+		_, _ = fmt.Scanln(&response)
+		if response != "y" && response != "Y" {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	if !archivedPurgeDryRun && len(purge) > 0 {
+		regPath, err := registry.DefaultPath()
+		if err != nil {
+			return fmt.Errorf("finding state path: %w", err)
+		}
+		var purged []registry.ArchivedWorkspace
+		var recreated []string
+		err = registry.Update(regPath, func(reg *registry.Registry) error {
+			purged, recreated = removeArchivedInRegistry(reg, repo.Name, purge)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("saving state: %w", err)
+		}
+		purge = purged
+
+		for _, a := range purge {
+			if err := snapshot.Remove(a.Snapshot); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			// Logs of a workspace that was recreated under the same name
+			// belong to the new workspace.
+			if !slices.Contains(recreated, a.Name) {
+				if logDir, err := logfile.Dir(tmux.RepoName(repo.Path), a.Name); err == nil {
+					if err := logfile.Remove(logDir); err != nil {
+						fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+					}
+				}
+			}
+		}
+	}
+
+	names := make([]string, len(purge))
+	for i, a := range purge {
+		names[i] = a.Name
+	}
+
+	if jsonout.Enabled {
+		action := "purged"
+		if archivedPurgeDryRun {
+			action = "dry_run"
+		}
+		return jsonout.Write(struct {
+			Action     string   `json:"action"`
+			Workspaces []string `json:"workspaces"`
+		}{Action: action, Workspaces: names})
+	}
+
+	switch {
+	case len(purge) == 0:
+		fmt.Println("No archived workspaces to purge.")
+	case archivedPurgeDryRun:
+		fmt.Printf("Dry run — would purge %d archived workspace(s):\n", len(purge))
+		for _, name := range names {
+			fmt.Printf("  %s\n", name)
+		}
+	default:
+		fmt.Printf("Purged %d archived workspace(s).\n", len(purge))
+	}
+	return nil
+}
+
+// removeArchivedInRegistry removes the archived workspaces in purge from the
+// repo's registry entry, matching on snapshot as well as name: a workspace
+// restored and archived again since purge was selected has a new snapshot
+// and is kept. It returns the entries removed and the names among them that
+// are in use by a workspace again.
+func removeArchivedInRegistry(reg *registry.Registry, repoName string, purge []registry.ArchivedWorkspace) (purged []registry.ArchivedWorkspace, recreated []string) {
+	r := reg.Find(repoName)
+	if r == nil {
+		return nil, nil
+	}
+	r.Archived = slices.DeleteFunc(r.Archived, func(a registry.ArchivedWorkspace) bool {
+		if !slices.ContainsFunc(purge, func(p registry.ArchivedWorkspace) bool {
+			return p.Name == a.Name && p.Snapshot == a.Snapshot
+		}) {
+			return false
+		}
+		purged = append(purged, a)
+		if r.FindWorkspace(a.Name) != nil {
+			recreated = append(recreated, a.Name)
+		}
+		return true
+	})
+	return purged, recreated
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func TestRemoveArchivedInRegistry(t *testing.T) {
+	archived := func(name, snap string) registry.ArchivedWorkspace {
+		return registry.ArchivedWorkspace{Workspace: registry.Workspace{Name: name}, Snapshot: snap}
+	}
+	reg := &registry.Registry{Repos: []registry.Repo{{
+		Name:       "app",
+		Workspaces: []registry.Workspace{{Name: "old"}},
+		Archived: []registry.ArchivedWorkspace{
+			archived("old", "/snap/old-1"),
+			// Restored and archived again after the purge list was selected
+			archived("rearchived", "/snap/rearchived-2"),
+			archived("kept", "/snap/kept-1"),
+		},
+	}}}
+	purge := []registry.ArchivedWorkspace{
+		archived("old", "/snap/old-1"),
+		archived("rearchived", "/snap/rearchived-1"),
+	}
+
+	purged, recreated := removeArchivedInRegistry(reg, "app", purge)
+	if len(purged) != 1 || purged[0].Snapshot != "/snap/old-1" {
+		t.Errorf("purged = %+v, want only old", purged)
+	}
+	if !slices.Equal(recreated, []string{"old"}) {
+		t.Errorf("recreated = %v, want [old]", recreated)
+	}
+	var left []string
+	for _, a := range reg.Repos[0].Archived {
+		left = append(left, a.Name)
+	}
+	if !slices.Equal(left, []string{"rearchived", "kept"}) {
+		t.Errorf("archived left = %v, want [rearchived kept]", left)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/exitcode"
 	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
 )
 
@@ -16,7 +17,7 @@ func init() {
 var attachCmd = &cobra.Command{
 	Use:               "attach [name]",
 	Short:             "Attach to a workspace's background tmux session",
-	Long:              "Replaces the fr8 process with tmux attach. Detach with Ctrl-B d.",
+	Long:              "Replaces the fr8 process with tmux attach. Detach with Ctrl-B d.\nNot supported by the native runner; use fr8 ws logs -f instead.",
 	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: workspaceNameCompletion,
 	RunE:              runAttach,
@@ -31,7 +32,8 @@ func runAttach(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return err
 	}
 
@@ -46,5 +48,5 @@ func runAttach(cmd *cobra.Command, args []string) error {
 	}
 
 	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
-	return tmux.Attach(sessionName)
+	return rn.Attach(sessionName)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/jsonout"
 	"github.com/protocollar/fr8/internal/port"
+	"github.com/protocollar/fr8/internal/proxy"
 	"github.com/protocollar/fr8/internal/registry"
 )
 
@@ -27,17 +28,19 @@ func runBrowser(cmd *cobra.Command, args []string) error {
 		name = args[0]
 	}
 
-	ws, _, err := resolveWorkspace(name)
+	ws, rootPath, err := resolveWorkspace(name)
 	if err != nil {
 		return err
 	}
 
-	return openWorkspaceBrowser(ws)
+	return openWorkspaceBrowser(ws, registeredRepoName(rootPath))
 }
 
-func openWorkspaceBrowser(ws *registry.Workspace) error {
+// openWorkspaceBrowser opens the workspace's dev server, using its
+// fr8 proxy hostname when the proxy is running.
+func openWorkspaceBrowser(ws *registry.Workspace, repoName string) error {
 	listening := !port.IsFree(ws.Port)
-	url := fmt.Sprintf("http://localhost:%d", ws.Port)
+	url := proxy.BrowserURL(repoName, ws)
 
 	if jsonout.Enabled {
 		return jsonout.Write(struct {
@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+var cloneName string
+var clonePath string
+var cloneBare bool
+var cloneWorkspace string
+var cloneFrom string
+
+func init() {
+	repoCloneCmd.Flags().StringVar(&cloneName, "name", "", "repo name in the registry (default: the checkout's directory name)")
+	repoCloneCmd.Flags().StringVar(&clonePath, "path", "", "where to clone (default: ./<name>); with --from, the directory for relative paths")
+	repoCloneCmd.Flags().BoolVar(&cloneBare, "bare", false, "clone bare into <path>.git and check the default branch out at <path>")
+	repoCloneCmd.Flags().StringVar(&cloneWorkspace, "workspace", "", "create a first workspace with this name")
+	repoCloneCmd.Flags().StringVar(&cloneFrom, "from", "", "clone every repo listed in a manifest file")
+	repoCmd.AddCommand(repoCloneCmd)
+}
+
+var repoCloneCmd = &cobra.Command{
+	Use:   "clone <url>",
+	Short: "Clone a repo and register it",
+	Long: `Clones a repo, registers it with fr8 (like fr8 repo add) and checks its
+config (like fr8 config doctor). With --workspace, also creates a first
+workspace.
+
+With --bare, the repo is cloned bare into <path>.git and its default branch
+checked out as a worktree at <path>, which fr8 uses as the repo's root.
+
+With --from, clones every repo listed in a JSON manifest:
+
+  {
+    "repos": [
+      {"url": "git@github.com:acme/api.git"},
+      {"url": "git@github.com:acme/web.git", "name": "web", "path": "~/src/web", "bare": true, "workspace": "first"}
+    ]
+  }
+
+Relative paths are resolved against --path (default: the current directory).
+Repos that are already registered are skipped and existing checkouts are
+registered without cloning, so a manifest can be applied again as it grows.`,
+	Example: `  fr8 repo clone git@github.com:acme/api.git
+  fr8 repo clone https://github.com/acme/web.git --name web --path ~/src/web --bare
+  fr8 repo clone git@github.com:acme/api.git --workspace first
+  fr8 repo clone --from team.json --path ~/src`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cloneFrom != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: runRepoClone,
+}
+
+// cloneSpec is a repo to clone: the command-line arguments, or an entry of a
+// --from manifest.
+type cloneSpec struct {
+	URL       string `json:"url"`
+	Name      string `json:"name,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Bare      bool   `json:"bare,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+}
+
+// cloneManifest is the file read by fr8 repo clone --from.
+type cloneManifest struct {
+	Repos []cloneSpec `json:"repos"`
+}
+
+// cloneResult reports what fr8 repo clone did with one repo.
+type cloneResult struct {
+	Name      string   `json:"name"`
+	URL       string   `json:"url"`
+	Path      string   `json:"path"`
+	Action    string   `json:"action"` // cloned, registered, skipped or failed
+	Error     string   `json:"error,omitempty"`
+	Errors    []string `json:"config_errors,omitempty"`
+	Warnings  []string `json:"config_warnings,omitempty"`
+	Workspace string   `json:"workspace,omitempty"`
+}
+
+func runRepoClone(cmd *cobra.Command, args []string) error {
+	if cloneFrom == "" {
+		spec := cloneSpec{URL: args[0], Name: cloneName, Path: clonePath, Bare: cloneBare, Workspace: cloneWorkspace}
+		res := cloneRepo(spec, ".")
+		if res.Action == "failed" {
+			return errors.New(res.Error)
+		}
+		if jsonout.Enabled {
+			return jsonout.Write(res)
+		}
+		printCloneResult(res)
+		return nil
+	}
+
+	if cloneName != "" || cloneBare || cloneWorkspace != "" {
+		return fmt.Errorf("--name, --bare and --workspace can't be combined with --from; set them per repo in the manifest")
+	}
+	manifest, err := loadCloneManifest(cloneFrom)
+	if err != nil {
+		return err
+	}
+	baseDir := clonePath
+	if baseDir == "" {
+		baseDir = "."
+	}
+
+	results := make([]cloneResult, 0, len(manifest.Repos))
+	failed := 0
+	for _, spec := range manifest.Repos {
+		res := cloneRepo(spec, baseDir)
+		if res.Action == "failed" {
+			failed++
+		}
+		if !jsonout.Enabled {
+			printCloneResult(res)
+		}
+		results = append(results, res)
+	}
+	if jsonout.Enabled {
+		if err := jsonout.Write(results); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repos failed", failed, len(results))
+	}
+	return nil
+}
+
+// loadCloneManifest reads and validates a --from manifest.
+func loadCloneManifest(path string) (*cloneManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	var m cloneManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(m.Repos) == 0 {
+		return nil, fmt.Errorf("%s lists no repos", path)
+	}
+	names := make(map[string]bool, len(m.Repos))
+	for i, spec := range m.Repos {
+		if spec.URL == "" {
+			return nil, fmt.Errorf("%s: repos[%d] has no url", path, i)
+		}
+		name, _ := resolveCloneSpec(spec, ".")
+		if names[name] {
+			return nil, fmt.Errorf("%s: repo name %q is used twice", path, name)
+		}
+		names[name] = true
+	}
+	return &m, nil
+}
+
+// cloneRepo clones and registers the repo described by spec, resolving a
+// relative spec.Path against baseDir. Failures are reported in the result.
+func cloneRepo(spec cloneSpec, baseDir string) cloneResult {
+	name, path := resolveCloneSpec(spec, baseDir)
+	res := cloneResult{Name: name, URL: spec.URL, Path: path}
+	fail := func(err error) cloneResult {
+		res.Action = "failed"
+		res.Error = err.Error()
+		return res
+	}
+	if name == "" {
+		return fail(fmt.Errorf("can't derive a repo name from %q (use --name)", spec.URL))
+	}
+
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return fail(err)
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return fail(fmt.Errorf("loading registry: %w", err))
+	}
+	if existing := reg.Find(name); existing != nil {
+		if canonicalPath(existing.Path) != canonicalPath(path) {
+			return fail(fmt.Errorf("repo %q is already registered at %s", name, existing.Path))
+		}
+		res.Action = "skipped"
+		return res
+	}
+
+	res.Action = "registered"
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if err := cloneInto(spec.URL, path, spec.Bare); err != nil {
+			return fail(err)
+		}
+		res.Action = "cloned"
+	} else if !git.IsInsideWorkTree(path) {
+		return fail(fmt.Errorf("%s already exists and is not a git checkout", path))
+	}
+
+	rootPath, err := git.RootWorktreePath(path)
+	if err != nil {
+		return fail(fmt.Errorf("finding root worktree: %w", err))
+	}
+	if rootPath, err = filepath.EvalSymlinks(rootPath); err != nil {
+		return fail(fmt.Errorf("resolving path: %w", err))
+	}
+	res.Path = rootPath
+	err = registry.Update(regPath, func(reg *registry.Registry) error {
+		if r := reg.FindByPath(rootPath); r != nil {
+			res.Name = r.Name
+			res.Action = "skipped"
+			return nil
+		}
+		return reg.Add(registry.Repo{Name: name, Path: rootPath})
+	})
+	if err != nil {
+		return fail(err)
+	}
+	if res.Action == "skipped" {
+		return res
+	}
+
+	cfg, err := loadConfig(rootPath)
+	if err != nil {
+		res.Errors = []string{fmt.Sprintf("loading config: %v", err)}
+		return res
+	}
+	res.Errors, res.Warnings, _ = checkConfig(rootPath, cfg)
+
+	if spec.Workspace != "" {
+		ws, err := createWorkspace(rootPath, spec.Workspace, "", false, true, false, workspaceSource{createdBy: commandLine(), quiet: true})
+		if err != nil {
+			return fail(fmt.Errorf("creating workspace: %w", err))
+		}
+		res.Workspace = ws.Name
+	}
+	return res
+}
+
+// resolveCloneSpec returns the registry name and absolute checkout path of
+// spec. The path defaults to <baseDir>/<name>, and a relative one is resolved
+// against baseDir. The name defaults to the checkout's directory name when a
+// path is given, like fr8 repo add, and to the name in the URL otherwise.
+func resolveCloneSpec(spec cloneSpec, baseDir string) (name, path string) {
+	name = spec.Name
+	if spec.Path == "" {
+		if name == "" {
+			name = repoNameFromURL(spec.URL)
+		}
+		path = filepath.Join(baseDir, name)
+	} else {
+		path = spec.Path
+		if strings.HasPrefix(path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, path[2:])
+			}
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if name == "" {
+			name = filepath.Base(filepath.Clean(path))
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return name, path
+}
+
+// cloneInto clones url to path. A bare clone goes to <path>.git with the
+// default branch checked out at path.
+func cloneInto(url, path string, bare bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	if !bare {
+		_, _ = fmt.Fprintf(jsonout.MsgOut(), "Cloning %s into %s...\n", url, shortenHomePath(path))
+		return git.Clone(url, path, false)
+	}
+
+	bareDir := path + ".git"
+	_, _ = fmt.Fprintf(jsonout.MsgOut(), "Cloning %s into %s (bare)...\n", url, shortenHomePath(bareDir))
+	if err := git.Clone(url, bareDir, true); err != nil {
+		return err
+	}
+	branch, err := git.CurrentBranch(bareDir)
+	if err != nil {
+		return fmt.Errorf("finding default branch: %w", err)
+	}
+	_, _ = fmt.Fprintf(jsonout.MsgOut(), "Checking out %s at %s\n", branch, shortenHomePath(path))
+	if err := git.WorktreeAdd(bareDir, path, branch, false, ""); err != nil {
+		return fmt.Errorf("creating worktree: %w", err)
+	}
+	if err := git.SetRootWorktree(bareDir, path); err != nil {
+		return err
+	}
+	if err := git.SetUpstream(path, branch, "origin/"+branch); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return nil
+}
+
+func printCloneResult(res cloneResult) {
+	switch res.Action {
+	case "failed":
+		fmt.Fprintf(os.Stderr, "✗ %s: %s\n", res.Name, res.Error)
+		return
+	case "skipped":
+		fmt.Printf("%s is already registered → %s\n", res.Name, shortenHomePath(res.Path))
+		return
+	}
+	fmt.Printf("Registered %q → %s\n", res.Name, shortenHomePath(res.Path))
+	for _, e := range res.Errors {
+		fmt.Printf("  ✗ %s\n", e)
+	}
+	for _, w := range res.Warnings {
+		fmt.Printf("  ⚠ %s\n", w)
+	}
+	if res.Workspace != "" {
+		fmt.Printf("Created workspace %q (enter it with: fr8 ws shell %s)\n", res.Workspace, res.Workspace)
+	}
+}
+
+// repoNameFromURL derives a repo name from a clone URL the way git clone
+// names the directory, e.g. git@github.com:acme/api.git → api.
+func repoNameFromURL(url string) string {
+	url = strings.TrimRight(url, "/")
+	url = strings.TrimSuffix(url, ".git")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return url
+}
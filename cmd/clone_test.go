@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoNameFromURL(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:acme/api.git":     "api",
+		"https://github.com/acme/web.git": "web",
+		"https://github.com/acme/web/":    "web",
+		"ssh://git@host:2222/team/tool":   "tool",
+		"/srv/git/project.git":            "project",
+		"git@host:solo.git":               "solo",
+	}
+	for url, want := range tests {
+		if got := repoNameFromURL(url); got != want {
+			t.Errorf("repoNameFromURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestResolveCloneSpec(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	tests := []struct {
+		spec     cloneSpec
+		wantName string
+		wantPath string
+	}{
+		{cloneSpec{URL: "git@github.com:acme/api.git"}, "api", "/src/api"},
+		{cloneSpec{URL: "git@github.com:acme/api.git", Name: "backend"}, "backend", "/src/backend"},
+		{cloneSpec{URL: "git@github.com:acme/api.git", Path: "work/api-v2"}, "api-v2", "/src/work/api-v2"},
+		{cloneSpec{URL: "git@github.com:acme/api.git", Path: "/opt/api", Name: "api"}, "api", "/opt/api"},
+		{cloneSpec{URL: "git@github.com:acme/api.git", Path: "~/code/api"}, "api", filepath.Join(home, "code", "api")},
+	}
+	for _, tt := range tests {
+		name, path := resolveCloneSpec(tt.spec, "/src")
+		if name != tt.wantName || path != tt.wantPath {
+			t.Errorf("resolveCloneSpec(%+v) = %q, %q, want %q, %q", tt.spec, name, path, tt.wantName, tt.wantPath)
+		}
+	}
+}
+
+func TestLoadCloneManifest(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		p := filepath.Join(dir, "team.json")
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	m, err := loadCloneManifest(write(`{"repos": [
+		{"url": "git@github.com:acme/api.git"},
+		{"url": "git@github.com:acme/web.git", "name": "web", "path": "~/src/web", "bare": true, "workspace": "first"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Repos) != 2 || !m.Repos[1].Bare || m.Repos[1].Workspace != "first" {
+		t.Errorf("loadCloneManifest() = %+v", m)
+	}
+
+	for content, wantErr := range map[string]string{
+		`{"repos": []}`:                "lists no repos",
+		`{"repos": [{"name": "api"}]}`: "has no url",
+		`{"repos": [{"url": "a/api.git"}, {"url": "b/api.git"}]}`: `"api" is used twice`,
+		`{"repos": [`: "parsing",
+	} {
+		if _, err := loadCloneManifest(write(content)); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("loadCloneManifest(%s) error = %v, want %q", content, err, wantErr)
+		}
+	}
+}
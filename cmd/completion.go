@@ -78,3 +78,20 @@ func workspaceNameCompletion(cmd *cobra.Command, args []string, toComplete strin
 	// Fall back to all registered workspace names
 	return reg.AllWorkspaceNames(), cobra.ShellCompDirectiveNoFileComp
 }
+
+// archivedNameCompletion completes the names of the current repo's
+// soft-archived workspaces.
+func archivedNameCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	repo, err := currentRepo()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, len(repo.Archived))
+	for i, a := range repo.Archived {
+		names[i] = a.Name
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
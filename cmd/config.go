@@ -1,25 +1,32 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/config"
 	"github.com/protocollar/fr8/internal/exitcode"
+	"github.com/protocollar/fr8/internal/filesync"
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/jsonout"
 	"github.com/protocollar/fr8/internal/registry"
 )
 
 var doctorFix bool
+var showOrigin bool
 
 func init() {
 	configDoctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "auto-fix correctable issues")
+	configShowCmd.Flags().BoolVar(&showOrigin, "origin", false, "show which config layer each value comes from")
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configDoctorCmd)
 	configCmd.AddCommand(configValidateCmd) // alias
@@ -35,7 +42,22 @@ var configCmd = &cobra.Command{
 var configShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show resolved configuration",
-	Args:  cobra.NoArgs,
+	Long: `Shows the configuration fr8 uses for the current repo. Settings are read
+from these layers, each overriding the ones before it:
+
+  1. built-in defaults
+  2. "defaults" in ~/.config/fr8/config.json
+  3. "repos" -> <repo name> in ~/.config/fr8/config.json
+  4. fr8.json (or conductor.json) in the repo
+  5. fr8.local.json in the repo (keep it out of git)
+  6. FR8_CONFIG_BASE_PORT, FR8_CONFIG_PORT_RANGE, FR8_CONFIG_WORKTREE_PATH,
+     FR8_CONFIG_TTL and FR8_CONFIG_RESTART
+
+Objects such as scripts and services are merged key by key. --origin shows
+which layer each value came from.`,
+	Example: `  fr8 config show
+  fr8 config show --origin`,
+	Args: cobra.NoArgs,
 	RunE:  runConfigShow,
 }
 
@@ -96,7 +118,7 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not inside a git repository (run from a repo or use --repo <name>)")
 	}
 
-	cfg, err := config.Load(rootPath)
+	cfg, err := loadConfig(rootPath)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
@@ -112,6 +134,28 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 		"worktree_path":          cfg.WorktreePath,
 		"resolved_worktree_path": config.ResolveWorktreePath(cfg, rootPath),
 	}
+	if len(cfg.Services) > 0 {
+		resolved["services"] = cfg.Services
+	}
+	if len(cfg.Ports) > 0 {
+		resolved["ports"] = cfg.Ports
+	}
+	if len(cfg.Env) > 0 {
+		resolved["env"] = cfg.Env
+	}
+	if cfg.Restart != "" {
+		resolved["restart"] = cfg.Restart
+	}
+	if cfg.Healthcheck != nil {
+		resolved["healthcheck"] = cfg.Healthcheck
+	}
+	if cfg.TTL != "" {
+		resolved["ttl"] = cfg.TTL
+	}
+
+	if showOrigin {
+		return writeConfigOrigins(cfg, resolved)
+	}
 
 	if jsonout.Enabled {
 		return jsonout.Write(resolved)
@@ -125,78 +169,96 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runConfigDoctor(cmd *cobra.Command, args []string) error {
-	cwd, err := os.Getwd()
+// writeConfigOrigins prints each resolved setting with the config layer it
+// came from.
+func writeConfigOrigins(cfg *config.Config, resolved map[string]interface{}) error {
+	values, err := flattenConfig(resolved)
 	if err != nil {
 		return err
 	}
-
-	rootPath, err := git.RootWorktreePath(cwd)
-	if err != nil {
-		return fmt.Errorf("not inside a git repository (run from a repo or use --repo <name>)")
+	keys := make([]string, 0, len(values))
+	origins := make(map[string]string, len(values))
+	for key := range values {
+		keys = append(keys, key)
+		if key == "resolved_worktree_path" {
+			origins[key] = cfg.Origin("worktree_path")
+		} else {
+			origins[key] = cfg.Origin(key)
+		}
 	}
+	sort.Strings(keys)
 
-	cfg, err := config.Load(rootPath)
-	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+	if jsonout.Enabled {
+		return jsonout.Write(struct {
+			Config  map[string]interface{} `json:"config"`
+			Origins map[string]string      `json:"origins"`
+		}{Config: resolved, Origins: origins})
 	}
 
-	var warnings []string
-	var configErrors []string
-	var fixableFiles []string // config files with legacy keys
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", key, values[key], origins[key])
+	}
+	return w.Flush()
+}
 
-	// Check for deprecated camelCase keys
-	for _, name := range []string{"fr8.json", "conductor.json"} {
-		p := filepath.Join(rootPath, name)
-		if legacy := config.HasLegacyKeys(p); len(legacy) > 0 {
-			fixableFiles = append(fixableFiles, p)
-			for _, key := range legacy {
-				warnings = append(warnings, fmt.Sprintf("%s: deprecated key %q — rename to %q (fixable)", name, key, config.LegacyKeyReplacement(key)))
-			}
-		}
+// flattenConfig turns a resolved config into dotted keys such as
+// "scripts.setup", with each leaf value formatted as JSON.
+func flattenConfig(resolved map[string]interface{}) (map[string]string, error) {
+	data, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
 	}
 
-	// Check script paths
-	for name, script := range map[string]string{
-		"setup":   cfg.Scripts.Setup,
-		"run":     cfg.Scripts.Run,
-		"archive": cfg.Scripts.Archive,
-	} {
-		if script == "" {
-			continue
-		}
-		parts := strings.Fields(script)
-		if _, err := exec.LookPath(parts[0]); err != nil {
-			// Check relative to rootPath
-			if _, err := os.Stat(fmt.Sprintf("%s/%s", rootPath, parts[0])); err != nil {
-				warnings = append(warnings, fmt.Sprintf("scripts.%s: %q not found in $PATH or repo", name, parts[0]))
+	values := make(map[string]string)
+	var walk func(prefix string, v interface{}) error
+	walk = func(prefix string, v interface{}) error {
+		if obj, ok := v.(map[string]interface{}); ok && len(obj) > 0 {
+			for k, child := range obj {
+				if err := walk(prefix+"."+k, child); err != nil {
+					return err
+				}
 			}
+			return nil
 		}
-	}
-
-	// Check worktree path writable
-	wtPath := config.ResolveWorktreePath(cfg, rootPath)
-	if info, err := os.Stat(wtPath); err == nil {
-		if !info.IsDir() {
-			configErrors = append(configErrors, fmt.Sprintf("worktree_path: %q exists but is not a directory", wtPath))
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return err
 		}
+		values[strings.TrimPrefix(prefix, ".")] = strings.TrimSpace(buf.String())
+		return nil
 	}
-	// Parent must exist or be creatable — not an error if it doesn't exist yet
-
-	// Check port ranges
-	if cfg.BasePort < 1024 {
-		warnings = append(warnings, fmt.Sprintf("base_port: %d is a privileged port (< 1024)", cfg.BasePort))
+	if err := walk("", tree); err != nil {
+		return nil, err
 	}
-	if cfg.BasePort > 65535 {
-		configErrors = append(configErrors, fmt.Sprintf("base_port: %d is out of range (> 65535)", cfg.BasePort))
+	return values, nil
+}
+
+func runConfigDoctor(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
 	}
-	if cfg.PortRange < 1 {
-		configErrors = append(configErrors, fmt.Sprintf("port_range: %d must be at least 1", cfg.PortRange))
+
+	rootPath, err := git.RootWorktreePath(cwd)
+	if err != nil {
+		return fmt.Errorf("not inside a git repository (run from a repo or use --repo <name>)")
 	}
-	if cfg.BasePort+cfg.PortRange*100 > 65535 {
-		warnings = append(warnings, fmt.Sprintf("base_port %d + port_range %d may exhaust available ports with many workspaces", cfg.BasePort, cfg.PortRange))
+
+	cfg, err := loadConfig(rootPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
 	}
 
+	configErrors, warnings, fixableFiles := checkConfig(rootPath, cfg)
+
 	// Handle --fix
 	var fixed []string
 	if doctorFix && len(fixableFiles) > 0 {
@@ -282,9 +344,147 @@ func runConfigDoctor(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// checkConfig runs the fr8 config doctor checks on the repo at rootPath. It
+// returns the errors, the warnings and the config files with legacy keys that
+// --fix can migrate.
+func checkConfig(rootPath string, cfg *config.Config) (configErrors, warnings, fixableFiles []string) {
+	// Check for deprecated camelCase keys
+	for _, name := range append(slices.Clone(config.FileNames), config.LocalFileName) {
+		p := filepath.Join(rootPath, name)
+		if legacy := config.HasLegacyKeys(p); len(legacy) > 0 {
+			fixableFiles = append(fixableFiles, p)
+			for _, key := range legacy {
+				warnings = append(warnings, fmt.Sprintf("%s: deprecated key %q — rename to %q (fixable)", name, key, config.LegacyKeyReplacement(key)))
+			}
+		}
+	}
+
+	// Check script paths
+	for name, script := range map[string]string{
+		"setup":   cfg.Scripts.Setup,
+		"run":     cfg.Scripts.Run,
+		"archive": cfg.Scripts.Archive,
+	} {
+		if script == "" {
+			continue
+		}
+		parts := strings.Fields(script)
+		if _, err := exec.LookPath(parts[0]); err != nil {
+			// Check relative to rootPath
+			if _, err := os.Stat(fmt.Sprintf("%s/%s", rootPath, parts[0])); err != nil {
+				warnings = append(warnings, fmt.Sprintf("scripts.%s: %q not found in $PATH or repo", name, parts[0]))
+			}
+		}
+	}
+
+	// Check worktree path writable
+	wtPath := config.ResolveWorktreePath(cfg, rootPath)
+	if info, err := os.Stat(wtPath); err == nil {
+		if !info.IsDir() {
+			configErrors = append(configErrors, fmt.Sprintf("worktree_path: %q exists but is not a directory", wtPath))
+		}
+	}
+	// Parent must exist or be creatable — not an error if it doesn't exist yet
+
+	// Check port ranges
+	if cfg.BasePort < 1024 {
+		warnings = append(warnings, fmt.Sprintf("base_port: %d is a privileged port (< 1024)", cfg.BasePort))
+	}
+	if cfg.BasePort > 65535 {
+		configErrors = append(configErrors, fmt.Sprintf("base_port: %d is out of range (> 65535)", cfg.BasePort))
+	}
+	if cfg.PortRange < 1 {
+		configErrors = append(configErrors, fmt.Sprintf("port_range: %d must be at least 1", cfg.PortRange))
+	}
+
+	for _, err := range cfg.ValidatePorts() {
+		configErrors = append(configErrors, err.Error())
+	}
+	for _, err := range cfg.ValidateRestart() {
+		configErrors = append(configErrors, err.Error())
+	}
+	for _, err := range cfg.ValidateHealthcheck() {
+		configErrors = append(configErrors, err.Error())
+	}
+	for _, err := range cfg.ValidateTTL() {
+		configErrors = append(configErrors, err.Error())
+	}
+	for _, err := range cfg.ValidateEnv() {
+		configErrors = append(configErrors, err.Error())
+	}
+	for _, err := range cfg.ValidateServices() {
+		configErrors = append(configErrors, err.Error())
+	}
+
+	if len(cfg.Services) > 0 && cfg.Scripts.Run != "" {
+		warnings = append(warnings, "scripts.run: ignored because services are defined")
+	}
+	warnings = append(warnings, checkWorktrees(rootPath)...)
+	warnings = append(warnings, checkIncludeFile(rootPath)...)
+	if cfg.BasePort+cfg.PortRange*100 > 65535 {
+		warnings = append(warnings, fmt.Sprintf("base_port %d + port_range %d may exhaust available ports with many workspaces", cfg.BasePort, cfg.PortRange))
+	}
+	return configErrors, warnings, fixableFiles
+}
+
 func orEmpty(s []string) []string {
 	if s == nil {
 		return []string{}
 	}
 	return s
 }
+
+// checkWorktrees warns about discrepancies between the repo's workspaces and
+// its git worktrees (see fr8 repo reconcile).
+func checkWorktrees(rootPath string) []string {
+	worktrees, err := git.WorktreeList(rootPath)
+	if err != nil {
+		return nil
+	}
+	var workspaces []registry.Workspace
+	if regPath, err := registry.DefaultPath(); err == nil {
+		if reg, err := registry.Load(regPath); err == nil {
+			if repo := reg.FindByPath(rootPath); repo != nil {
+				workspaces = repo.Workspaces
+			}
+		}
+	}
+
+	var warnings []string
+	for _, issue := range diagnoseWorktrees(rootPath, worktrees, workspaces) {
+		hint := "run: " + issue.Fix
+		if issue.Fixable {
+			hint = "fixable with: fr8 repo reconcile --fix"
+		}
+		if issue.Workspace != "" {
+			warnings = append(warnings, fmt.Sprintf("workspace %q: %s (%s)", issue.Workspace, issue.Detail, hint))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("worktree %s: %s (%s)", issue.Path, issue.Detail, hint))
+		}
+	}
+	return warnings
+}
+
+// checkIncludeFile warns about .worktreeinclude rules that are invalid or
+// match nothing in the root worktree.
+func checkIncludeFile(rootPath string) []string {
+	includeFile := filesync.FindIncludeFile(rootPath)
+	if includeFile == "" {
+		return nil
+	}
+	rules, err := filesync.ParseFile(includeFile)
+	if err != nil {
+		return []string{fmt.Sprintf(".worktreeinclude: %v", err)}
+	}
+
+	var warnings []string
+	for _, rule := range rules {
+		if !rule.Valid() {
+			warnings = append(warnings, fmt.Sprintf(".worktreeinclude: invalid pattern %q", rule.String()))
+		}
+	}
+	for _, rule := range filesync.Unmatched(rootPath, rules) {
+		warnings = append(warnings, fmt.Sprintf(".worktreeinclude: %q matches nothing in %s", rule.String(), shortenHomePath(rootPath)))
+	}
+	return warnings
+}
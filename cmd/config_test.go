@@ -18,3 +18,18 @@ func TestConfigDir(t *testing.T) {
 		t.Errorf("configDir() = %q, want %q", dir, want)
 	}
 }
+
+func TestCheckIncludeFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("A=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".worktreeinclude"), []byte(".env*\n!.env.test\nconfig/master.key\n[oops\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// [oops is invalid; !.env.test and config/master.key match nothing
+	if warnings := checkIncludeFile(root); len(warnings) != 3 {
+		t.Errorf("checkIncludeFile() = %v, want 3 warnings", warnings)
+	}
+}
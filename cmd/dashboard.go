@@ -12,6 +12,7 @@ import (
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/jsonout"
 	"github.com/protocollar/fr8/internal/opener"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
 	"github.com/protocollar/fr8/internal/tui"
 )
@@ -47,7 +48,9 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 			ws := result.ShellWorkspace
 			rootPath := result.RootPath
 			defaultBranch, _ := git.DefaultBranch(rootPath)
-			envVars := env.Build(ws, rootPath, defaultBranch)
+			cfg, _ := loadConfig(rootPath)
+			envVars, envErr := env.Build(ws, rootPath, defaultBranch, cfg)
+			warnEnv(envErr)
 
 			userShell := os.Getenv("SHELL")
 			if userShell == "" {
@@ -79,7 +82,7 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 			ws := result.AttachWorkspace
 			rootPath := result.RootPath
 			sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
-			if err := tmux.AttachRun(sessionName); err != nil {
+			if err := runner.Default().AttachRun(sessionName); err != nil {
 				return err
 			}
 			continue
@@ -104,7 +107,7 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 		}
 
 		if result.CreateRequested {
-			ws, err := createWorkspace(result.RootPath, result.CreateName, "", false, true, false)
+			ws, err := createWorkspace(result.RootPath, result.CreateName, "", false, true, false, workspaceSource{createdBy: "fr8 dashboard"})
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error creating workspace: %v\n", err)
 			} else {
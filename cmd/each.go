@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/env"
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
+	"github.com/protocollar/fr8/internal/tmux"
+)
+
+var eachAllRepos bool
+var eachRunning bool
+var eachDirty bool
+var eachMerged bool
+var eachJobs int
+
+func init() {
+	eachCmd.Flags().BoolVar(&eachAllRepos, "all-repos", false, "run in workspaces across all registered repos")
+	eachCmd.Flags().BoolVar(&eachRunning, "running", false, "only workspaces with a running session")
+	eachCmd.Flags().BoolVar(&eachDirty, "dirty", false, "only workspaces with uncommitted changes")
+	eachCmd.Flags().BoolVar(&eachMerged, "merged", false, "only workspaces whose branch is merged")
+	eachCmd.Flags().IntVarP(&eachJobs, "jobs", "j", runtime.NumCPU(), "number of workspaces to run in at once")
+	workspaceCmd.AddCommand(eachCmd)
+}
+
+var eachCmd = &cobra.Command{
+	Use:   "each [flags] -- <command>",
+	Short: "Run a command in every workspace in parallel",
+	Long: `Runs a command in every workspace of the current repo (or --repo), in
+parallel, with the workspace's environment variables set. Each line of output
+is prefixed with the workspace name, followed by a summary of exit codes.
+
+With --json, output is captured and returned per workspace instead.
+Exits non-zero if the command fails in any workspace.`,
+	Example: `  fr8 ws each -- git pull
+  fr8 ws each --dirty -- git status --short
+  fr8 ws each --all-repos -j 4 -- bundle install`,
+	RunE: runEach,
+}
+
+// eachTarget is a workspace that fr8 ws each runs in.
+type eachTarget struct {
+	repo          string
+	rootPath      string
+	defaultBranch string
+	cfg           *config.Config
+	ws            registry.Workspace
+}
+
+// label names the target in output: the workspace name, or repo/workspace
+// with --all-repos.
+func (t eachTarget) label() string {
+	if eachAllRepos {
+		return t.repo + "/" + t.ws.Name
+	}
+	return t.ws.Name
+}
+
+type eachResult struct {
+	Repo       string `json:"repo"`
+	Workspace  string `json:"workspace"`
+	Path       string `json:"path"`
+	ExitCode   int    `json:"exit_code"`
+	DurationMS int64  `json:"duration_ms"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	Error      string `json:"error,omitempty"`
+}
+
+func runEach(cmd *cobra.Command, args []string) error {
+	if cmd.ArgsLenAtDash() != 0 || len(args) == 0 {
+		return fmt.Errorf("usage: fr8 ws each [flags] -- <command>")
+	}
+	if eachAllRepos && resolveRepo != "" {
+		return fmt.Errorf("cannot use --all-repos with --repo")
+	}
+	if eachJobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
+	command := strings.Join(args, " ")
+
+	targets, err := eachTargets()
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		if jsonout.Enabled {
+			return jsonout.Write([]eachResult{})
+		}
+		fmt.Println("No matching workspaces.")
+		return nil
+	}
+
+	width := 0
+	for _, t := range targets {
+		width = max(width, len(t.label()))
+	}
+
+	var mu sync.Mutex
+	results := make([]eachResult, len(targets))
+	sem := make(chan struct{}, eachJobs)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var stdout, stderr bytes.Buffer
+			var out, errOut io.Writer = &stdout, &stderr
+			if !jsonout.Enabled {
+				prefix := fmt.Sprintf("%-*s | ", width, t.label())
+				pw := &prefixWriter{w: os.Stdout, prefix: prefix, mu: &mu}
+				pe := &prefixWriter{w: os.Stderr, prefix: prefix, mu: &mu}
+				defer pw.Flush()
+				defer pe.Flush()
+				out, errOut = pw, pe
+			}
+
+			start := time.Now()
+			code, err := runInWorkspace(cmd.Context(), &t.ws, t.rootPath, t.defaultBranch, t.cfg, command, out, errOut)
+			results[i] = eachResult{
+				Repo:       t.repo,
+				Workspace:  t.ws.Name,
+				Path:       t.ws.Path,
+				ExitCode:   code,
+				DurationMS: time.Since(start).Milliseconds(),
+				Stdout:     stdout.String(),
+				Stderr:     stderr.String(),
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.ExitCode != 0 {
+			failed++
+		}
+	}
+
+	if jsonout.Enabled {
+		if err := jsonout.Write(results); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "WORKSPACE\tSTATUS\tDURATION")
+		for i, r := range results {
+			status := "ok"
+			switch {
+			case r.Error != "":
+				status = "error: " + r.Error
+			case r.ExitCode != 0:
+				status = fmt.Sprintf("exit %d", r.ExitCode)
+			}
+			d := time.Duration(r.DurationMS) * time.Millisecond
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", targets[i].label(), status, d.Round(100*time.Millisecond))
+		}
+		_ = w.Flush()
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("command failed in %d of %d workspace(s)", failed, len(results))
+	}
+	return nil
+}
+
+// eachTargets returns the workspaces matching the fr8 ws each flags: those
+// of the current repo, the --repo repo, or every repo with --all-repos.
+func eachTargets() ([]eachTarget, error) {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading registry: %w", err)
+	}
+
+	var repos []registry.Repo
+	switch {
+	case eachAllRepos:
+		repos = reg.Repos
+	case resolveRepo != "":
+		repo := reg.Find(resolveRepo)
+		if repo == nil {
+			return nil, fmt.Errorf("repo %q not found in registry (see repos: fr8 repo list)", resolveRepo)
+		}
+		repos = []registry.Repo{*repo}
+	default:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		repo := reg.FindRepoByWorkspacePath(cwd)
+		if repo == nil {
+			if rootPath, err := git.RootWorktreePath(cwd); err == nil {
+				repo = reg.FindByPath(rootPath)
+			}
+		}
+		if repo == nil {
+			return nil, fmt.Errorf("not inside a registered repo (use --repo <name> or --all-repos)")
+		}
+		repos = []registry.Repo{*repo}
+	}
+
+	runningSessions := make(map[string]bool)
+	if eachRunning {
+		if rn := runner.Default(); rn.Available() == nil {
+			sessions, _ := rn.List()
+			for _, s := range sessions {
+				runningSessions[s.Name] = true
+			}
+		}
+	}
+
+	var targets []eachTarget
+	for _, repo := range repos {
+		rootPath, err := git.RootWorktreePath(repo.Path)
+		if err != nil {
+			rootPath = repo.Path
+		}
+		defaultBranch, _ := git.DefaultBranch(rootPath)
+		cfg, _ := loadConfig(rootPath)
+
+		for _, ws := range repo.Workspaces {
+			if eachRunning && !runningSessions[tmux.SessionName(tmux.RepoName(rootPath), ws.Name)] {
+				continue
+			}
+			if eachDirty {
+				dc, _ := git.DirtyStatus(ws.Path)
+				if !dc.Dirty() {
+					continue
+				}
+			}
+			if eachMerged {
+				// Same selection as fr8 ws gc --merged
+				branch, err := git.CurrentBranch(ws.Path)
+				if err != nil || !gcIsMerged(&ws, branch, defaultBranch) {
+					continue
+				}
+			}
+			targets = append(targets, eachTarget{
+				repo:          repo.Name,
+				rootPath:      rootPath,
+				defaultBranch: defaultBranch,
+				cfg:           cfg,
+				ws:            ws,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// runInWorkspace runs command with sh -c in a workspace's directory and
+// environment, returning its exit code. The command is killed when ctx is
+// done. err is set only if the command could not be started (exit code -1).
+func runInWorkspace(ctx context.Context, ws *registry.Workspace, rootPath, defaultBranch string, cfg *config.Config, command string, stdout, stderr io.Writer) (int, error) {
+	shell, err := shellPath()
+	if err != nil {
+		return -1, err
+	}
+	c := exec.CommandContext(ctx, shell, "-c", command)
+	// Don't wait forever on output from background children of a killed command.
+	c.WaitDelay = time.Second
+	c.Dir = ws.Path
+	envVars, envErr := env.Build(ws, rootPath, defaultBranch, cfg)
+	// Written to the command's stderr so captured output (ws exec --capture,
+	// workspace_exec) carries it too
+	for _, w := range envWarnings(envErr) {
+		_, _ = fmt.Fprintf(stderr, "Warning: %s (see: fr8 config doctor)\n", w)
+	}
+	c.Env = envVars
+	c.Stdout = stdout
+	c.Stderr = stderr
+
+	err = c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+// prefixWriter writes each complete line to w with prefix, holding mu so
+// lines from concurrent writers don't interleave. Call Flush to write a
+// trailing partial line.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.writeLine(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes any buffered partial line, ending it with a newline.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = io.WriteString(p.w, p.prefix)
+	_, _ = p.w.Write(line)
+}
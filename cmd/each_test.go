@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	pw := &prefixWriter{w: &out, prefix: "alpha | ", mu: &sync.Mutex{}}
+
+	_, _ = pw.Write([]byte("one\ntw"))
+	_, _ = pw.Write([]byte("o\nthree"))
+	if got, want := out.String(), "alpha | one\nalpha | two\n"; got != want {
+		t.Errorf("before Flush = %q, want %q", got, want)
+	}
+
+	pw.Flush()
+	if got, want := out.String(), "alpha | one\nalpha | two\nalpha | three\n"; got != want {
+		t.Errorf("after Flush = %q, want %q", got, want)
+	}
+}
+
+func TestRunInWorkspace(t *testing.T) {
+	ws := &registry.Workspace{Name: "alpha", Path: t.TempDir(), Port: 5000}
+
+	var stdout, stderr bytes.Buffer
+	code, err := runInWorkspace(context.Background(), ws, "/tmp/repo", "main", nil, `echo "$FR8_WORKSPACE_NAME $FR8_PORT $PWD"; echo oops >&2; exit 3`, &stdout, &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 3 {
+		t.Errorf("exit code = %d, want 3", code)
+	}
+	if got := strings.TrimSpace(stdout.String()); !strings.HasPrefix(got, "alpha 5000 ") {
+		t.Errorf("stdout = %q, want workspace env", got)
+	}
+	if got := stderr.String(); got != "oops\n" {
+		t.Errorf("stderr = %q, want %q", got, "oops\n")
+	}
+}
+
+func TestRunInWorkspaceReportsEnvThatFailsToExpand(t *testing.T) {
+	ws := &registry.Workspace{Name: "alpha", Path: t.TempDir(), Port: 5000}
+	cfg := &config.Config{Env: map[string]string{
+		"BAD":   "{{.Ports.missing}}",
+		"WORSE": "{{.Nope}}",
+	}}
+
+	var stdout, stderr bytes.Buffer
+	if _, err := runInWorkspace(context.Background(), ws, "/tmp/repo", "main", cfg, "true", &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	got := stderr.String()
+	if strings.Count(got, "Warning: ") != 2 || !strings.Contains(got, "env.BAD") || !strings.Contains(got, "env.WORSE") {
+		t.Errorf("stderr = %q, want a warning for each of env.BAD and env.WORSE", got)
+	}
+}
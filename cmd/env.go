@@ -2,22 +2,44 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/env"
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+var (
+	envFormat string
+	envWrite  string
 )
 
 func init() {
+	envCmd.Flags().StringVar(&envFormat, "format", "sh", "output format: "+strings.Join(env.Formats, ", "))
+	envCmd.Flags().StringVar(&envWrite, "write", "", "write a dotenv file into the workspace (path relative to it), rewritten on rename")
+	_ = envCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(env.Formats, cobra.ShellCompDirectiveNoFileComp))
 	workspaceCmd.AddCommand(envCmd)
 }
 
 var envCmd = &cobra.Command{
-	Use:               "env [name]",
-	Short:             "Print workspace environment variables as export statements",
-	Long:              "Prints FR8_* and CONDUCTOR_* variables suitable for eval \"$(fr8 ws env)\".",
+	Use:   "env [name]",
+	Short: "Print workspace environment variables as export statements",
+	Long: `Prints FR8_* and CONDUCTOR_* variables, and those from the env section of fr8.json,
+suitable for eval "$(fr8 ws env)". Use --format for other shells and tools:
+
+  sh      export statements for bash and zsh (default)
+  fish    fr8 ws env --format fish | source
+  nu      $env assignments for Nushell
+  dotenv  a .env file
+  json    a JSON object (same as --json)
+  docker  an env file for docker run --env-file
+
+--write materialises a dotenv file in the workspace and records it, so
+fr8 ws rename and fr8 ws restore keep it up to date.`,
 	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: workspaceNameCompletion,
 	RunE:              runEnv,
@@ -29,33 +51,140 @@ func runEnv(cmd *cobra.Command, args []string) error {
 		name = args[0]
 	}
 
+	format := envFormat
+	if jsonout.Enabled && !cmd.Flags().Changed("format") {
+		format = "json"
+	}
+
 	ws, rootPath, err := resolveWorkspace(name)
 	if err != nil {
 		return err
 	}
 
+	if envWrite != "" {
+		if cmd.Flags().Changed("format") && envFormat != "dotenv" {
+			return fmt.Errorf("--write always writes a dotenv file; it can't be combined with --format %s", envFormat)
+		}
+		return runEnvWrite(ws, rootPath, envWrite)
+	}
+
 	defaultBranch, _ := git.DefaultBranch(rootPath)
 
-	vars := env.BuildFr8Only(ws, rootPath, defaultBranch)
+	cfg, _ := loadConfig(rootPath)
+	vars, envErr := env.BuildFr8Only(ws, rootPath, defaultBranch, cfg)
+	warnEnv(envErr)
 
-	if jsonout.Enabled {
-		// Output FR8_* vars only as a map (skip CONDUCTOR_* compat vars)
-		envMap := make(map[string]string)
-		for _, v := range vars {
-			parts := strings.SplitN(v, "=", 2)
-			if len(parts) == 2 && strings.HasPrefix(parts[0], "FR8_") {
-				envMap[parts[0]] = parts[1]
-			}
-		}
-		return jsonout.Write(envMap)
+	out, err := env.Format(vars, format)
+	if err != nil {
+		return err
 	}
+	fmt.Print(out)
+	return nil
+}
 
-	for _, v := range vars {
-		parts := strings.SplitN(v, "=", 2)
-		if len(parts) == 2 {
-			fmt.Printf("export %s=%q\n", parts[0], parts[1])
+// runEnvWrite writes the workspace's dotenv file to file, relative to the
+// workspace, and records it in the registry so renames rewrite it.
+func runEnvWrite(ws *registry.Workspace, rootPath, file string) error {
+	rel := file
+	if filepath.IsAbs(rel) {
+		r, err := filepath.Rel(ws.Path, rel)
+		if err != nil {
+			return err
 		}
+		rel = r
+	}
+	rel = filepath.Clean(rel)
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is not a file inside the workspace (%s)", file, ws.Path)
+	}
+
+	ws.EnvFile = rel
+	if err := writeEnvFile(ws, rootPath); err != nil {
+		return err
+	}
+
+	_, err := updateWorkspace(rootPath, ws.Name, func(w *registry.Workspace) error {
+		w.EnvFile = rel
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(ws.Path, rel)
+	if jsonout.Enabled {
+		return jsonout.Write(struct {
+			Action    string `json:"action"`
+			Workspace string `json:"workspace"`
+			Path      string `json:"path"`
+		}{Action: "written", Workspace: ws.Name, Path: path})
 	}
+	fmt.Printf("Wrote %s\n", shortenHomePath(path))
+	return nil
+}
 
+// writeEnvFile writes the workspace's variables to its EnvFile in dotenv
+// format. It does nothing if the workspace has no EnvFile.
+func writeEnvFile(ws *registry.Workspace, rootPath string) error {
+	if ws.EnvFile == "" {
+		return nil
+	}
+	defaultBranch, _ := git.DefaultBranch(rootPath)
+	cfg, _ := loadConfig(rootPath)
+	vars, envErr := env.BuildFr8Only(ws, rootPath, defaultBranch, cfg)
+	warnEnv(envErr)
+	out, err := env.Format(vars, "dotenv")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(ws.Path, ws.EnvFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	data := "# Written by fr8 ws env --write; rewritten when the workspace is renamed or restored.\n" + out
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		return fmt.Errorf("writing env file: %w", err)
+	}
 	return nil
 }
+
+// refreshEnvFile rewrites the workspace's EnvFile after its name, path or
+// port changed. A file the user has deleted is left deleted.
+func refreshEnvFile(ws *registry.Workspace, rootPath string) {
+	if ws.EnvFile == "" {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(ws.Path, ws.EnvFile)); err != nil {
+		return
+	}
+	if err := writeEnvFile(ws, rootPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// warnEnv prints the error of env.Build or env.BuildFr8Only, naming the env
+// variables of fr8.json that failed to expand and were left out.
+func warnEnv(err error) {
+	for _, w := range envWarnings(err) {
+		fmt.Fprintf(os.Stderr, "Warning: %s (see: fr8 config doctor)\n", w)
+	}
+}
+
+// envWarnings splits the error of env.Build or env.BuildFr8Only into one
+// message per variable, for JSON output and MCP results.
+func envWarnings(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else {
+		errs = []error{err}
+	}
+	warnings := make([]string, 0, len(errs))
+	for _, e := range errs {
+		warnings = append(warnings, e.Error())
+	}
+	return warnings
+}
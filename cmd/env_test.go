@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func TestWriteEnvFile(t *testing.T) {
+	t.Setenv("FR8_CONFIG_DIR", t.TempDir())
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	ws := &registry.Workspace{Name: "old", Path: t.TempDir(), Port: 5000, EnvFile: filepath.Join("config", ".env.fr8")}
+
+	if err := writeEnvFile(ws, root); err != nil {
+		t.Fatalf("writeEnvFile: %v", err)
+	}
+	path := filepath.Join(ws.Path, ws.EnvFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\nFR8_WORKSPACE_NAME=old\n") {
+		t.Errorf("env file missing FR8_WORKSPACE_NAME:\n%s", data)
+	}
+
+	ws.Name = "new"
+	refreshEnvFile(ws, root)
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), "\nFR8_WORKSPACE_NAME=new\n") {
+		t.Errorf("env file not rewritten after rename:\n%s", data)
+	}
+
+	// A deleted env file stays deleted
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	refreshEnvFile(ws, root)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected deleted env file to stay deleted, got %v", err)
+	}
+}
@@ -1,19 +1,29 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/env"
 	"github.com/protocollar/fr8/internal/exitcode"
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
 )
 
+var execCapture bool
+var execTimeout time.Duration
+
 func init() {
+	execCmd.Flags().BoolVar(&execCapture, "capture", false, "run as a child process and report its exit code and output")
+	execCmd.Flags().DurationVar(&execTimeout, "timeout", 0, "kill the command after this long (with --capture)")
 	workspaceCmd.AddCommand(execCmd)
 }
 
@@ -23,28 +33,39 @@ var execCmd = &cobra.Command{
 	Long: `Runs an arbitrary command with workspace environment variables set.
 The workspace name is optional if you're inside a workspace directory.
 
+By default fr8 is replaced by the command, which takes over the terminal.
+With --capture, the command runs as a child process instead: its output is
+printed when it finishes and fr8 exits with its exit code. With --json, the
+exit code, stdout, stderr and duration are returned as JSON.
+
 Examples:
   fr8 ws exec myws -- bundle exec rails c
   fr8 ws exec -- npm test
+  fr8 ws exec myws --capture --timeout 5m --json -- bin/rails test
   cd /path/to/workspace && fr8 ws exec -- make build`,
 	Args:               cobra.MinimumNArgs(1),
 	DisableFlagParsing: true,
 	RunE:               runExec,
 }
 
-func runExec(cmd *cobra.Command, args []string) error {
-	if jsonout.Enabled {
-		return &exitcode.ExitError{
-			Err:      fmt.Errorf("exec requires an interactive terminal and cannot be used with --json"),
-			ExitCode: exitcode.InteractiveOnly,
-			Code:     "interactive_only",
-		}
-	}
-
-	// Parse: either "exec -- <cmd>" or "exec <name> -- <cmd>"
-	var wsName string
-	var command []string
+// execResult is the outcome of a command run with fr8 ws exec --capture or
+// the workspace_exec MCP tool.
+type execResult struct {
+	Workspace       string `json:"workspace"`
+	Command         string `json:"command"`
+	ExitCode        int    `json:"exit_code"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	DurationMS      int64  `json:"duration_ms"`
+	TimedOut        bool   `json:"timed_out,omitempty"`
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+}
 
+func runExec(cmd *cobra.Command, args []string) error {
+	// Parse: either "exec [flags] -- <cmd>" or "exec [flags] <name> -- <cmd>".
+	// Flag parsing is disabled so the command's own flags pass through, so
+	// the flags before -- are parsed here.
 	dashIdx := -1
 	for i, a := range args {
 		if a == "--" {
@@ -54,23 +75,58 @@ func runExec(cmd *cobra.Command, args []string) error {
 	}
 
 	if dashIdx == -1 {
+		if len(args) == 1 && (args[0] == "-h" || args[0] == "--help") {
+			return cmd.Help()
+		}
 		return fmt.Errorf("usage: fr8 ws exec [name] -- <command>")
 	}
-	if dashIdx > 0 {
-		wsName = args[0]
+	cmd.DisableFlagParsing = false
+	err := cmd.ParseFlags(args[:dashIdx])
+	cmd.DisableFlagParsing = true
+	if err != nil {
+		return err
 	}
-	command = args[dashIdx+1:]
+	if help, _ := cmd.Flags().GetBool("help"); help {
+		return cmd.Help()
+	}
+	if err := applyOutputFlags(); err != nil {
+		return err
+	}
+
+	var wsName string
+	switch pos := cmd.Flags().Args(); len(pos) {
+	case 0:
+	case 1:
+		wsName = pos[0]
+	default:
+		return fmt.Errorf("usage: fr8 ws exec [name] -- <command>")
+	}
+	command := args[dashIdx+1:]
 	if len(command) == 0 {
 		return fmt.Errorf("no command specified after --")
 	}
 
+	if execCapture {
+		return runExecCapture(cmd.Context(), wsName, strings.Join(command, " "))
+	}
+
+	if jsonout.Enabled {
+		return &exitcode.ExitError{
+			Err:      fmt.Errorf("exec requires an interactive terminal and cannot be used with --json (use --capture)"),
+			ExitCode: exitcode.InteractiveOnly,
+			Code:     "interactive_only",
+		}
+	}
+
 	ws, rootPath, err := resolveWorkspace(wsName)
 	if err != nil {
 		return err
 	}
 
 	defaultBranch, _ := git.DefaultBranch(rootPath)
-	envVars := env.Build(ws, rootPath, defaultBranch)
+	cfg, _ := loadConfig(rootPath)
+	envVars, envErr := env.Build(ws, rootPath, defaultBranch, cfg)
+	warnEnv(envErr)
 
 	if err := os.Chdir(ws.Path); err != nil {
 		return fmt.Errorf("changing to workspace directory: %w", err)
@@ -82,3 +138,76 @@ func runExec(cmd *cobra.Command, args []string) error {
 	}
 	return syscall.Exec(shell, []string{"sh", "-c", strings.Join(command, " ")}, envVars)
 }
+
+func runExecCapture(ctx context.Context, wsName, command string) error {
+	ws, rootPath, err := resolveWorkspace(wsName)
+	if err != nil {
+		return err
+	}
+
+	res, err := captureInWorkspace(ctx, ws, rootPath, command, execTimeout, 0)
+	if err != nil {
+		return err
+	}
+
+	if jsonout.Enabled {
+		return jsonout.Write(res)
+	}
+
+	_, _ = os.Stdout.WriteString(res.Stdout)
+	_, _ = os.Stderr.WriteString(res.Stderr)
+	switch {
+	case res.TimedOut:
+		return exitcode.New("timeout", exitcode.GeneralError, fmt.Sprintf("command timed out after %s", execTimeout))
+	case res.ExitCode != 0:
+		code := res.ExitCode
+		if code < 0 {
+			code = exitcode.GeneralError
+		}
+		return exitcode.New("command_failed", code, fmt.Sprintf("command exited with code %d", res.ExitCode))
+	}
+	return nil
+}
+
+// captureInWorkspace runs command with sh -c in a workspace, capturing its
+// output. A positive timeout kills the command after that long; a positive
+// limit keeps only the last limit bytes of stdout and of stderr. err is set
+// only if the command could not be started.
+func captureInWorkspace(ctx context.Context, ws *registry.Workspace, rootPath, command string, timeout time.Duration, limit int) (execResult, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	defaultBranch, _ := git.DefaultBranch(rootPath)
+	cfg, _ := loadConfig(rootPath)
+
+	var stdout, stderr bytes.Buffer
+	start := time.Now()
+	code, err := runInWorkspace(ctx, ws, rootPath, defaultBranch, cfg, command, &stdout, &stderr)
+	if err != nil {
+		return execResult{}, err
+	}
+
+	res := execResult{
+		Workspace:  ws.Name,
+		Command:    command,
+		ExitCode:   code,
+		DurationMS: time.Since(start).Milliseconds(),
+		TimedOut:   errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+	res.Stdout, res.StdoutTruncated = truncateOutput(stdout.String(), limit)
+	res.Stderr, res.StderrTruncated = truncateOutput(stderr.String(), limit)
+	return res, nil
+}
+
+// truncateOutput keeps the last limit bytes of s, where failures usually
+// are, marking what was cut. A limit of 0 or less keeps everything.
+func truncateOutput(s string, limit int) (string, bool) {
+	if limit <= 0 || len(s) <= limit {
+		return s, false
+	}
+	cut := len(s) - limit
+	return fmt.Sprintf("[... %d bytes truncated ...]\n", cut) + s[cut:], true
+}
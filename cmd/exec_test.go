@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func TestTruncateOutput(t *testing.T) {
+	tests := []struct {
+		s     string
+		limit int
+		want  string
+		trunc bool
+	}{
+		{"hello", 0, "hello", false},
+		{"hello", 5, "hello", false},
+		{"hello world", 5, "[... 6 bytes truncated ...]\nworld", true},
+	}
+	for _, tt := range tests {
+		got, trunc := truncateOutput(tt.s, tt.limit)
+		if got != tt.want || trunc != tt.trunc {
+			t.Errorf("truncateOutput(%q, %d) = %q, %v; want %q, %v", tt.s, tt.limit, got, trunc, tt.want, tt.trunc)
+		}
+	}
+}
+
+func TestCaptureInWorkspace(t *testing.T) {
+	ws := &registry.Workspace{Name: "alpha", Path: t.TempDir(), Port: 5000}
+	root := t.TempDir()
+
+	res, err := captureInWorkspace(context.Background(), ws, root, `echo "$FR8_WORKSPACE_NAME"; echo 0123456789 >&2; exit 2`, 0, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Workspace != "alpha" || res.ExitCode != 2 || res.TimedOut {
+		t.Errorf("result = %+v, want workspace alpha, exit code 2", res)
+	}
+	if res.Stdout != "alpha\n" || res.StdoutTruncated {
+		t.Errorf("stdout = %q (truncated %v), want %q", res.Stdout, res.StdoutTruncated, "alpha\n")
+	}
+	if !strings.HasSuffix(res.Stderr, "789\n") || !res.StderrTruncated {
+		t.Errorf("stderr = %q (truncated %v), want tail of output", res.Stderr, res.StderrTruncated)
+	}
+
+	res, err = captureInWorkspace(context.Background(), ws, root, "sleep 5", 100*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.TimedOut || res.ExitCode == 0 {
+		t.Errorf("result = %+v, want timed out", res)
+	}
+}
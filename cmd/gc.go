@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
+	"github.com/protocollar/fr8/internal/tmux"
+)
+
+var gcMerged bool
+var gcOlderThan string
+var gcIdle string
+var gcDryRun bool
+var gcForce bool
+var gcKeepLogs bool
+var gcSoft bool
+
+func init() {
+	gcCmd.Flags().BoolVar(&gcMerged, "merged", false, "archive workspaces whose branch is merged")
+	gcCmd.Flags().StringVar(&gcOlderThan, "older-than", "", "archive workspaces created longer ago than this (e.g. 14d)")
+	gcCmd.Flags().StringVar(&gcIdle, "idle", "", "archive workspaces with no commits or runs for this long (e.g. 7d)")
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "show what would be archived without doing it")
+	gcCmd.Flags().BoolVarP(&gcForce, "force", "f", false, "skip confirmation and archive dirty or running workspaces too")
+	gcCmd.Flags().BoolVar(&gcKeepLogs, "keep-logs", false, "keep the archived workspaces' session logs")
+	gcCmd.Flags().BoolVar(&gcSoft, "soft", false, "save uncommitted changes so workspaces can be restored with fr8 ws restore")
+	workspaceCmd.AddCommand(gcCmd)
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Archive expired, merged or idle workspaces",
+	Long: `Archives the workspaces of the current repo (or --repo) that are no longer
+needed, the same way fr8 ws archive does: stopping the session, running the
+archive script and removing the worktree.
+
+A workspace is archived when its TTL has passed (ttl in fr8.json, or --ttl
+on fr8 ws new), or when it matches every one of --merged, --older-than and
+--idle that is given. A workspace is idle when it has had no new commits and
+no fr8 ws run for the given time.
+
+Pinned workspaces (fr8 ws pin) are never archived. Workspaces with
+uncommitted changes or a running session are skipped unless --force. With
+--soft, workspaces are soft-archived (see fr8 ws archive --soft) and
+uncommitted changes don't cause them to be skipped.`,
+	Example: `  fr8 ws gc --dry-run
+  fr8 ws gc --merged
+  fr8 ws gc --older-than 14d --idle 7d
+  fr8 ws gc --merged --force --json`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+// gcOptions selects the workspaces fr8 ws gc archives besides expired ones.
+type gcOptions struct {
+	merged    bool
+	olderThan time.Duration
+	idle      time.Duration
+}
+
+// gcFacts is what fr8 ws gc knows about a workspace.
+type gcFacts struct {
+	expiry     time.Time // zero if the workspace has no TTL
+	merged     bool
+	created    time.Time
+	lastActive time.Time
+}
+
+// reasons returns why a workspace should be archived at now, or nil if it
+// should be kept: it has expired, or it matches every selected filter.
+func (o gcOptions) reasons(f gcFacts, now time.Time) []string {
+	var reasons []string
+	if !f.expiry.IsZero() && !now.Before(f.expiry) {
+		reasons = append(reasons, "expired")
+	}
+	if !o.merged && o.olderThan == 0 && o.idle == 0 {
+		return reasons
+	}
+
+	var matched []string
+	if o.merged {
+		if !f.merged {
+			return reasons
+		}
+		matched = append(matched, "merged")
+	}
+	if o.olderThan > 0 {
+		age := now.Sub(f.created)
+		if f.created.IsZero() || age < o.olderThan {
+			return reasons
+		}
+		matched = append(matched, "created "+shortDuration(age)+" ago")
+	}
+	if o.idle > 0 {
+		idle := now.Sub(f.lastActive)
+		if idle < o.idle {
+			return reasons
+		}
+		matched = append(matched, "idle "+shortDuration(idle))
+	}
+	return append(reasons, matched...)
+}
+
+// shortDuration formats d in its largest whole unit, e.g. "9d" or "5h".
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// gcItem is a workspace fr8 ws gc selected, and what happened to it.
+type gcItem struct {
+	Workspace string   `json:"workspace"`
+	Branch    string   `json:"branch"`
+	Path      string   `json:"path"`
+	Reasons   []string `json:"reasons"`
+	Action    string   `json:"action"` // archived, would_archive, skipped or failed
+	Detail    string   `json:"detail,omitempty"`
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	opts := gcOptions{merged: gcMerged}
+	for _, f := range []struct {
+		flag  string
+		value string
+		dest  *time.Duration
+	}{
+		{"--older-than", gcOlderThan, &opts.olderThan},
+		{"--idle", gcIdle, &opts.idle},
+	} {
+		if f.value == "" {
+			continue
+		}
+		d, err := config.ParseDuration(f.value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid %s %q: must be a positive duration (e.g. 14d, 36h)", f.flag, f.value)
+		}
+		*f.dest = d
+	}
+
+	repo, err := currentRepo()
+	if err != nil {
+		return err
+	}
+	rootPath, err := git.RootWorktreePath(repo.Path)
+	if err != nil {
+		rootPath = repo.Path
+	}
+	cfg, err := loadConfig(rootPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	defaultBranch, _ := git.DefaultBranch(rootPath)
+
+	rn := runner.Default()
+	runnerOK := rn.Available() == nil
+	now := time.Now()
+
+	items := []gcItem{}
+	var targets []registry.Workspace
+	for _, ws := range repo.Workspaces {
+		sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
+		branch, _ := git.CurrentBranch(ws.Path)
+		facts := gcFacts{
+			expiry:  ws.Expiry(cfg.TTLDuration()),
+			created: ws.CreatedAt,
+		}
+		if opts.merged {
+			facts.merged = gcIsMerged(&ws, branch, defaultBranch)
+		}
+		if opts.idle > 0 {
+			facts.lastActive = lastActive(&ws, sessionName)
+		}
+		reasons := opts.reasons(facts, now)
+		if len(reasons) == 0 {
+			continue
+		}
+
+		item := gcItem{Workspace: ws.Name, Branch: branch, Path: ws.Path, Reasons: reasons, Action: "would_archive"}
+		if ws.Pinned {
+			item.Action, item.Detail = "skipped", "pinned"
+		} else if !gcForce {
+			if runnerOK && rn.IsRunning(sessionName) {
+				item.Action, item.Detail = "skipped", "running (use --force)"
+			} else if dirty, _ := git.HasUncommittedChanges(ws.Path); dirty && !gcSoft {
+				item.Action, item.Detail = "skipped", "uncommitted changes (use --force)"
+			}
+		}
+		if item.Action == "would_archive" {
+			targets = append(targets, ws)
+		}
+		items = append(items, item)
+	}
+
+	if len(targets) > 0 && !gcDryRun && !gcForce && isInteractive() {
+		fmt.Printf("Archive %d workspace(s)?\n", len(targets))
+		for _, item := range items {
+			if item.Action == "would_archive" {
+				fmt.Printf("  - %s (%s)\n", item.Workspace, strings.Join(item.Reasons, ", "))
+			}
+		}
+		fmt.Printf("\nContinue? [y/N] ")
+
+		var response string
+		_, _ = fmt.Scanln(&response)
+		if response != "y" && response != "Y" {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	failed := 0
+	if !gcDryRun {
+		for i := range items {
+			if items[i].Action != "would_archive" {
+				continue
+			}
+			ws := repo.FindWorkspace(items[i].Workspace)
+			_, _ = fmt.Fprintf(jsonout.MsgOut(), "Archiving %q...\n", ws.Name)
+			if _, err := archiveWorkspace(cfg, ws, rootPath, archiveOptions{keepLogs: gcKeepLogs, soft: gcSoft}); err != nil {
+				items[i].Action, items[i].Detail = "failed", err.Error()
+				failed++
+				continue
+			}
+			items[i].Action = "archived"
+		}
+	}
+
+	if jsonout.Enabled {
+		if err := jsonout.Write(items); err != nil {
+			return err
+		}
+	} else if len(items) == 0 {
+		fmt.Println("No workspaces to collect.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "WORKSPACE\tREASONS\tRESULT")
+		for _, item := range items {
+			result := strings.ReplaceAll(item.Action, "_", " ")
+			if item.Detail != "" {
+				result += ": " + item.Detail
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", item.Workspace, strings.Join(item.Reasons, ", "), result)
+		}
+		_ = w.Flush()
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to archive %d workspace(s)", failed)
+	}
+	return nil
+}
+
+// gcIsMerged reports whether a workspace's branch is merged into the default
+// branch. A new branch with no commits of its own is trivially merged, so it
+// doesn't count.
+func gcIsMerged(ws *registry.Workspace, branch, defaultBranch string) bool {
+	if branch == "" || defaultBranch == "" || branch == defaultBranch {
+		return false
+	}
+	if merged, _ := git.IsMerged(ws.Path, branch, defaultBranch); !merged {
+		return false
+	}
+	if o := ws.Origin; o != nil && o.Source == registry.SourceNew && o.BaseCommit != "" {
+		if head, err := git.HeadCommit(ws.Path); err == nil && head == o.BaseCommit {
+			return false
+		}
+	}
+	return true
+}
+
+// lastActive returns when a workspace was last used: the latest of its
+// creation, its last commit and its last fr8 ws run.
+func lastActive(ws *registry.Workspace, sessionName string) time.Time {
+	t := ws.CreatedAt
+	if c, err := git.LastCommit(ws.Path); err == nil && c.Time.After(t) {
+		t = c.Time
+	}
+	if s := runner.StartedAt(sessionName); s.After(t) {
+		t = s
+	}
+	return t
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGCReasons(t *testing.T) {
+	day := 24 * time.Hour
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	old := gcFacts{created: now.Add(-20 * day), lastActive: now.Add(-9 * day)}
+
+	tests := []struct {
+		name  string
+		opts  gcOptions
+		facts gcFacts
+		want  []string
+	}{
+		{"no filters", gcOptions{}, old, nil},
+		{"expired", gcOptions{}, gcFacts{expiry: now.Add(-time.Hour)}, []string{"expired"}},
+		{"not yet expired", gcOptions{}, gcFacts{expiry: now.Add(time.Hour)}, nil},
+		{"not merged", gcOptions{merged: true}, old, nil},
+		{"merged", gcOptions{merged: true}, gcFacts{merged: true}, []string{"merged"}},
+		{"older than", gcOptions{olderThan: 14 * day}, old, []string{"created 20d ago"}},
+		{"idle", gcOptions{idle: 7 * day}, old, []string{"idle 9d"}},
+		{"all filters must match", gcOptions{merged: true, idle: 7 * day}, old, nil},
+		{"expired and filters", gcOptions{idle: 7 * day}, gcFacts{expiry: now, lastActive: now.Add(-8 * day)}, []string{"expired", "idle 8d"}},
+		{"expired without filter match", gcOptions{merged: true}, gcFacts{expiry: now}, []string{"expired"}},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.reasons(tt.facts, now); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: reasons() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/env"
+	"github.com/protocollar/fr8/internal/hook"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func init() {
+	hookCmd.AddCommand(hookExportCmd)
+	rootCmd.AddCommand(hookCmd)
+}
+
+var hookCmd = &cobra.Command{
+	Use:   "hook [bash|zsh|fish]",
+	Short: "Print a shell hook that loads workspace environment variables on cd",
+	Long: `Prints a hook for your shell that exports a workspace's environment
+variables (those of fr8 ws env) when you cd into it, and restores the
+values they had before (or unsets them) when you leave. Add it to your
+shell's startup file:
+
+  # ~/.bashrc
+  eval "$(fr8 hook bash)"
+
+  # ~/.zshrc
+  eval "$(fr8 hook zsh)"
+
+  # ~/.config/fish/config.fish
+  fr8 hook fish | source`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: hook.Shells,
+	RunE:      runHook,
+}
+
+// hookExportCmd is run by the shell hook on every directory change.
+var hookExportCmd = &cobra.Command{
+	Use:    hook.ExportCommand + " <shell>",
+	Short:  "Print statements loading the current workspace's environment",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE:   runHookExport,
+}
+
+func runHook(cmd *cobra.Command, args []string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding fr8 executable: %w", err)
+	}
+	script, err := hook.Script(args[0], self)
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
+
+func runHookExport(cmd *cobra.Command, args []string) error {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return err
+	}
+	idx, err := hook.LoadIndex(regPath)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	ws, repo := idx.Lookup(cwd)
+	var wsPath string
+	var vars []string
+	if ws != nil {
+		wsPath = ws.Path
+	}
+	// Still in the workspace loaded last time (or still outside any)
+	if wsPath == os.Getenv(hook.DirVar) {
+		return nil
+	}
+	if ws != nil {
+		cfg, _ := loadConfig(repo.RootPath)
+		var envErr error
+		vars, envErr = env.BuildFr8Only(ws, repo.RootPath, repo.DefaultBranch, cfg)
+		warnEnv(envErr)
+	}
+
+	out, err := hook.Export(args[0], wsPath, vars, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
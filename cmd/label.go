@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func init() {
+	workspaceCmd.AddCommand(labelCmd)
+}
+
+var labelCmd = &cobra.Command{
+	Use:   "label <name> [add|rm <label>...]",
+	Short: "Show, add or remove a workspace's labels",
+	Long: `Shows a workspace's labels, or adds or removes them. Labels group
+workspaces for filtering with --label on fr8 ws list, fr8 ws run --all and
+fr8 ws stop --all, and with label:<name> in the dashboard filter.`,
+	Example: `  fr8 ws label bright-berlin add review
+  fr8 ws label bright-berlin rm review
+  fr8 ws label bright-berlin
+  fr8 ws list --label review`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: workspaceNameCompletion,
+	RunE:              runLabel,
+}
+
+func runLabel(cmd *cobra.Command, args []string) error {
+	ws, rootPath, err := resolveWorkspace(args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		if jsonout.Enabled {
+			return jsonout.Write(struct {
+				Workspace string   `json:"workspace"`
+				Labels    []string `json:"labels"`
+			}{Workspace: ws.Name, Labels: orEmpty(ws.Labels)})
+		}
+		for _, l := range ws.Labels {
+			fmt.Println(l)
+		}
+		return nil
+	}
+
+	op, labels := args[1], args[2:]
+	if op != "add" && op != "rm" {
+		return fmt.Errorf("unknown label action %q (use add or rm)", op)
+	}
+	if len(labels) == 0 {
+		return fmt.Errorf("usage: fr8 ws label <name> %s <label>...", op)
+	}
+	for _, l := range labels {
+		if err := registry.ValidateLabel(l); err != nil {
+			return err
+		}
+	}
+
+	var changed []string
+	updated, err := updateWorkspace(rootPath, ws.Name, func(w *registry.Workspace) error {
+		for _, l := range labels {
+			if (op == "add" && w.AddLabel(l)) || (op == "rm" && w.RemoveLabel(l)) {
+				changed = append(changed, l)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	action := "labeled"
+	if op == "rm" {
+		action = "unlabeled"
+	}
+	if jsonout.Enabled {
+		return jsonout.Write(struct {
+			Action    string   `json:"action"`
+			Workspace string   `json:"workspace"`
+			Changed   []string `json:"changed"`
+			Labels    []string `json:"labels"`
+		}{Action: action, Workspace: ws.Name, Changed: orEmpty(changed), Labels: orEmpty(updated.Labels)})
+	}
+
+	switch {
+	case len(changed) == 0 && op == "add":
+		fmt.Printf("%q already has those labels.\n", ws.Name)
+	case len(changed) == 0:
+		fmt.Printf("%q has none of those labels.\n", ws.Name)
+	case op == "add":
+		fmt.Printf("Labeled %q: %s\n", ws.Name, strings.Join(changed, ", "))
+	default:
+		fmt.Printf("Unlabeled %q: %s\n", ws.Name, strings.Join(changed, ", "))
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/jsonout"
 	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
 )
 
@@ -18,12 +20,14 @@ var listAll bool
 var listRunning bool
 var listDirty bool
 var listMerged bool
+var listLabels []string
 
 func init() {
 	listCmd.Flags().BoolVarP(&listAll, "all", "a", false, "list workspaces across all registered repos")
 	listCmd.Flags().BoolVar(&listRunning, "running", false, "only show running workspaces")
 	listCmd.Flags().BoolVar(&listDirty, "dirty", false, "only show workspaces with uncommitted changes")
 	listCmd.Flags().BoolVar(&listMerged, "merged", false, "only show workspaces whose branch is merged")
+	listCmd.Flags().StringSliceVar(&listLabels, "label", nil, "only show workspaces with this label (repeatable)")
 	workspaceCmd.AddCommand(listCmd)
 }
 
@@ -35,7 +39,8 @@ var listCmd = &cobra.Command{
   fr8 ws list --all
   fr8 ws list --running
   fr8 ws list --dirty
-  fr8 ws list --merged`,
+  fr8 ws list --merged
+  fr8 ws list --label review`,
 	Args: cobra.NoArgs,
 	RunE: runList,
 }
@@ -66,19 +71,28 @@ func runList(cmd *cobra.Command, args []string) error {
 		return runListAll()
 	}
 
-	// Reconcile: remove workspaces whose paths no longer exist
-	reconcileRepo(repo, cwd)
+	// Reconcile: follow moved worktrees and drop workspaces git no longer knows
+	reconciled := *repo
+	_ = registry.Update(regPath, func(reg *registry.Registry) error {
+		if r := reg.FindByPath(rootPath); r != nil {
+			reconcileRepo(r, rootPath)
+			reconciled = *r
+		}
+		return nil
+	})
+	repo = &reconciled
 
-	// Determine repo name for tmux session lookup
-	hasTmux := tmux.Available() == nil
+	// Determine repo name for session lookup
+	rn := runner.Default()
+	hasRunner := rn.Available() == nil
 	repoName := filepath.Base(rootPath)
 	defaultBranch, _ := git.DefaultBranch(rootPath)
-	hasFilters := listRunning || listDirty || listMerged
+	hasFilters := listRunning || listDirty || listMerged || len(listLabels) > 0
 
 	// Build running session lookup map (one subprocess instead of N)
 	runningSessions := make(map[string]bool)
-	if hasTmux {
-		sessions, _ := tmux.ListFr8Sessions()
+	if hasRunner {
+		sessions, _ := rn.List()
 		for _, s := range sessions {
 			runningSessions[s.Name] = true
 		}
@@ -87,7 +101,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	var items []workspaceListItem
 	for _, ws := range repo.Workspaces {
 		running := false
-		if hasTmux {
+		if hasRunner {
 			sessionName := tmux.SessionName(repoName, ws.Name)
 			running = runningSessions[sessionName]
 		}
@@ -98,6 +112,9 @@ func runList(cmd *cobra.Command, args []string) error {
 			if listRunning && !running {
 				continue
 			}
+			if !ws.HasLabels(listLabels) {
+				continue
+			}
 			if listDirty {
 				dc, _ := git.DirtyStatus(ws.Path)
 				if !dc.Dirty() {
@@ -113,18 +130,21 @@ func runList(cmd *cobra.Command, args []string) error {
 		}
 
 		items = append(items, workspaceListItem{
-			Name:      ws.Name,
-			Branch:    branch,
-			Port:      ws.Port,
-			Path:      ws.Path,
-			Running:   running,
-			CreatedAt: ws.CreatedAt,
+			Name:           ws.Name,
+			Branch:         branch,
+			Port:           ws.Port,
+			Path:           ws.Path,
+			Running:        running,
+			LastCrash:      crashedSession(tmux.SessionName(repoName, ws.Name), running),
+			CreatedAt:      ws.CreatedAt,
+			Origin:         ws.Origin,
+			BranchSwitched: ws.BranchSwitched(branch),
+			Note:           ws.Note,
+			Labels:         ws.Labels,
+			Pinned:         ws.Pinned,
 		})
 	}
 
-	// Save reconciled state
-	_ = reg.Save(regPath)
-
 	if jsonout.Enabled {
 		if items == nil {
 			items = []workspaceListItem{}
@@ -138,13 +158,9 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	_, _ = fmt.Fprintln(w, "NAME\tBRANCH\tPORT\tRUNNING\tPATH")
+	_, _ = fmt.Fprintln(w, "NAME\tBRANCH\tPORT\tRUNNING\tLABELS\tPATH\tNOTE")
 	for _, item := range items {
-		runMark := ""
-		if item.Running {
-			runMark = "●"
-		}
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", item.Name, item.Branch, item.Port, runMark, item.Path)
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", item.Name, item.Branch, item.Port, runMark(item), strings.Join(item.Labels, ","), item.Path, shortNote(item.Note))
 	}
 	_ = w.Flush()
 
@@ -162,13 +178,14 @@ func runListAll() error {
 		return fmt.Errorf("loading registry: %w", err)
 	}
 
-	hasTmux := tmux.Available() == nil
-	hasFilters := listRunning || listDirty || listMerged
+	rn := runner.Default()
+	hasRunner := rn.Available() == nil
+	hasFilters := listRunning || listDirty || listMerged || len(listLabels) > 0
 
 	// Build running session lookup map (one subprocess instead of N)
 	runningSessions := make(map[string]bool)
-	if hasTmux {
-		sessions, _ := tmux.ListFr8Sessions()
+	if hasRunner {
+		sessions, _ := rn.List()
 		for _, s := range sessions {
 			runningSessions[s.Name] = true
 		}
@@ -182,7 +199,7 @@ func runListAll() error {
 
 		for _, ws := range repo.Workspaces {
 			running := false
-			if hasTmux {
+			if hasRunner {
 				sessionName := tmux.SessionName(repo.Name, ws.Name)
 				running = runningSessions[sessionName]
 			}
@@ -193,6 +210,9 @@ func runListAll() error {
 				if listRunning && !running {
 					continue
 				}
+				if !ws.HasLabels(listLabels) {
+					continue
+				}
 				if listDirty {
 					dirty, _ := git.HasUncommittedChanges(ws.Path)
 					if !dirty {
@@ -208,13 +228,19 @@ func runListAll() error {
 			}
 
 			items = append(items, workspaceListItem{
-				Repo:      repo.Name,
-				Name:      ws.Name,
-				Branch:    branch,
-				Port:      ws.Port,
-				Path:      ws.Path,
-				Running:   running,
-				CreatedAt: ws.CreatedAt,
+				Repo:           repo.Name,
+				Name:           ws.Name,
+				Branch:         branch,
+				Port:           ws.Port,
+				Path:           ws.Path,
+				Running:        running,
+				LastCrash:      crashedSession(tmux.SessionName(repo.Name, ws.Name), running),
+				CreatedAt:      ws.CreatedAt,
+				Origin:         ws.Origin,
+				BranchSwitched: ws.BranchSwitched(branch),
+				Note:           ws.Note,
+				Labels:         ws.Labels,
+				Pinned:         ws.Pinned,
 			})
 		}
 	}
@@ -237,35 +263,29 @@ func runListAll() error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	_, _ = fmt.Fprintln(w, "REPO\tNAME\tBRANCH\tPORT\tRUNNING\tPATH")
+	_, _ = fmt.Fprintln(w, "REPO\tNAME\tBRANCH\tPORT\tRUNNING\tLABELS\tPATH\tNOTE")
 	for _, item := range items {
-		runMark := ""
-		if item.Running {
-			runMark = "●"
-		}
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", item.Repo, item.Name, item.Branch, item.Port, runMark, item.Path)
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", item.Repo, item.Name, item.Branch, item.Port, runMark(item), strings.Join(item.Labels, ","), item.Path, shortNote(item.Note))
 	}
 	_ = w.Flush()
 
 	return nil
 }
 
-func reconcileRepo(repo *registry.Repo, cwd string) {
-	gitWorktrees, err := git.WorktreeList(cwd)
+func reconcileRepo(repo *registry.Repo, rootPath string) {
+	gitWorktrees, err := git.WorktreeList(rootPath)
 	if err != nil {
 		return
 	}
 
-	wtPaths := make(map[string]bool, len(gitWorktrees))
-	for _, wt := range gitWorktrees {
-		wtPaths[wt.Path] = true
-	}
-
-	var remaining []registry.Workspace
-	for _, ws := range repo.Workspaces {
-		if wtPaths[ws.Path] {
-			remaining = append(remaining, ws)
+	for _, issue := range diagnoseWorktrees(rootPath, gitWorktrees, repo.Workspaces) {
+		switch issue.Kind {
+		case issueMoved:
+			if ws := repo.FindWorkspace(issue.Workspace); ws != nil {
+				ws.Path = issue.NewPath
+			}
+		case issueMissing, issueNotWorktree:
+			_ = repo.RemoveWorkspace(issue.Workspace)
 		}
 	}
-	repo.Workspaces = remaining
 }
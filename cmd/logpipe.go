@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/logfile"
+)
+
+func init() {
+	rootCmd.AddCommand(logPipeCmd)
+}
+
+// logPipeCmd is run by tmux pipe-pane to record a session window's output.
+// It is not meant to be run by hand.
+var logPipeCmd = &cobra.Command{
+	Use:                logfile.PipeCommand + " <log-file>",
+	Short:              "Copy stdin into a workspace log file",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	Args:               cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w, err := logfile.Open(args[0])
+		if err != nil {
+			return err
+		}
+		_, copyErr := io.Copy(w, os.Stdin)
+		if err := w.Close(); err != nil {
+			return err
+		}
+		return copyErr
+	},
+}
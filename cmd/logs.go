@@ -4,31 +4,54 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"regexp"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/exitcode"
 	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/logfile"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
 )
 
 var logsLines int
 var logsFollow bool
+var logsService string
+var logsSince string
+var logsGrep string
+var logsPrevious bool
 
 func init() {
 	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 50, "number of lines to capture")
 	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "follow output (poll every 1s)")
+	logsCmd.Flags().StringVar(&logsService, "service", "", "show output from a single service")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "only show output since a time or duration (e.g. 10m, \"2006-01-02 15:04\")")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "", "only show lines matching a regular expression")
+	logsCmd.Flags().BoolVar(&logsPrevious, "previous", false, "show output from the previous run")
 	workspaceCmd.AddCommand(logsCmd)
 }
 
 var logsCmd = &cobra.Command{
 	Use:   "logs [name]",
 	Short: "Show recent output from a workspace's background session",
+	Long: `Show recent output from a workspace's background session.
+
+Session output is also recorded to log files under the fr8 state directory
+(logs/<repo>/<workspace>/), so output that has scrolled away or belongs to
+a stopped session can still be read. Logs are read from disk when the
+session is not running or when --since, --grep or --previous is given.
+Each log is rotated at 10 MiB, keeping 3 older segments, and the log from
+the previous run is kept for --previous.`,
 	Example: `  fr8 ws logs
   fr8 ws logs my-feature
   fr8 ws logs -n 100
-  fr8 ws logs -f`,
+  fr8 ws logs -f
+  fr8 ws logs my-feature --service worker
+  fr8 ws logs --since 10m --grep error
+  fr8 ws logs --previous`,
 	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: workspaceNameCompletion,
 	RunE:              runLogs,
@@ -43,7 +66,8 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return err
 	}
 
@@ -57,10 +81,24 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	opts, err := logOptions(logsLines, logsSince, logsGrep, logsPrevious)
+	if err != nil {
+		return err
+	}
+
 	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
 
+	// Multi-service workspaces capture every service window unless one is named.
+	var multiService bool
+	if cfg, err := loadConfig(rootPath); err == nil {
+		multiService = len(cfg.Services) > 0
+	}
+	capture := func() (string, error) {
+		return workspaceOutput(rn, sessionName, logsService, multiService, opts)
+	}
+
 	if !logsFollow {
-		output, err := tmux.CapturePanes(sessionName, logsLines)
+		output, err := capture()
 		if err != nil {
 			return err
 		}
@@ -69,8 +107,9 @@ func runLogs(cmd *cobra.Command, args []string) error {
 			return jsonout.Write(struct {
 				Workspace string `json:"workspace"`
 				Session   string `json:"session"`
+				Service   string `json:"service,omitempty"`
 				Output    string `json:"output"`
-			}{Workspace: ws.Name, Session: sessionName, Output: output})
+			}{Workspace: ws.Name, Session: sessionName, Service: logsService, Output: output})
 		}
 
 		fmt.Print(output)
@@ -86,7 +125,7 @@ func runLogs(cmd *cobra.Command, args []string) error {
 	defer ticker.Stop()
 
 	// Initial capture
-	output, err := tmux.CapturePanes(sessionName, logsLines)
+	output, err := capture()
 	if err != nil {
 		return err
 	}
@@ -98,11 +137,15 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		case <-sig:
 			return nil
 		case <-ticker.C:
-			if !tmux.IsRunning(sessionName) {
+			if !rn.IsRunning(sessionName) {
 				fmt.Fprintf(os.Stderr, "\nSession ended.\n")
 				return nil
 			}
-			output, err := tmux.CapturePanes(sessionName, logsLines)
+			if logsService != "" && !rn.HasProcess(sessionName, logsService) {
+				fmt.Fprintf(os.Stderr, "\nService %q ended.\n", logsService)
+				return nil
+			}
+			output, err := capture()
 			if err != nil {
 				return err
 			}
@@ -111,3 +154,106 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		}
 	}
 }
+
+// logOptions builds log read options from the logs flags.
+func logOptions(lines int, since, grep string, previous bool) (logfile.Options, error) {
+	opts := logfile.Options{Lines: lines, Previous: previous}
+	if since != "" {
+		t, err := logfile.ParseSince(since, time.Now())
+		if err != nil {
+			return opts, err
+		}
+		opts.Since = t
+	}
+	if grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+		opts.Grep = re
+	}
+	return opts, nil
+}
+
+// workspaceOutput returns a workspace's output, captured from the running
+// session or, when it is stopped or opts filters the output, read from its
+// log files.
+func workspaceOutput(rn runner.Runner, sessionName, service string, multiService bool, opts logfile.Options) (string, error) {
+	filtered := !opts.Since.IsZero() || opts.Grep != nil || opts.Previous
+	if !filtered && rn.IsRunning(sessionName) {
+		return captureOutput(rn, sessionName, service, multiService, opts.Lines)
+	}
+	logDir, err := runner.LogDir(sessionName)
+	if err != nil {
+		return "", err
+	}
+	return readLogs(logDir, sessionName, service, multiService, opts)
+}
+
+// readLogs returns output from a session's log files, laid out like
+// captureOutput.
+func readLogs(logDir, sessionName, service string, multiService bool, opts logfile.Options) (string, error) {
+	if service != "" || !multiService {
+		return logfile.Read(logDir, service, opts)
+	}
+
+	var names []string
+	for _, n := range logfile.Names(logDir) {
+		if logfile.Exists(logDir, n, opts.Previous) {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no output logged for session %q (start with: fr8 ws run)", sessionName)
+	}
+
+	var b strings.Builder
+	for i, n := range names {
+		output, err := logfile.Read(logDir, n, opts)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "==> %s <==\n", n)
+		b.WriteString(strings.TrimRight(output, "\n"))
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// captureOutput returns recent output from a workspace session. With a
+// service name it captures only that service's output; for multi-service
+// workspaces it captures each running service under a "==> name <==" header.
+func captureOutput(rn runner.Runner, sessionName, service string, multiService bool, lines int) (string, error) {
+	if service != "" {
+		return rn.CaptureProcess(sessionName, service, lines)
+	}
+	if !multiService {
+		return rn.Capture(sessionName, lines)
+	}
+
+	windows, err := rn.Processes(sessionName)
+	if err != nil {
+		return "", err
+	}
+	if len(windows) == 0 {
+		return "", fmt.Errorf("session %q is not running (start with: fr8 ws run)", sessionName)
+	}
+
+	var b strings.Builder
+	for i, w := range windows {
+		output, err := rn.CaptureProcess(sessionName, w, lines)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "==> %s <==\n", w)
+		b.WriteString(strings.TrimRight(output, "\n"))
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
@@ -44,15 +44,17 @@ SETUP
 
 AVAILABLE TOOLS
 
-  workspace_list      List workspaces (filter by repo, running, dirty, merged)
-  workspace_status    Get workspace details, env vars, process status
+  workspace_list      List workspaces (filter by repo, running, dirty, merged, label)
+  workspace_status    Get workspace details, env vars, process status, health
   workspace_create    Create a new workspace (branch, remote, PR, idempotent)
-  workspace_archive   Archive a workspace (force, idempotent)
-  workspace_run       Start dev server in background tmux session
+  workspace_archive   Archive a workspace (force, idempotent, soft)
+  workspace_run       Start dev server in the background (optionally wait healthy)
   workspace_stop      Stop a workspace's background session
-  workspace_env       Get FR8_* environment variables for a workspace
+  workspace_env       Get FR8_* environment variables (JSON, shell, dotenv, docker)
+  workspace_exec      Run a command in a workspace and capture its output
   workspace_logs      Get recent output from a background session
   workspace_rename    Rename a workspace
+  workspace_sync      Re-copy .worktreeinclude files into a workspace
   repo_list           List registered repos
   config_show         Show resolved fr8 configuration for a repo
   config_doctor       Check fr8 configuration health and report errors/warnings
@@ -4,22 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/protocollar/fr8/internal/config"
 	"github.com/protocollar/fr8/internal/env"
+	"github.com/protocollar/fr8/internal/filesync"
 	"github.com/protocollar/fr8/internal/gh"
 	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/health"
 	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
 	"github.com/protocollar/fr8/internal/workspace"
 )
 
+// Defaults for the workspace_exec tool, which returns output to an agent
+// and so bounds both how long a command runs and how much it returns.
+const (
+	mcpExecTimeout   = 5 * time.Minute
+	mcpExecMaxOutput = 16 * 1024
+)
+
 // mcpResult marshals v as JSON and returns it as MCP text content.
 func mcpResult(v any) (*mcp.CallToolResult, error) {
 	data, err := json.MarshalIndent(v, "", "  ")
@@ -88,11 +96,12 @@ func mcpResolveRepo(repo string) (rootPath string, err error) {
 func registerMCPTools(s *server.MCPServer) {
 	s.AddTool(
 		mcp.NewTool("workspace_list",
-			mcp.WithDescription("List workspaces. Without repo param, lists across all registered repos."),
+			mcp.WithDescription("List workspaces with their labels and notes. Without repo param, lists across all registered repos."),
 			mcp.WithString("repo", mcp.Description("Filter to a specific repo name")),
 			mcp.WithBoolean("running", mcp.Description("Only show running workspaces")),
 			mcp.WithBoolean("dirty", mcp.Description("Only show workspaces with uncommitted changes")),
 			mcp.WithBoolean("merged", mcp.Description("Only show workspaces whose branch is merged")),
+			mcp.WithString("label", mcp.Description("Only show workspaces with this label (comma-separated for several)")),
 			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithDestructiveHintAnnotation(false),
 		),
@@ -101,7 +110,7 @@ func registerMCPTools(s *server.MCPServer) {
 
 	s.AddTool(
 		mcp.NewTool("workspace_status",
-			mcp.WithDescription("Get workspace details including environment variables, process status, and dirty state."),
+			mcp.WithDescription("Get workspace details including environment variables, process status and health, and dirty state."),
 			mcp.WithString("name", mcp.Description("Workspace name"), mcp.Required()),
 			mcp.WithString("repo", mcp.Description("Repo name (required if workspace exists in multiple repos)")),
 			mcp.WithReadOnlyHintAnnotation(true),
@@ -120,6 +129,7 @@ func registerMCPTools(s *server.MCPServer) {
 			mcp.WithString("repo", mcp.Description("Target repo name from registry")),
 			mcp.WithBoolean("no_setup", mcp.Description("Skip running the setup script")),
 			mcp.WithBoolean("if_not_exists", mcp.Description("Succeed silently if workspace already exists")),
+			mcp.WithString("ttl", mcp.Description("Let fr8 ws gc archive the workspace after this long (e.g. 7d, 36h)")),
 			mcp.WithReadOnlyHintAnnotation(false),
 			mcp.WithDestructiveHintAnnotation(false),
 		),
@@ -128,11 +138,13 @@ func registerMCPTools(s *server.MCPServer) {
 
 	s.AddTool(
 		mcp.NewTool("workspace_archive",
-			mcp.WithDescription("Archive (tear down) a workspace: runs archive script, removes worktree, frees port."),
+			mcp.WithDescription("Archive (tear down) a workspace: runs archive script, removes worktree, frees port, deletes session logs."),
 			mcp.WithString("name", mcp.Description("Workspace name"), mcp.Required()),
 			mcp.WithString("repo", mcp.Description("Repo name")),
 			mcp.WithBoolean("force", mcp.Description("Skip uncommitted changes check")),
 			mcp.WithBoolean("if_exists", mcp.Description("Succeed silently if workspace not found")),
+			mcp.WithBoolean("keep_logs", mcp.Description("Keep the workspace's session logs")),
+			mcp.WithBoolean("soft", mcp.Description("Save uncommitted changes so the workspace can be restored with fr8 ws restore")),
 			mcp.WithDestructiveHintAnnotation(true),
 		),
 		handleWorkspaceArchive,
@@ -140,10 +152,13 @@ func registerMCPTools(s *server.MCPServer) {
 
 	s.AddTool(
 		mcp.NewTool("workspace_run",
-			mcp.WithDescription("Start the dev server in a background tmux session."),
+			mcp.WithDescription("Start the dev server (or each configured service) in the background."),
 			mcp.WithString("name", mcp.Description("Workspace name"), mcp.Required()),
 			mcp.WithString("repo", mcp.Description("Repo name")),
+			mcp.WithString("service", mcp.Description("Start only this service (adds it to a running session)")),
 			mcp.WithBoolean("if_not_running", mcp.Description("Succeed silently if already running")),
+			mcp.WithBoolean("wait", mcp.Description("Wait until the workspace passes its healthcheck before returning")),
+			mcp.WithNumber("wait_timeout", mcp.Description("Seconds to wait with wait=true (default: 60)")),
 			mcp.WithDestructiveHintAnnotation(false),
 		),
 		handleWorkspaceRun,
@@ -151,9 +166,10 @@ func registerMCPTools(s *server.MCPServer) {
 
 	s.AddTool(
 		mcp.NewTool("workspace_stop",
-			mcp.WithDescription("Stop a workspace's background tmux session."),
+			mcp.WithDescription("Stop a workspace's background session."),
 			mcp.WithString("name", mcp.Description("Workspace name"), mcp.Required()),
 			mcp.WithString("repo", mcp.Description("Repo name")),
+			mcp.WithString("service", mcp.Description("Stop only this service, leaving the rest running")),
 			mcp.WithBoolean("if_running", mcp.Description("Succeed silently if not running")),
 			mcp.WithDestructiveHintAnnotation(false),
 		),
@@ -162,21 +178,39 @@ func registerMCPTools(s *server.MCPServer) {
 
 	s.AddTool(
 		mcp.NewTool("workspace_env",
-			mcp.WithDescription("Get workspace environment variables (FR8_* vars)."),
+			mcp.WithDescription("Get workspace environment variables (FR8_* vars and the env section of fr8.json), as a JSON object or in another format."),
 			mcp.WithString("name", mcp.Description("Workspace name"), mcp.Required()),
 			mcp.WithString("repo", mcp.Description("Repo name")),
+			mcp.WithString("format", mcp.Description("Output format (default: json)"), mcp.Enum(env.Formats...)),
 			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithDestructiveHintAnnotation(false),
 		),
 		handleWorkspaceEnv,
 	)
 
+	s.AddTool(
+		mcp.NewTool("workspace_exec",
+			mcp.WithDescription("Run a shell command in a workspace's directory with its environment, returning the exit code, stdout and stderr. Long output keeps the end of each stream."),
+			mcp.WithString("name", mcp.Description("Workspace name"), mcp.Required()),
+			mcp.WithString("repo", mcp.Description("Repo name")),
+			mcp.WithString("command", mcp.Description("Command to run with sh -c"), mcp.Required()),
+			mcp.WithNumber("timeout", mcp.Description("Seconds before the command is killed (default: 300)")),
+			mcp.WithNumber("max_output", mcp.Description("Maximum bytes returned per stream (default: 16384)")),
+			mcp.WithDestructiveHintAnnotation(true),
+		),
+		handleWorkspaceExec,
+	)
+
 	s.AddTool(
 		mcp.NewTool("workspace_logs",
-			mcp.WithDescription("Get recent output from a workspace's background tmux session."),
+			mcp.WithDescription("Get recent output from a workspace's background session, or from its log files when stopped or filtered."),
 			mcp.WithString("name", mcp.Description("Workspace name"), mcp.Required()),
 			mcp.WithString("repo", mcp.Description("Repo name")),
 			mcp.WithNumber("lines", mcp.Description("Number of lines to capture (default: 50)")),
+			mcp.WithString("service", mcp.Description("Capture output from a single service")),
+			mcp.WithString("since", mcp.Description("Only return output since a time or duration (e.g. 10m)")),
+			mcp.WithString("grep", mcp.Description("Only return lines matching a regular expression")),
+			mcp.WithBoolean("previous", mcp.Description("Return output from the previous run")),
 			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithDestructiveHintAnnotation(false),
 		),
@@ -195,6 +229,19 @@ func registerMCPTools(s *server.MCPServer) {
 		handleWorkspaceRename,
 	)
 
+	s.AddTool(
+		mcp.NewTool("workspace_sync",
+			mcp.WithDescription("Re-copy .worktreeinclude files from the root worktree into a workspace, returning the files copied or linked."),
+			mcp.WithString("name", mcp.Description("Workspace name"), mcp.Required()),
+			mcp.WithString("repo", mcp.Description("Repo name")),
+			mcp.WithBoolean("dry_run", mcp.Description("Only return the files that differ")),
+			mcp.WithBoolean("reverse", mcp.Description("Copy the workspace's changed files back to the root worktree instead")),
+			mcp.WithBoolean("force", mcp.Description("With reverse, also overwrite root files changed since the workspace's copy; otherwise they are returned with action skip")),
+			mcp.WithDestructiveHintAnnotation(true),
+		),
+		handleWorkspaceSync,
+	)
+
 	s.AddTool(
 		mcp.NewTool("repo_list",
 			mcp.WithDescription("List registered repos."),
@@ -231,7 +278,11 @@ func handleWorkspaceList(ctx context.Context, req mcp.CallToolRequest) (*mcp.Cal
 	filterRunning := req.GetBool("running", false)
 	filterDirty := req.GetBool("dirty", false)
 	filterMerged := req.GetBool("merged", false)
-	hasFilters := filterRunning || filterDirty || filterMerged
+	var filterLabels []string
+	if label := req.GetString("label", ""); label != "" {
+		filterLabels = strings.Split(label, ",")
+	}
+	hasFilters := filterRunning || filterDirty || filterMerged || len(filterLabels) > 0
 
 	regPath, err := registry.DefaultPath()
 	if err != nil {
@@ -242,10 +293,11 @@ func handleWorkspaceList(ctx context.Context, req mcp.CallToolRequest) (*mcp.Cal
 		return mcpError(fmt.Sprintf("loading registry: %v", err))
 	}
 
-	hasTmux := tmux.Available() == nil
+	rn := runner.Default()
+	hasRunner := rn.Available() == nil
 	runningSessions := make(map[string]bool)
-	if hasTmux {
-		sessions, _ := tmux.ListFr8Sessions()
+	if hasRunner {
+		sessions, _ := rn.List()
 		for _, s := range sessions {
 			runningSessions[s.Name] = true
 		}
@@ -261,7 +313,7 @@ func handleWorkspaceList(ctx context.Context, req mcp.CallToolRequest) (*mcp.Cal
 
 		for _, ws := range r.Workspaces {
 			running := false
-			if hasTmux {
+			if hasRunner {
 				sessionName := tmux.SessionName(r.Name, ws.Name)
 				running = runningSessions[sessionName]
 			}
@@ -272,6 +324,9 @@ func handleWorkspaceList(ctx context.Context, req mcp.CallToolRequest) (*mcp.Cal
 				if filterRunning && !running {
 					continue
 				}
+				if !ws.HasLabels(filterLabels) {
+					continue
+				}
 				if filterDirty {
 					dc, _ := git.DirtyStatus(ws.Path)
 					if !dc.Dirty() {
@@ -287,13 +342,19 @@ func handleWorkspaceList(ctx context.Context, req mcp.CallToolRequest) (*mcp.Cal
 			}
 
 			items = append(items, workspaceListItem{
-				Repo:      r.Name,
-				Name:      ws.Name,
-				Branch:    branch,
-				Port:      ws.Port,
-				Path:      ws.Path,
-				Running:   running,
-				CreatedAt: ws.CreatedAt,
+				Repo:           r.Name,
+				Name:           ws.Name,
+				Branch:         branch,
+				Port:           ws.Port,
+				Path:           ws.Path,
+				Running:        running,
+				LastCrash:      crashedSession(tmux.SessionName(r.Name, ws.Name), running),
+				CreatedAt:      ws.CreatedAt,
+				Origin:         ws.Origin,
+				BranchSwitched: ws.BranchSwitched(branch),
+				Note:           ws.Note,
+				Labels:         ws.Labels,
+				Pinned:         ws.Pinned,
 			})
 		}
 	}
@@ -328,36 +389,49 @@ func handleWorkspaceStatus(ctx context.Context, req mcp.CallToolRequest) (*mcp.C
 		pr, _ = gh.PRStatus(ws.Path, branch)
 	}
 
+	rn := runner.Default()
+	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
 	running := false
-	if tmux.Available() == nil {
-		sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
-		running = tmux.IsRunning(sessionName)
+	if rn.Available() == nil {
+		running = rn.IsRunning(sessionName)
 	}
 
-	vars := env.BuildFr8Only(ws, rootPath, defaultBranch)
-	envMap := make(map[string]string)
-	for _, v := range vars {
-		parts := strings.SplitN(v, "=", 2)
-		if len(parts) == 2 && strings.HasPrefix(parts[0], "FR8_") {
-			envMap[parts[0]] = parts[1]
-		}
+	var services []workspace.ServiceState
+	var healthState string
+	cfg, _ := loadConfig(rootPath)
+	if cfg != nil {
+		services = workspace.ServiceStates(rn, cfg, ws, rootPath)
+		healthState = workspace.Health(ctx, rn, cfg, ws, rootPath)
 	}
 
+	vars, envErr := env.BuildFr8Only(ws, rootPath, defaultBranch, cfg)
+	envMap := env.Map(vars)
+
 	return mcpResult(workspaceStatusJSON{
-		Name:       ws.Name,
-		Path:       ws.Path,
-		Branch:     branch,
-		Port:       ws.Port,
-		PortEnd:    ws.Port + 9,
-		Dirty:      dc.Dirty(),
-		Staged:     dc.Staged,
-		Modified:   dc.Modified,
-		Untracked:  dc.Untracked,
-		Running:    running,
-		CreatedAt:  ws.CreatedAt,
-		Env:        envMap,
-		LastCommit: lastCommitPtr,
-		PR:         pr,
+		Name:           ws.Name,
+		Path:           ws.Path,
+		Branch:         branch,
+		Port:           ws.Port,
+		PortEnd:        ws.Port + 9,
+		Dirty:          dc.Dirty(),
+		Staged:         dc.Staged,
+		Modified:       dc.Modified,
+		Untracked:      dc.Untracked,
+		Running:        running,
+		Health:         healthState,
+		LastCrash:      crashedSession(sessionName, running),
+		Services:       services,
+		Ports:          namedPorts(cfg, ws.Port),
+		CreatedAt:      ws.CreatedAt,
+		Origin:         ws.Origin,
+		BranchSwitched: ws.BranchSwitched(branch),
+		Note:           ws.Note,
+		Labels:         ws.Labels,
+		Pinned:         ws.Pinned,
+		Env:            envMap,
+		EnvWarnings:    envWarnings(envErr),
+		LastCommit:     lastCommitPtr,
+		PR:             pr,
 	})
 }
 
@@ -370,6 +444,14 @@ func handleWorkspaceCreate(ctx context.Context, req mcp.CallToolRequest) (*mcp.C
 	noSetup := req.GetBool("no_setup", false)
 	ifNotExists := req.GetBool("if_not_exists", false)
 
+	var ttl time.Duration
+	if s := req.GetString("ttl", ""); s != "" {
+		var err error
+		if ttl, err = parseTTL(s); err != nil {
+			return mcpError(err.Error())
+		}
+	}
+
 	rootPath, err := mcpResolveRepo(repo)
 	if err != nil {
 		return mcpError(err.Error())
@@ -408,7 +490,7 @@ func handleWorkspaceCreate(ctx context.Context, req mcp.CallToolRequest) (*mcp.C
 		}
 	}
 
-	ws, err := createWorkspace(rootPath, wsName, branch, trackRemote, !noSetup, false)
+	ws, err := createWorkspace(rootPath, wsName, branch, trackRemote, !noSetup, false, workspaceSource{pr: pr, createdBy: "mcp workspace_create", ttl: ttl})
 	if err != nil {
 		return mcpError(err.Error())
 	}
@@ -424,6 +506,8 @@ func handleWorkspaceArchive(ctx context.Context, req mcp.CallToolRequest) (*mcp.
 	repo := req.GetString("repo", "")
 	force := req.GetBool("force", false)
 	ifExists := req.GetBool("if_exists", false)
+	keepLogs := req.GetBool("keep_logs", false)
+	soft := req.GetBool("soft", false)
 
 	ws, rootPath, err := mcpResolveWorkspace(name, repo)
 	if err != nil {
@@ -435,7 +519,7 @@ func handleWorkspaceArchive(ctx context.Context, req mcp.CallToolRequest) (*mcp.
 		return mcpError(err.Error())
 	}
 
-	cfg, err := config.Load(rootPath)
+	cfg, err := loadConfig(rootPath)
 	if err != nil {
 		return mcpError(fmt.Sprintf("loading config: %v", err))
 	}
@@ -443,47 +527,21 @@ func handleWorkspaceArchive(ctx context.Context, req mcp.CallToolRequest) (*mcp.
 	// Capture branch before worktree removal
 	branch, _ := git.CurrentBranch(ws.Path)
 
-	// Safety: check for uncommitted changes
-	if !force {
+	// Safety: check for uncommitted changes, which a soft archive keeps
+	if !force && !soft {
 		dirty, _ := git.HasUncommittedChanges(ws.Path)
 		if dirty {
 			return mcpError(fmt.Sprintf("workspace %q has uncommitted changes (use force=true to override)", ws.Name))
 		}
 	}
 
-	// Stop tmux session if running
-	if tmux.Available() == nil {
-		sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
-		if tmux.IsRunning(sessionName) {
-			_ = tmux.Stop(sessionName)
-		}
-	}
-
-	// Run archive script
-	defaultBranch, _ := git.DefaultBranch(rootPath)
-	if cfg.Scripts.Archive != "" {
-		envVars := env.Build(ws, rootPath, defaultBranch)
-		_ = runScript(cfg.Scripts.Archive, ws.Path, envVars)
-	}
-
-	// Remove worktree
-	_ = git.WorktreeRemove(rootPath, ws.Path)
-
-	// Update state via registry
-	regPath, err := registry.DefaultPath()
-	if err != nil {
-		return mcpError(fmt.Sprintf("finding state path: %v", err))
-	}
-	reg, err := registry.Load(regPath)
+	archived, err := archiveWorkspace(cfg, ws, rootPath, archiveOptions{keepLogs: keepLogs, soft: soft})
 	if err != nil {
-		return mcpError(fmt.Sprintf("loading registry: %v", err))
+		return mcpError(err.Error())
 	}
-	r := reg.FindByPath(rootPath)
-	if r != nil {
-		_ = r.RemoveWorkspace(ws.Name)
-		if err := reg.Save(regPath); err != nil {
-			return mcpError(fmt.Sprintf("saving state: %v", err))
-		}
+	var snapshotDir string
+	if archived != nil {
+		snapshotDir = archived.Snapshot
 	}
 
 	return mcpResult(struct {
@@ -494,6 +552,7 @@ func handleWorkspaceArchive(ctx context.Context, req mcp.CallToolRequest) (*mcp.
 			Port   int    `json:"port"`
 			Path   string `json:"path"`
 		} `json:"workspace"`
+		Snapshot string `json:"snapshot,omitempty"`
 	}{
 		Action: "archived",
 		Workspace: struct {
@@ -502,15 +561,20 @@ func handleWorkspaceArchive(ctx context.Context, req mcp.CallToolRequest) (*mcp.
 			Port   int    `json:"port"`
 			Path   string `json:"path"`
 		}{Name: ws.Name, Branch: branch, Port: ws.Port, Path: ws.Path},
+		Snapshot: snapshotDir,
 	})
 }
 
 func handleWorkspaceRun(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	name := req.GetString("name", "")
 	repo := req.GetString("repo", "")
+	service := req.GetString("service", "")
 	ifNotRunning := req.GetBool("if_not_running", false)
+	wait := req.GetBool("wait", false)
+	waitTimeout := time.Duration(req.GetInt("wait_timeout", int(defaultWaitTimeout/time.Second))) * time.Second
 
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return mcpError(err.Error())
 	}
 
@@ -519,46 +583,101 @@ func handleWorkspaceRun(ctx context.Context, req mcp.CallToolRequest) (*mcp.Call
 		return mcpError(err.Error())
 	}
 
-	cfg, err := config.Load(rootPath)
+	cfg, err := loadConfig(rootPath)
 	if err != nil {
 		return mcpError(fmt.Sprintf("loading config: %v", err))
 	}
-	if cfg.Scripts.Run == "" {
-		return mcpError("no run script configured (add \"scripts.run\" to fr8.json)")
+	if !cfg.HasRun() {
+		return mcpError("no run script configured (add \"scripts.run\" or \"services\" to fr8.json)")
 	}
 
 	defaultBranch, _ := git.DefaultBranch(rootPath)
-	envVars := env.BuildFr8Only(ws, rootPath, defaultBranch)
 	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
 
-	if tmux.IsRunning(sessionName) {
+	// waitResult waits for the workspace to become healthy if asked to,
+	// returning its health for the result.
+	waitResult := func() (string, error) {
+		if !wait {
+			return "", nil
+		}
+		if err := waitForHealth(ctx, rn, cfg, ws, rootPath, waitTimeout); err != nil {
+			return "", err
+		}
+		return health.Healthy, nil
+	}
+
+	if service != "" {
+		type serviceResult struct {
+			Action    string `json:"action"`
+			Workspace string `json:"workspace"`
+			Session   string `json:"session"`
+			Service   string `json:"service"`
+			Health    string `json:"health,omitempty"`
+		}
+		if _, ok := cfg.Services[service]; !ok {
+			return mcpError(fmt.Sprintf("service %q not found in fr8.json", service))
+		}
+		if rn.HasProcess(sessionName, service) {
+			if ifNotRunning {
+				healthState, err := waitResult()
+				if err != nil {
+					return mcpError(err.Error())
+				}
+				return mcpResult(serviceResult{Action: "already_running", Workspace: ws.Name, Session: sessionName, Service: service, Health: healthState})
+			}
+			return mcpError(fmt.Sprintf("service %q is already running (use workspace_stop first or set if_not_running=true)", service))
+		}
+		if err := workspace.StartService(rn, cfg, ws, rootPath, defaultBranch, service); err != nil {
+			return mcpError(err.Error())
+		}
+		healthState, err := waitResult()
+		if err != nil {
+			return mcpError(err.Error())
+		}
+		return mcpResult(serviceResult{Action: "started", Workspace: ws.Name, Session: sessionName, Service: service, Health: healthState})
+	}
+
+	if rn.IsRunning(sessionName) {
 		if ifNotRunning {
+			healthState, err := waitResult()
+			if err != nil {
+				return mcpError(err.Error())
+			}
 			return mcpResult(struct {
 				Action    string `json:"action"`
 				Workspace string `json:"workspace"`
 				Session   string `json:"session"`
-			}{Action: "already_running", Workspace: ws.Name, Session: sessionName})
+				Health    string `json:"health,omitempty"`
+			}{Action: "already_running", Workspace: ws.Name, Session: sessionName, Health: healthState})
 		}
 		return mcpError(fmt.Sprintf("session %q is already running (use workspace_stop first or set if_not_running=true)", sessionName))
 	}
 
-	if err := tmux.Start(sessionName, ws.Path, cfg.Scripts.Run, envVars); err != nil {
+	if err := workspace.Start(rn, cfg, ws, rootPath, defaultBranch); err != nil {
+		return mcpError(err.Error())
+	}
+	healthState, err := waitResult()
+	if err != nil {
 		return mcpError(err.Error())
 	}
 
 	return mcpResult(struct {
-		Action    string `json:"action"`
-		Workspace string `json:"workspace"`
-		Session   string `json:"session"`
-	}{Action: "started", Workspace: ws.Name, Session: sessionName})
+		Action    string   `json:"action"`
+		Workspace string   `json:"workspace"`
+		Session   string   `json:"session"`
+		Services  []string `json:"services,omitempty"`
+		Health    string   `json:"health,omitempty"`
+	}{Action: "started", Workspace: ws.Name, Session: sessionName, Services: serviceNames(cfg), Health: healthState})
 }
 
 func handleWorkspaceStop(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	name := req.GetString("name", "")
 	repo := req.GetString("repo", "")
+	service := req.GetString("service", "")
 	ifRunning := req.GetBool("if_running", false)
 
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return mcpError(err.Error())
 	}
 
@@ -568,7 +687,27 @@ func handleWorkspaceStop(ctx context.Context, req mcp.CallToolRequest) (*mcp.Cal
 	}
 
 	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
-	if !tmux.IsRunning(sessionName) {
+
+	if service != "" {
+		type serviceResult struct {
+			Action    string `json:"action"`
+			Workspace string `json:"workspace"`
+			Session   string `json:"session"`
+			Service   string `json:"service"`
+		}
+		if !rn.HasProcess(sessionName, service) {
+			if !ifRunning {
+				return mcpError(fmt.Sprintf("service %q is not running in workspace %q", service, ws.Name))
+			}
+			return mcpResult(serviceResult{Action: "already_stopped", Workspace: ws.Name, Session: sessionName, Service: service})
+		}
+		if err := rn.StopProcess(sessionName, service); err != nil {
+			return mcpError(err.Error())
+		}
+		return mcpResult(serviceResult{Action: "stopped", Workspace: ws.Name, Session: sessionName, Service: service})
+	}
+
+	if !rn.IsRunning(sessionName) {
 		if !ifRunning {
 			return mcpError(fmt.Sprintf("workspace %q is not running", ws.Name))
 		}
@@ -579,7 +718,7 @@ func handleWorkspaceStop(ctx context.Context, req mcp.CallToolRequest) (*mcp.Cal
 		}{Action: "already_stopped", Workspace: ws.Name, Session: sessionName})
 	}
 
-	if err := tmux.Stop(sessionName); err != nil {
+	if err := rn.Stop(sessionName); err != nil {
 		return mcpError(err.Error())
 	}
 
@@ -600,25 +739,58 @@ func handleWorkspaceEnv(ctx context.Context, req mcp.CallToolRequest) (*mcp.Call
 	}
 
 	defaultBranch, _ := git.DefaultBranch(rootPath)
-	vars := env.BuildFr8Only(ws, rootPath, defaultBranch)
+	cfg, _ := loadConfig(rootPath)
+	vars, envErr := env.BuildFr8Only(ws, rootPath, defaultBranch, cfg)
+	out, err := env.Format(vars, req.GetString("format", "json"))
+	if err != nil {
+		return mcpError(err.Error())
+	}
+	content := []mcp.Content{mcp.NewTextContent(out)}
+	for _, w := range envWarnings(envErr) {
+		content = append(content, mcp.NewTextContent("Warning: "+w+" (left out; see: fr8 config doctor)"))
+	}
+	return &mcp.CallToolResult{Content: content}, nil
+}
 
-	envMap := make(map[string]string)
-	for _, v := range vars {
-		parts := strings.SplitN(v, "=", 2)
-		if len(parts) == 2 && strings.HasPrefix(parts[0], "FR8_") {
-			envMap[parts[0]] = parts[1]
-		}
+func handleWorkspaceExec(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := req.GetString("name", "")
+	repo := req.GetString("repo", "")
+	command := req.GetString("command", "")
+	timeout := time.Duration(req.GetInt("timeout", int(mcpExecTimeout/time.Second))) * time.Second
+	maxOutput := req.GetInt("max_output", mcpExecMaxOutput)
+
+	if strings.TrimSpace(command) == "" {
+		return mcpError("command is required")
+	}
+	if timeout <= 0 {
+		return mcpError("timeout must be positive")
+	}
+
+	ws, rootPath, err := mcpResolveWorkspace(name, repo)
+	if err != nil {
+		return mcpError(err.Error())
 	}
 
-	return mcpResult(envMap)
+	res, err := captureInWorkspace(ctx, ws, rootPath, command, timeout, maxOutput)
+	if err != nil {
+		return mcpError(err.Error())
+	}
+	return mcpResult(res)
 }
 
 func handleWorkspaceLogs(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	name := req.GetString("name", "")
 	repo := req.GetString("repo", "")
 	lines := req.GetInt("lines", 50)
+	service := req.GetString("service", "")
+
+	opts, err := logOptions(lines, req.GetString("since", ""), req.GetString("grep", ""), req.GetBool("previous", false))
+	if err != nil {
+		return mcpError(err.Error())
+	}
 
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return mcpError(err.Error())
 	}
 
@@ -627,8 +799,13 @@ func handleWorkspaceLogs(ctx context.Context, req mcp.CallToolRequest) (*mcp.Cal
 		return mcpError(err.Error())
 	}
 
+	var multiService bool
+	if cfg, err := loadConfig(rootPath); err == nil {
+		multiService = len(cfg.Services) > 0
+	}
+
 	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
-	output, err := tmux.CapturePanes(sessionName, lines)
+	output, err := workspaceOutput(rn, sessionName, service, multiService, opts)
 	if err != nil {
 		return mcpError(err.Error())
 	}
@@ -636,8 +813,9 @@ func handleWorkspaceLogs(ctx context.Context, req mcp.CallToolRequest) (*mcp.Cal
 	return mcpResult(struct {
 		Workspace string `json:"workspace"`
 		Session   string `json:"session"`
+		Service   string `json:"service,omitempty"`
 		Output    string `json:"output"`
-	}{Workspace: ws.Name, Session: sessionName, Output: output})
+	}{Workspace: ws.Name, Session: sessionName, Service: service, Output: output})
 }
 
 func handleWorkspaceRename(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -654,44 +832,10 @@ func handleWorkspaceRename(ctx context.Context, req mcp.CallToolRequest) (*mcp.C
 		return mcpError(err.Error())
 	}
 
-	oldPath := ws.Path
-	newPath := filepath.Join(filepath.Dir(oldPath), newName)
-	if err := git.WorktreeMove(rootPath, oldPath, newPath); err != nil {
-		return mcpError(fmt.Sprintf("moving worktree: %v", err))
-	}
-
-	// Update state via registry
-	regPath, err := registry.DefaultPath()
+	newPath, err := renameWorkspace(ws, rootPath, newName)
 	if err != nil {
-		return mcpError(fmt.Sprintf("finding state path: %v", err))
-	}
-	reg, err := registry.Load(regPath)
-	if err != nil {
-		return mcpError(fmt.Sprintf("loading registry: %v", err))
-	}
-	r := reg.FindByPath(rootPath)
-	if r == nil {
-		return mcpError(fmt.Sprintf("repo not found in registry for path: %s", rootPath))
-	}
-	if err := r.RenameWorkspace(oldName, newName); err != nil {
 		return mcpError(err.Error())
 	}
-	renamed := r.FindWorkspace(newName)
-	renamed.Path = newPath
-
-	if err := reg.Save(regPath); err != nil {
-		return mcpError(fmt.Sprintf("saving state: %v", err))
-	}
-
-	// Rename tmux session if running
-	if tmux.Available() == nil {
-		repoName := tmux.RepoName(rootPath)
-		oldSession := tmux.SessionName(repoName, oldName)
-		if tmux.IsRunning(oldSession) {
-			newSession := tmux.SessionName(repoName, newName)
-			_ = tmux.RenameSession(oldSession, newSession)
-		}
-	}
 
 	return mcpResult(struct {
 		Action  string `json:"action"`
@@ -701,6 +845,26 @@ func handleWorkspaceRename(ctx context.Context, req mcp.CallToolRequest) (*mcp.C
 	}{Action: "renamed", OldName: oldName, NewName: newName, Path: newPath})
 }
 
+func handleWorkspaceSync(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := req.GetString("name", "")
+	repo := req.GetString("repo", "")
+
+	ws, rootPath, err := mcpResolveWorkspace(name, repo)
+	if err != nil {
+		return mcpError(err.Error())
+	}
+
+	res := syncWorkspace(ws, rootPath, filesync.Options{
+		DryRun:  req.GetBool("dry_run", false),
+		Reverse: req.GetBool("reverse", false),
+		Force:   req.GetBool("force", false),
+	})
+	if res.Error != "" {
+		return mcpError(res.Error)
+	}
+	return mcpResult(res)
+}
+
 func handleRepoList(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	withWorkspaces := req.GetBool("workspaces", false)
 
@@ -740,7 +904,7 @@ func handleConfigShow(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallTo
 		return mcpError(err.Error())
 	}
 
-	cfg, err := config.Load(rootPath)
+	cfg, err := loadConfig(rootPath)
 	if err != nil {
 		return mcpError(fmt.Sprintf("loading config: %v", err))
 	}
@@ -756,6 +920,12 @@ func handleConfigShow(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallTo
 		"worktree_path":          cfg.WorktreePath,
 		"resolved_worktree_path": config.ResolveWorktreePath(cfg, rootPath),
 	}
+	if len(cfg.Services) > 0 {
+		resolved["services"] = cfg.Services
+	}
+	if len(cfg.Ports) > 0 {
+		resolved["ports"] = cfg.Ports
+	}
 
 	return mcpResult(resolved)
 }
@@ -767,46 +937,12 @@ func handleConfigDoctor(ctx context.Context, req mcp.CallToolRequest) (*mcp.Call
 		return mcpError(err.Error())
 	}
 
-	cfg, err := config.Load(rootPath)
+	cfg, err := loadConfig(rootPath)
 	if err != nil {
 		return mcpError(fmt.Sprintf("loading config: %v", err))
 	}
 
-	var warnings []string
-	var configErrors []string
-
-	for name, script := range map[string]string{
-		"setup":   cfg.Scripts.Setup,
-		"run":     cfg.Scripts.Run,
-		"archive": cfg.Scripts.Archive,
-	} {
-		if script == "" {
-			continue
-		}
-		parts := strings.Fields(script)
-		if _, err := exec.LookPath(parts[0]); err != nil {
-			if _, err := os.Stat(fmt.Sprintf("%s/%s", rootPath, parts[0])); err != nil {
-				warnings = append(warnings, fmt.Sprintf("scripts.%s: %q not found in $PATH or repo", name, parts[0]))
-			}
-		}
-	}
-
-	wtPath := config.ResolveWorktreePath(cfg, rootPath)
-	if info, err := os.Stat(wtPath); err == nil {
-		if !info.IsDir() {
-			configErrors = append(configErrors, fmt.Sprintf("worktree_path: %q exists but is not a directory", wtPath))
-		}
-	}
-
-	if cfg.BasePort < 1024 {
-		warnings = append(warnings, fmt.Sprintf("base_port: %d is a privileged port (< 1024)", cfg.BasePort))
-	}
-	if cfg.BasePort > 65535 {
-		configErrors = append(configErrors, fmt.Sprintf("base_port: %d is out of range (> 65535)", cfg.BasePort))
-	}
-	if cfg.PortRange < 1 {
-		configErrors = append(configErrors, fmt.Sprintf("port_range: %d must be at least 1", cfg.PortRange))
-	}
+	configErrors, warnings, _ := checkConfig(rootPath, cfg)
 
 	if configErrors == nil {
 		configErrors = []string{}
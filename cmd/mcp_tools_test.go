@@ -105,8 +105,10 @@ func TestRegisterMCPTools(t *testing.T) {
 		"workspace_run",
 		"workspace_stop",
 		"workspace_env",
+		"workspace_exec",
 		"workspace_logs",
 		"workspace_rename",
+		"workspace_sync",
 		"repo_list",
 		"config_show",
 		"config_doctor",
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,6 +28,7 @@ var noSetup bool
 var noShell bool
 var newIfNotExists bool
 var newDryRun bool
+var newTTL string
 
 func init() {
 	newCmd.Flags().StringVarP(&newBranch, "branch", "b", "", "branch name (creates new branch if it doesn't exist)")
@@ -36,6 +38,7 @@ func init() {
 	newCmd.Flags().BoolVar(&noShell, "no-shell", false, "skip dropping into a workspace shell after creation")
 	newCmd.Flags().BoolVar(&newIfNotExists, "if-not-exists", false, "succeed silently if workspace already exists")
 	newCmd.Flags().BoolVar(&newDryRun, "dry-run", false, "show what would be created without doing it")
+	newCmd.Flags().StringVar(&newTTL, "ttl", "", "let fr8 ws gc archive the workspace after this long (e.g. 7d, 36h)")
 	newCmd.MarkFlagsMutuallyExclusive("branch", "remote", "pull-request")
 	workspaceCmd.AddCommand(newCmd)
 }
@@ -49,6 +52,7 @@ var newCmd = &cobra.Command{
   fr8 ws new -r feature/existing-branch
   fr8 ws new -p 42
   fr8 ws new --no-shell
+  fr8 ws new spike --ttl 3d
   fr8 ws new --repo myapp my-feature`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runNew,
@@ -88,6 +92,14 @@ func runNew(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	var ttl time.Duration
+	if newTTL != "" {
+		var err error
+		if ttl, err = parseTTL(newTTL); err != nil {
+			return err
+		}
+	}
+
 	// Determine branch and whether to track remote
 	branch := newBranch
 	trackRemote := false
@@ -108,7 +120,8 @@ func runNew(cmd *cobra.Command, args []string) error {
 	// When --json, never enter a subshell
 	enterShell := !noShell && !jsonout.Enabled
 
-	ws, err := createWorkspace(rootPath, nameFromArgs(args), branch, trackRemote, !noSetup, enterShell)
+	src := workspaceSource{pr: newPR, createdBy: commandLine(), ttl: ttl}
+	ws, err := createWorkspace(rootPath, nameFromArgs(args), branch, trackRemote, !noSetup, enterShell, src)
 	if err != nil {
 		return err
 	}
@@ -116,6 +129,12 @@ func runNew(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// commandLine returns the fr8 command being run, for recording in a
+// workspace's origin.
+func commandLine() string {
+	return strings.Join(append([]string{"fr8"}, os.Args[1:]...), " ")
+}
+
 func nameFromArgs(args []string) string {
 	if len(args) > 0 {
 		return args[0]
@@ -142,52 +161,60 @@ func resolvePRBranch(dir, prNumber string) (string, error) {
 	return branch, nil
 }
 
+// workspaceSource describes how a workspace was requested, for the origin
+// createWorkspace records in the registry.
+type workspaceSource struct {
+	pr        string        // pull request number, with -p
+	createdBy string        // command that created the workspace
+	ttl       time.Duration // with --ttl, how long until fr8 ws gc may archive it
+	quiet     bool          // the caller reports the created workspace itself
+}
+
+// parseTTL parses a workspace TTL such as "7d" or "36h".
+func parseTTL(s string) (time.Duration, error) {
+	d, err := config.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid TTL %q: must be a positive duration (e.g. 7d, 36h)", s)
+	}
+	return d, nil
+}
+
 // createWorkspace is the shared workspace creation logic used by both the CLI
 // (runNew) and the TUI dashboard loop. When trackRemote is true, the branch is
 // expected to exist on origin and a local tracking branch will be created.
-func createWorkspace(rootPath, wsName, branch string, trackRemote, runSetup, enterShell bool) (*registry.Workspace, error) {
-	cfg, err := config.Load(rootPath)
+func createWorkspace(rootPath, wsName, branch string, trackRemote, runSetup, enterShell bool, src workspaceSource) (*registry.Workspace, error) {
+	cfg, err := loadConfig(rootPath)
 	if err != nil {
 		return nil, fmt.Errorf("loading config: %w", err)
 	}
 
-	// Load registry for workspace state
 	regPath, err := registry.DefaultPath()
 	if err != nil {
 		return nil, fmt.Errorf("finding state path: %w", err)
 	}
-	reg, err := registry.Load(regPath)
-	if err != nil {
-		return nil, fmt.Errorf("loading registry: %w", err)
-	}
 
-	repo := reg.FindByPath(rootPath)
-	if repo == nil {
-		// Auto-register the repo
-		newRepo := registry.Repo{Name: filepath.Base(rootPath), Path: rootPath}
-		if err := reg.Add(newRepo); err != nil {
-			return nil, fmt.Errorf("registering repo: %w", err)
-		}
-		repo = reg.FindByPath(rootPath)
-	}
-
-	// Workspace name
+	// Early existence check so --if-not-exists can return before fetching.
+	// The authoritative check happens again under the registry lock below.
 	if wsName != "" {
-		if existing := repo.FindWorkspace(wsName); existing != nil {
-			if newIfNotExists {
-				if jsonout.Enabled {
-					return existing, jsonout.Write(struct {
-						Action    string              `json:"action"`
-						Workspace *registry.Workspace `json:"workspace"`
-					}{Action: "already_exists", Workspace: existing})
+		reg, err := registry.Load(regPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading registry: %w", err)
+		}
+		if repo := reg.FindByPath(rootPath); repo != nil {
+			if existing := repo.FindWorkspace(wsName); existing != nil {
+				if newIfNotExists {
+					if jsonout.Enabled {
+						return existing, jsonout.Write(struct {
+							Action    string              `json:"action"`
+							Workspace *registry.Workspace `json:"workspace"`
+						}{Action: "already_exists", Workspace: existing})
+					}
+					_, _ = fmt.Fprintf(jsonout.MsgOut(), "Workspace %q already exists.\n", wsName)
+					return existing, nil
 				}
-				_, _ = fmt.Fprintf(jsonout.MsgOut(), "Workspace %q already exists.\n", wsName)
-				return existing, nil
+				return nil, fmt.Errorf("workspace %q already exists", wsName)
 			}
-			return nil, fmt.Errorf("workspace %q already exists", wsName)
 		}
-	} else {
-		wsName = names.Generate(repo.WorkspaceNames())
 	}
 
 	// Determine default branch and fetch latest from origin
@@ -206,49 +233,20 @@ func createWorkspace(rootPath, wsName, branch string, trackRemote, runSetup, ent
 		startPoint = remoteRef
 	}
 
-	// Branch resolution
-	createBranch := false
-	switch {
-	case branch == "":
-		branch = wsName
-		createBranch = true
-	case trackRemote:
-		// --remote or --pr: create local tracking branch from origin/<branch>
-		remoteBranch := "origin/" + branch
-		if !git.RemoteRefExists(rootPath, remoteBranch) {
-			return nil, fmt.Errorf("remote branch %s not found (did you forget to push?)", remoteBranch)
-		}
-		if !git.BranchExists(rootPath, branch) {
-			_, _ = fmt.Fprintf(jsonout.MsgOut(), "Creating local branch %s tracking %s\n", branch, remoteBranch)
-			if err := git.CreateTrackingBranch(rootPath, branch, remoteBranch); err != nil {
-				return nil, fmt.Errorf("creating tracking branch: %w", err)
-			}
+	// Dry run: plan against a snapshot of the registry without taking the lock
+	if newDryRun {
+		reg, err := registry.Load(regPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading registry: %w", err)
 		}
-		startPoint = ""
-		createBranch = false
-	default:
-		// -b: create new local branch if it doesn't exist
-		if !git.BranchExists(rootPath, branch) {
-			createBranch = true
+		plan, err := planWorkspace(reg, cfg, rootPath, wsName, branch, trackRemote, startPoint)
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	// Port — collect ports from all registered repos to avoid cross-repo conflicts
-	allocatedPort, err := port.Allocate(reg.AllAllocatedPorts(), cfg.BasePort, cfg.PortRange)
-	if err != nil {
-		return nil, fmt.Errorf("allocating port: %w", err)
-	}
-
-	// Worktree path
-	wtBase := config.ResolveWorktreePath(cfg, rootPath)
-	wsPath := filepath.Join(wtBase, wsName)
-
-	// Dry run: report what would be created without doing it
-	if newDryRun {
 		planned := registry.Workspace{
-			Name:      wsName,
-			Path:      wsPath,
-			Port:      allocatedPort,
+			Name:      plan.name,
+			Path:      plan.path,
+			Port:      plan.port,
 			CreatedAt: time.Now().UTC(),
 		}
 		if jsonout.Enabled {
@@ -265,60 +263,86 @@ func createWorkspace(rootPath, wsName, branch string, trackRemote, runSetup, ent
 				Path   string `json:"path"`
 				Branch string `json:"branch"`
 				Port   int    `json:"port"`
-			}{Name: planned.Name, Path: planned.Path, Branch: branch, Port: planned.Port}})
+			}{Name: planned.Name, Path: planned.Path, Branch: plan.branch, Port: planned.Port}})
 		}
 		fmt.Printf("Dry run — would create workspace:\n")
 		fmt.Printf("  Name:   %s\n", planned.Name)
-		fmt.Printf("  Branch: %s\n", branch)
+		fmt.Printf("  Branch: %s\n", plan.branch)
 		fmt.Printf("  Port:   %d-%d\n", planned.Port, planned.Port+cfg.PortRange-1)
 		fmt.Printf("  Path:   %s\n", planned.Path)
 		return &planned, nil
 	}
 
-	// Create worktree
-	_, _ = fmt.Fprintf(jsonout.MsgOut(), "Creating workspace %q...\n", wsName)
-	if err := os.MkdirAll(wtBase, 0755); err != nil {
-		return nil, fmt.Errorf("creating worktree directory: %w", err)
-	}
-
-	if err := git.WorktreeAdd(rootPath, wsPath, branch, createBranch, startPoint); err != nil {
-		return nil, fmt.Errorf("creating worktree: %w", err)
-	}
+	// Name, port, and worktree are all decided and created while holding the
+	// registry lock so concurrent creations can't claim the same port or name.
+	var ws registry.Workspace
+	var plan workspacePlan
+	err = registry.Update(regPath, func(reg *registry.Registry) error {
+		var err error
+		plan, err = planWorkspace(reg, cfg, rootPath, wsName, branch, trackRemote, startPoint)
+		if err != nil {
+			return err
+		}
 
-	ws := registry.Workspace{
-		Name:      wsName,
-		Path:      wsPath,
-		Port:      allocatedPort,
-		CreatedAt: time.Now().UTC(),
-	}
+		_, _ = fmt.Fprintf(jsonout.MsgOut(), "Creating workspace %q...\n", plan.name)
+		if err := os.MkdirAll(filepath.Dir(plan.path), 0755); err != nil {
+			return fmt.Errorf("creating worktree directory: %w", err)
+		}
+		if err := git.WorktreeAdd(rootPath, plan.path, plan.branch, plan.createBranch, plan.startPoint); err != nil {
+			return fmt.Errorf("creating worktree: %w", err)
+		}
 
-	if err := repo.AddWorkspace(ws); err != nil {
-		// Clean up worktree on state failure
-		_ = git.WorktreeRemove(rootPath, wsPath)
-		return nil, fmt.Errorf("saving workspace: %w", err)
-	}
-	if err := reg.Save(regPath); err != nil {
-		_ = git.WorktreeRemove(rootPath, wsPath)
-		return nil, fmt.Errorf("saving state: %w", err)
+		created := registry.Workspace{
+			Name:      plan.name,
+			Path:      plan.path,
+			Port:      plan.port,
+			CreatedAt: time.Now().UTC(),
+			Origin:    plan.origin(trackRemote, src, cfg),
+		}
+		if src.ttl > 0 {
+			created.ExpiresAt = created.CreatedAt.Add(src.ttl)
+		}
+		if err := plan.repo.AddWorkspace(created); err != nil {
+			// Clean up worktree on state failure
+			_ = git.WorktreeRemove(rootPath, plan.path)
+			return fmt.Errorf("saving workspace: %w", err)
+		}
+		ws = created
+		return nil
+	})
+	if err != nil {
+		if ws.Path != "" {
+			// The worktree was added but writing the registry failed
+			_ = git.WorktreeRemove(rootPath, ws.Path)
+			return nil, fmt.Errorf("saving state: %w", err)
+		}
+		return nil, err
 	}
+	branch = plan.branch
 
 	// Sync files
 	_, _ = fmt.Fprintf(jsonout.MsgOut(), "Syncing files...\n")
-	if err := filesync.Sync(rootPath, wsPath); err != nil {
+	changes, err := filesync.Sync(rootPath, ws.Path, filesync.Options{})
+	printSyncChanges(jsonout.MsgOut(), changes, false)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: file sync failed: %v\n", err)
 	}
 
 	// Run setup script
 	if runSetup && cfg.Scripts.Setup != "" {
 		_, _ = fmt.Fprintf(jsonout.MsgOut(), "Running setup script: %s\n", cfg.Scripts.Setup)
-		envVars := env.Build(&ws, rootPath, defaultBranch)
-		if err := runScript(cfg.Scripts.Setup, wsPath, envVars); err != nil {
+		envVars, envErr := env.Build(&ws, rootPath, defaultBranch, cfg)
+		warnEnv(envErr)
+		if err := runScript(cfg.Scripts.Setup, ws.Path, envVars); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: setup script failed: %v\n", err)
 			fmt.Fprintln(os.Stderr, "The workspace was created but setup did not complete.")
-			fmt.Fprintf(os.Stderr, "You can re-run setup with: cd %s && %s\n", wsPath, cfg.Scripts.Setup)
+			fmt.Fprintf(os.Stderr, "You can re-run setup with: cd %s && %s\n", ws.Path, cfg.Scripts.Setup)
 		}
 	}
 
+	if src.quiet {
+		return &ws, nil
+	}
 	if jsonout.Enabled {
 		return &ws, jsonout.Write(struct {
 			Action    string `json:"action"`
@@ -351,7 +375,8 @@ func createWorkspace(rootPath, wsName, branch string, trackRemote, runSetup, ent
 		fmt.Println("Type 'exit' to leave the workspace shell.")
 		fmt.Println()
 
-		envVars := env.Build(&ws, rootPath, defaultBranch)
+		envVars, envErr := env.Build(&ws, rootPath, defaultBranch, cfg)
+		warnEnv(envErr)
 
 		userShell := os.Getenv("SHELL")
 		if userShell == "" {
@@ -378,6 +403,109 @@ func createWorkspace(rootPath, wsName, branch string, trackRemote, runSetup, ent
 	return &ws, nil
 }
 
+// workspacePlan is the resolved name, branch, port, and path for a workspace
+// that is about to be created.
+type workspacePlan struct {
+	repo         *registry.Repo
+	name         string
+	branch       string
+	createBranch bool
+	startPoint   string
+	port         int
+	path         string
+}
+
+// planWorkspace resolves everything createWorkspace needs from the registry:
+// it auto-registers the repo, picks or validates the workspace name, resolves
+// the branch, and allocates a port block. The returned plan's repo points into
+// reg, so callers inside registry.Update can add the workspace to it directly.
+func planWorkspace(reg *registry.Registry, cfg *config.Config, rootPath, wsName, branch string, trackRemote bool, startPoint string) (workspacePlan, error) {
+	repo := reg.FindByPath(rootPath)
+	if repo == nil {
+		// Auto-register the repo
+		newRepo := registry.Repo{Name: filepath.Base(rootPath), Path: rootPath}
+		if err := reg.Add(newRepo); err != nil {
+			return workspacePlan{}, fmt.Errorf("registering repo: %w", err)
+		}
+		repo = reg.FindByPath(rootPath)
+	}
+
+	// Workspace name
+	if wsName != "" {
+		if repo.FindWorkspace(wsName) != nil {
+			return workspacePlan{}, fmt.Errorf("workspace %q already exists", wsName)
+		}
+	} else {
+		wsName = names.Generate(repo.WorkspaceNames())
+	}
+
+	// Branch resolution
+	createBranch := false
+	switch {
+	case branch == "":
+		branch = wsName
+		createBranch = true
+	case trackRemote:
+		// --remote or --pr: create local tracking branch from origin/<branch>
+		remoteBranch := "origin/" + branch
+		if !git.RemoteRefExists(rootPath, remoteBranch) {
+			return workspacePlan{}, fmt.Errorf("remote branch %s not found (did you forget to push?)", remoteBranch)
+		}
+		if !git.BranchExists(rootPath, branch) {
+			_, _ = fmt.Fprintf(jsonout.MsgOut(), "Creating local branch %s tracking %s\n", branch, remoteBranch)
+			if err := git.CreateTrackingBranch(rootPath, branch, remoteBranch); err != nil {
+				return workspacePlan{}, fmt.Errorf("creating tracking branch: %w", err)
+			}
+		}
+		startPoint = ""
+		createBranch = false
+	default:
+		// -b: create new local branch if it doesn't exist
+		if !git.BranchExists(rootPath, branch) {
+			createBranch = true
+		}
+	}
+
+	// Port — collect ports from all registered repos to avoid cross-repo conflicts
+	allocatedPort, err := port.Allocate(reg.AllAllocatedPorts(), cfg.BasePort, cfg.PortRange)
+	if err != nil {
+		return workspacePlan{}, fmt.Errorf("allocating port: %w", err)
+	}
+
+	wtBase := config.ResolveWorktreePath(cfg, rootPath)
+	return workspacePlan{
+		repo:         repo,
+		name:         wsName,
+		branch:       branch,
+		createBranch: createBranch,
+		startPoint:   startPoint,
+		port:         allocatedPort,
+		path:         filepath.Join(wtBase, wsName),
+	}, nil
+}
+
+// origin returns the provenance to record for a workspace created from plan,
+// once its worktree exists.
+func (p workspacePlan) origin(trackRemote bool, src workspaceSource, cfg *config.Config) *registry.Origin {
+	o := &registry.Origin{
+		Branch:     p.branch,
+		Source:     registry.SourceBranch,
+		CreatedBy:  src.createdBy,
+		ConfigHash: cfg.Hash(),
+	}
+	switch {
+	case src.pr != "":
+		o.Source = registry.SourcePR
+		o.PR, _ = strconv.Atoi(strings.TrimPrefix(src.pr, "#"))
+	case trackRemote:
+		o.Source = registry.SourceRemote
+	case p.createBranch:
+		o.Source = registry.SourceNew
+	}
+	o.BaseCommit, _ = git.HeadCommit(p.path)
+	return o
+}
+
 func shortenHomePath(p string) string {
 	home, err := os.UserHomeDir()
 	if err != nil || home == "" {
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func init() {
+	workspaceCmd.AddCommand(noteCmd)
+}
+
+var noteCmd = &cobra.Command{
+	Use:   "note <name> [text]",
+	Short: "Show or set a workspace's note",
+	Long: `Shows a workspace's note, or sets it to text. The note is a free-form
+reminder of what the workspace is for, shown by fr8 ws list, fr8 ws status
+and the dashboard. An empty text clears it.`,
+	Example: `  fr8 ws note bright-berlin "Spike: replace the job queue"
+  fr8 ws note bright-berlin
+  fr8 ws note bright-berlin ""`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: workspaceNameCompletion,
+	RunE:              runNote,
+}
+
+func runNote(cmd *cobra.Command, args []string) error {
+	ws, rootPath, err := resolveWorkspace(args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		if jsonout.Enabled {
+			return jsonout.Write(struct {
+				Workspace string `json:"workspace"`
+				Note      string `json:"note"`
+			}{Workspace: ws.Name, Note: ws.Note})
+		}
+		if ws.Note != "" {
+			fmt.Println(ws.Note)
+		}
+		return nil
+	}
+
+	note := strings.TrimSpace(args[1])
+	if _, err := updateWorkspace(rootPath, ws.Name, func(w *registry.Workspace) error {
+		w.Note = note
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	action := "noted"
+	if note == "" {
+		action = "cleared"
+	}
+	if jsonout.Enabled {
+		return jsonout.Write(struct {
+			Action    string `json:"action"`
+			Workspace string `json:"workspace"`
+			Note      string `json:"note"`
+		}{Action: action, Workspace: ws.Name, Note: note})
+	}
+	if note == "" {
+		fmt.Printf("Cleared note on %q.\n", ws.Name)
+	} else {
+		fmt.Printf("Noted %q: %s\n", ws.Name, note)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func init() {
+	workspaceCmd.AddCommand(pinCmd)
+	workspaceCmd.AddCommand(unpinCmd)
+}
+
+var pinCmd = &cobra.Command{
+	Use:   "pin [name]",
+	Short: "Protect a workspace from fr8 ws gc",
+	Long: `Pins a workspace so that fr8 ws gc and the dashboard's batch archive never
+archive it, whatever its TTL, age or merge status. fr8 ws archive still works.
+The workspace name is optional if you're inside a workspace directory.`,
+	Example: `  fr8 ws pin long-lived-spike
+  fr8 ws unpin long-lived-spike`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: workspaceNameCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args, true)
+	},
+}
+
+var unpinCmd = &cobra.Command{
+	Use:               "unpin [name]",
+	Short:             "Allow fr8 ws gc to archive a pinned workspace again",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: workspaceNameCompletion,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args, false)
+	},
+}
+
+func setPinned(args []string, pinned bool) error {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	ws, rootPath, err := resolveWorkspace(name)
+	if err != nil {
+		return err
+	}
+
+	changed := ws.Pinned != pinned
+	if changed {
+		if _, err := updateWorkspace(rootPath, ws.Name, func(w *registry.Workspace) error {
+			w.Pinned = pinned
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	action := "pinned"
+	if !pinned {
+		action = "unpinned"
+	}
+	if jsonout.Enabled {
+		return jsonout.Write(struct {
+			Action    string `json:"action"`
+			Workspace string `json:"workspace"`
+			Changed   bool   `json:"changed"`
+		}{Action: action, Workspace: ws.Name, Changed: changed})
+	}
+	switch {
+	case !changed:
+		fmt.Printf("Workspace %q is already %s.\n", ws.Name, action)
+	case pinned:
+		fmt.Printf("Pinned %q; fr8 ws gc will leave it alone.\n", ws.Name)
+	default:
+		fmt.Printf("Unpinned %q.\n", ws.Name)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/proxy"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+var proxyPort int
+
+func init() {
+	proxyServeCmd.Flags().IntVar(&proxyPort, "port", proxy.DefaultPort, "port to listen on")
+	proxyCmd.AddCommand(proxyServeCmd)
+	rootCmd.AddCommand(proxyCmd)
+}
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Local reverse proxy giving each workspace a stable hostname",
+}
+
+var proxyServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve http://<workspace>.<repo>.localhost for every registered workspace",
+	Long: `Start an HTTP reverse proxy on localhost that routes
+<workspace>.<repo>.localhost to the workspace's port.
+
+Workspaces are read from the global registry, which is re-read whenever it
+changes, so new and renamed workspaces are picked up without a restart.
+Requests for a workspace whose dev server is down get a 502 page listing all
+known workspaces.
+
+While the proxy is running, fr8 ws browser and the dashboard open proxy URLs
+instead of http://localhost:<port>.`,
+	Example: `  fr8 proxy serve
+  fr8 proxy serve --port 8080`,
+	Args: cobra.NoArgs,
+	RunE: runProxyServe,
+}
+
+func runProxyServe(cmd *cobra.Command, args []string) error {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return fmt.Errorf("finding state path: %w", err)
+	}
+	statePath, err := proxy.StatePath()
+	if err != nil {
+		return fmt.Errorf("finding state path: %w", err)
+	}
+	if st := proxy.Running(); st != nil {
+		return fmt.Errorf("proxy is already running on port %d (pid %d)", st.Port, st.PID)
+	}
+
+	// Listen on both loopback addresses: browsers may resolve *.localhost to either.
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", proxyPort))
+	if err != nil {
+		return fmt.Errorf("listening on port %d: %w", proxyPort, err)
+	}
+	listeners := []net.Listener{ln}
+	if ln6, err := net.Listen("tcp", fmt.Sprintf("[::1]:%d", proxyPort)); err == nil {
+		listeners = append(listeners, ln6)
+	}
+
+	srv := &http.Server{
+		Handler:           proxy.NewServer(regPath, proxyPort),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if err := proxy.WriteState(statePath, proxy.State{PID: os.Getpid(), Port: proxyPort, StartedAt: time.Now()}); err != nil {
+		return err
+	}
+	defer func() { _ = proxy.RemoveState(statePath) }()
+
+	if jsonout.Enabled {
+		if err := jsonout.Write(struct {
+			Action string `json:"action"`
+			Port   int    `json:"port"`
+			URL    string `json:"url"`
+		}{Action: "listening", Port: proxyPort, URL: fmt.Sprintf("http://<workspace>.<repo>.localhost:%d", proxyPort)}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Proxy listening on http://<workspace>.<repo>.localhost:%d\n", proxyPort)
+		fmt.Printf("  Index: http://localhost:%d\n", proxyPort)
+		fmt.Println("Press Ctrl-C to stop.")
+	}
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) { errCh <- srv.Serve(l) }(l)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	select {
+	case <-sig:
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("serving proxy: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
@@ -7,7 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/jsonout"
-	"github.com/protocollar/fr8/internal/tmux"
+	"github.com/protocollar/fr8/internal/runner"
 )
 
 func init() {
@@ -22,18 +22,19 @@ var psCmd = &cobra.Command{
 }
 
 func runPS(cmd *cobra.Command, args []string) error {
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return err
 	}
 
-	sessions, err := tmux.ListFr8Sessions()
+	sessions, err := rn.List()
 	if err != nil {
 		return err
 	}
 
 	if jsonout.Enabled {
 		if sessions == nil {
-			sessions = []tmux.Session{}
+			sessions = []runner.Session{}
 		}
 		return jsonout.Write(sessions)
 	}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+var reconcileFix bool
+
+func init() {
+	repoReconcileCmd.Flags().BoolVar(&reconcileFix, "fix", false, "repair the discrepancies that can be repaired automatically")
+	repoCmd.AddCommand(repoReconcileCmd)
+}
+
+var repoReconcileCmd = &cobra.Command{
+	Use:   "reconcile [name]",
+	Short: "Compare the registry with git's worktrees and repair drift",
+	Long: `Compares a repo's workspaces in the registry with the worktrees listed by
+git worktree list and reports every discrepancy. Defaults to the repo
+containing the current directory.
+
+With --fix, repairs what it can:
+  moved           the workspace's path is updated to where git worktree move put it
+  missing         the workspace's directory is gone; it is removed from the registry
+  not_a_worktree  the directory exists but git lost track of it; git worktree repair
+  prunable        git has a stale entry for a deleted worktree; git worktree prune
+
+Locked stale entries (git worktree unlock) and worktrees fr8 doesn't manage
+(fr8 ws adopt) are reported with the command to run by hand.`,
+	Example: `  fr8 repo reconcile
+  fr8 repo reconcile myapp --fix
+  fr8 repo reconcile --json`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: repoNameCompletion,
+	RunE:              runRepoReconcile,
+}
+
+// Kinds of discrepancy between the registry and git worktree list.
+const (
+	issueMoved       = "moved"          // the workspace's worktree is now at another path
+	issueMissing     = "missing"        // the workspace's directory no longer exists
+	issueNotWorktree = "not_a_worktree" // the directory exists but git doesn't list it
+	issuePrunable    = "prunable"       // git lists a deleted worktree no workspace refers to
+	issueLocked      = "locked"         // like prunable, but git worktree prune skips it
+	issueUnmanaged   = "unmanaged"      // a worktree that isn't a workspace
+)
+
+// worktreeIssue is one discrepancy found by fr8 repo reconcile.
+type worktreeIssue struct {
+	Kind      string `json:"kind"`
+	Workspace string `json:"workspace,omitempty"`
+	Path      string `json:"path"`
+	NewPath   string `json:"new_path,omitempty"`
+	Detail    string `json:"detail"`
+	Fix       string `json:"fix"` // what --fix does, or the command to run by hand
+	Fixable   bool   `json:"fixable"`
+	Fixed     bool   `json:"fixed"`
+
+	prune bool // --fix runs git worktree prune
+}
+
+func runRepoReconcile(cmd *cobra.Command, args []string) error {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return err
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return fmt.Errorf("loading registry: %w", err)
+	}
+
+	var repo *registry.Repo
+	if len(args) > 0 {
+		repo = reg.Find(args[0])
+		if repo == nil {
+			return fmt.Errorf("repo %q not found in registry (see repos: fr8 repo list)", args[0])
+		}
+	} else {
+		if repo, err = currentRepo(); err != nil {
+			return err
+		}
+	}
+	rootPath, err := git.RootWorktreePath(repo.Path)
+	if err != nil {
+		return fmt.Errorf("finding root worktree of %s: %w", repo.Path, err)
+	}
+	worktrees, err := git.WorktreeList(rootPath)
+	if err != nil {
+		return fmt.Errorf("listing worktrees: %w", err)
+	}
+
+	issues := diagnoseWorktrees(rootPath, worktrees, repo.Workspaces)
+	if reconcileFix && len(issues) > 0 {
+		issues, err = fixWorktreeIssues(regPath, repo.Name, rootPath, worktrees)
+		if err != nil {
+			return err
+		}
+	}
+
+	if jsonout.Enabled {
+		if issues == nil {
+			issues = []worktreeIssue{}
+		}
+		return jsonout.Write(struct {
+			Repo   string          `json:"repo"`
+			Issues []worktreeIssue `json:"issues"`
+		}{Repo: repo.Name, Issues: issues})
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("Registry and git worktrees of %s agree.\n", repo.Name)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ISSUE\tWORKSPACE\tPATH\tDETAIL\tFIX")
+	fixable := 0
+	for _, issue := range issues {
+		fix := issue.Fix
+		switch {
+		case issue.Fixed:
+			fix = "fixed: " + fix
+		case issue.Fixable:
+			fixable++
+			fix = "fixable: " + fix
+		default:
+			fix = "run: " + fix
+		}
+		name := issue.Workspace
+		if name == "" {
+			name = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", issue.Kind, name, shortenHomePath(issue.Path), issue.Detail, fix)
+	}
+	_ = w.Flush()
+	if fixable > 0 {
+		fmt.Printf("\nRepair %d issue(s) with: fr8 repo reconcile %s --fix\n", fixable, repo.Name)
+	}
+	return nil
+}
+
+// fixWorktreeIssues diagnoses the repo again under the registry lock and
+// repairs every fixable issue, returning them all with Fixed set.
+func fixWorktreeIssues(regPath, repoName, rootPath string, worktrees []git.Worktree) ([]worktreeIssue, error) {
+	var issues []worktreeIssue
+	err := registry.Update(regPath, func(reg *registry.Registry) error {
+		repo := reg.Find(repoName)
+		if repo == nil {
+			return fmt.Errorf("repo %q not found in registry", repoName)
+		}
+		issues = diagnoseWorktrees(rootPath, worktrees, repo.Workspaces)
+		for i := range issues {
+			issue := &issues[i]
+			switch issue.Kind {
+			case issueMoved:
+				if ws := repo.FindWorkspace(issue.Workspace); ws != nil {
+					ws.Path = issue.NewPath
+					issue.Fixed = true
+				}
+			case issueMissing:
+				issue.Fixed = repo.RemoveWorkspace(issue.Workspace) == nil
+			case issueNotWorktree:
+				if !issue.Fixable {
+					continue
+				}
+				if err := git.WorktreeRepair(rootPath, issue.Path); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+					continue
+				}
+				issue.Fixed = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("saving state: %w", err)
+	}
+
+	// One prune covers the stale entries of the workspaces removed above
+	// and the unregistered ones.
+	prune := false
+	for _, issue := range issues {
+		prune = prune || issue.prune
+	}
+	if prune {
+		if err := git.WorktreePrune(rootPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			for i := range issues {
+				if issues[i].Kind == issuePrunable {
+					issues[i].Fixed = true
+				}
+			}
+		}
+	}
+	return issues, nil
+}
+
+// diagnoseWorktrees lists the discrepancies between a repo's workspaces and
+// the worktrees git knows about.
+func diagnoseWorktrees(rootPath string, worktrees []git.Worktree, workspaces []registry.Workspace) []worktreeIssue {
+	unmanaged, missing := worktreeDrift(rootPath, worktrees, workspaces)
+	byPath := make(map[string]git.Worktree, len(worktrees))
+	for _, wt := range worktrees {
+		byPath[canonicalPath(wt.Path)] = wt
+	}
+
+	var issues []worktreeIssue
+	moved := make(map[string]bool) // unmanaged worktrees claimed by a workspace
+	for _, ws := range workspaces {
+		wt, ok := byPath[canonicalPath(ws.Path)]
+		if !ok || !wt.Prunable {
+			continue
+		}
+		if wt.Locked {
+			issues = append(issues, lockedIssue(ws.Name, wt))
+			continue
+		}
+		issues = append(issues, worktreeIssue{
+			Kind: issueMissing, Workspace: ws.Name, Path: ws.Path,
+			Detail:  "directory no longer exists",
+			Fix:     "remove the workspace and prune its worktree",
+			Fixable: true,
+			prune:   true,
+		})
+	}
+	for _, ws := range missing {
+		if _, err := os.Stat(ws.Path); err == nil {
+			issue := worktreeIssue{
+				Kind: issueNotWorktree, Workspace: ws.Name, Path: ws.Path,
+				Detail: "directory exists but git doesn't list it as a worktree",
+				Fix:    fmt.Sprintf("fr8 ws archive %s --force", ws.Name),
+			}
+			// A .git file means git only lost the link, e.g. after the repo moved
+			if fi, err := os.Lstat(filepath.Join(ws.Path, ".git")); err == nil && fi.Mode().IsRegular() {
+				issue.Fix = "git worktree repair"
+				issue.Fixable = true
+			}
+			issues = append(issues, issue)
+			continue
+		}
+		if wt := movedWorktree(ws, unmanaged, moved); wt != nil {
+			moved[wt.Path] = true
+			issues = append(issues, worktreeIssue{
+				Kind: issueMoved, Workspace: ws.Name, Path: ws.Path, NewPath: wt.Path,
+				Detail:  "worktree moved to " + wt.Path,
+				Fix:     "update the workspace's path",
+				Fixable: true,
+			})
+			continue
+		}
+		issues = append(issues, worktreeIssue{
+			Kind: issueMissing, Workspace: ws.Name, Path: ws.Path,
+			Detail:  "directory no longer exists",
+			Fix:     "remove the workspace",
+			Fixable: true,
+		})
+	}
+
+	managed := make(map[string]bool, len(workspaces))
+	for _, ws := range workspaces {
+		managed[canonicalPath(ws.Path)] = true
+	}
+	for _, wt := range worktrees {
+		if !wt.Prunable || managed[canonicalPath(wt.Path)] {
+			continue
+		}
+		if wt.Locked {
+			issues = append(issues, lockedIssue("", wt))
+			continue
+		}
+		detail := "stale worktree entry"
+		if wt.PruneReason != "" {
+			detail += ": " + wt.PruneReason
+		}
+		issues = append(issues, worktreeIssue{
+			Kind: issuePrunable, Path: wt.Path, Detail: detail,
+			Fix: "git worktree prune", Fixable: true, prune: true,
+		})
+	}
+	for _, wt := range unmanaged {
+		if moved[wt.Path] {
+			continue
+		}
+		issues = append(issues, worktreeIssue{
+			Kind: issueUnmanaged, Path: wt.Path,
+			Detail: "not managed by fr8",
+			Fix:    "fr8 ws adopt " + wt.Path,
+		})
+	}
+	return issues
+}
+
+// movedWorktree returns the unmanaged worktree that ws's worktree was most
+// likely moved to: the one git still knows by ws's directory name, else the
+// only one on the branch ws was created on, else the only one with the same
+// directory name. It returns nil when that's ambiguous.
+func movedWorktree(ws registry.Workspace, unmanaged []git.Worktree, claimed map[string]bool) *git.Worktree {
+	base := filepath.Base(ws.Path)
+	var candidates []*git.Worktree
+	for i, wt := range unmanaged {
+		if id, err := git.WorktreeID(wt.Path); err == nil && !claimed[wt.Path] && id == base {
+			return &unmanaged[i]
+		}
+	}
+	if ws.Origin != nil && ws.Origin.Branch != "" {
+		for i, wt := range unmanaged {
+			if !claimed[wt.Path] && wt.Branch == ws.Origin.Branch {
+				candidates = append(candidates, &unmanaged[i])
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		for i, wt := range unmanaged {
+			if !claimed[wt.Path] && filepath.Base(wt.Path) == base {
+				candidates = append(candidates, &unmanaged[i])
+			}
+		}
+	}
+	if len(candidates) != 1 {
+		return nil
+	}
+	return candidates[0]
+}
+
+func lockedIssue(wsName string, wt git.Worktree) worktreeIssue {
+	detail := "deleted worktree is locked"
+	if wt.LockReason != "" {
+		detail += ": " + wt.LockReason
+	}
+	return worktreeIssue{
+		Kind: issueLocked, Workspace: wsName, Path: wt.Path, Detail: detail,
+		Fix: "git worktree unlock " + wt.Path,
+	}
+}
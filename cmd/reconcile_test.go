@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func TestDiagnoseWorktrees(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	for _, name := range []string{"myapp", "ok", "moved-to", "stray", "relinked", "plain-dir"} {
+		if err := os.MkdirAll(path(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(path("relinked"), ".git"), []byte("gitdir: /elsewhere\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	worktrees := []git.Worktree{
+		{Path: path("myapp"), Branch: "main"},
+		{Path: path("ok"), Branch: "ok"},
+		{Path: path("moved-to"), Branch: "feature"},
+		{Path: path("stray"), Branch: "stray"},
+		{Path: path("deleted"), Branch: "deleted", Prunable: true},
+		{Path: path("pinned-gone"), Branch: "usb", Prunable: true, Locked: true, LockReason: "on a usb drive"},
+		{Path: path("stale"), Branch: "stale", Prunable: true},
+	}
+	workspaces := []registry.Workspace{
+		{Name: "ok", Path: path("ok")},
+		{Name: "feature", Path: path("feature"), Origin: &registry.Origin{Branch: "feature"}},
+		{Name: "deleted", Path: path("deleted")},
+		{Name: "usb", Path: path("pinned-gone")},
+		{Name: "relinked", Path: path("relinked")},
+		{Name: "plain-dir", Path: path("plain-dir")},
+		{Name: "vanished", Path: path("vanished")},
+	}
+
+	type want struct {
+		kind, workspace, path string
+		fixable               bool
+	}
+	wants := []want{
+		{issueMissing, "deleted", path("deleted"), true},
+		{issueLocked, "usb", path("pinned-gone"), false},
+		{issueMoved, "feature", path("feature"), true},
+		{issueNotWorktree, "relinked", path("relinked"), true},
+		{issueNotWorktree, "plain-dir", path("plain-dir"), false},
+		{issueMissing, "vanished", path("vanished"), true},
+		{issuePrunable, "", path("stale"), true},
+		{issueUnmanaged, "", path("stray"), false},
+	}
+
+	issues := diagnoseWorktrees(path("myapp"), worktrees, workspaces)
+	if len(issues) != len(wants) {
+		t.Fatalf("got %d issues, want %d: %+v", len(issues), len(wants), issues)
+	}
+	for i, w := range wants {
+		got := issues[i]
+		if got.Kind != w.kind || got.Workspace != w.workspace || got.Path != w.path || got.Fixable != w.fixable {
+			t.Errorf("issue[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+	if issues[2].NewPath != path("moved-to") {
+		t.Errorf("moved NewPath = %q, want %q", issues[2].NewPath, path("moved-to"))
+	}
+}
+
+func TestMovedWorktreeAmbiguous(t *testing.T) {
+	ws := registry.Workspace{Name: "feature", Path: "/ws/feature"}
+	unmanaged := []git.Worktree{
+		{Path: "/a/feature", Branch: "x"},
+		{Path: "/b/feature", Branch: "y"},
+	}
+	if wt := movedWorktree(ws, unmanaged, map[string]bool{}); wt != nil {
+		t.Errorf("movedWorktree() = %+v, want nil for two candidates", wt)
+	}
+	if wt := movedWorktree(ws, unmanaged, map[string]bool{"/a/feature": true}); wt == nil || wt.Path != "/b/feature" {
+		t.Errorf("movedWorktree() = %+v, want /b/feature", wt)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/logfile"
 	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
 )
 
@@ -32,45 +34,10 @@ func runRename(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Move the worktree directory (e.g. ~/fr8/myapp/old-name → ~/fr8/myapp/new-name)
-	oldPath := ws.Path
-	newPath := filepath.Join(filepath.Dir(oldPath), newName)
-	if err := git.WorktreeMove(rootPath, oldPath, newPath); err != nil {
-		return fmt.Errorf("moving worktree: %w", err)
-	}
-
-	// Update state: name and path
-	regPath, err := registry.DefaultPath()
+	newPath, err := renameWorkspace(ws, rootPath, newName)
 	if err != nil {
 		return err
 	}
-	reg, err := registry.Load(regPath)
-	if err != nil {
-		return fmt.Errorf("loading registry: %w", err)
-	}
-	repo := reg.FindByPath(rootPath)
-	if repo == nil {
-		return fmt.Errorf("repo not found in registry for path: %s", rootPath)
-	}
-	if err := repo.RenameWorkspace(oldName, newName); err != nil {
-		return err
-	}
-	renamed := repo.FindWorkspace(newName)
-	renamed.Path = newPath
-
-	if err := reg.Save(regPath); err != nil {
-		return fmt.Errorf("saving state: %w", err)
-	}
-
-	// Rename tmux session if running
-	if tmux.Available() == nil {
-		repoName := tmux.RepoName(rootPath)
-		oldSession := tmux.SessionName(repoName, oldName)
-		if tmux.IsRunning(oldSession) {
-			newSession := tmux.SessionName(repoName, newName)
-			_ = tmux.RenameSession(oldSession, newSession)
-		}
-	}
 
 	if jsonout.Enabled {
 		return jsonout.Write(struct {
@@ -85,3 +52,74 @@ func runRename(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Path: %s\n", newPath)
 	return nil
 }
+
+// renameWorkspace renames ws to newName: its registry entry and worktree,
+// its background session, logs and env file. It returns the new worktree
+// path. The session is renamed first, so a runner that can't rename a
+// running session stops the rename before anything else changes.
+func renameWorkspace(ws *registry.Workspace, rootPath, newName string) (string, error) {
+	oldName := ws.Name
+	repoName := tmux.RepoName(rootPath)
+	oldSession := tmux.SessionName(repoName, oldName)
+	newSession := tmux.SessionName(repoName, newName)
+
+	var renamedSession runner.Runner
+	if rn := runner.Default(); rn.Available() == nil && rn.IsRunning(oldSession) {
+		if err := rn.Rename(oldSession, newSession); err != nil {
+			return "", err
+		}
+		renamedSession = rn
+	}
+
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+
+	// Move the worktree directory (e.g. ~/fr8/myapp/old-name → ~/fr8/myapp/new-name)
+	// while holding the registry lock so the name check and the move are atomic.
+	newPath := filepath.Join(filepath.Dir(ws.Path), newName)
+	err = registry.Update(regPath, func(reg *registry.Registry) error {
+		return renameWorkspaceInRegistry(reg, rootPath, oldName, newName, newPath)
+	})
+	if err != nil {
+		if renamedSession != nil {
+			_ = renamedSession.Rename(newSession, oldSession)
+		}
+		return "", err
+	}
+	ws.Name, ws.Path = newName, newPath
+	refreshEnvFile(ws, rootPath)
+
+	// Move session logs
+	if oldDir, err := logfile.Dir(repoName, oldName); err == nil {
+		if newDir, err := logfile.Dir(repoName, newName); err == nil {
+			_ = logfile.Move(oldDir, newDir)
+		}
+	}
+	return newPath, nil
+}
+
+// renameWorkspaceInRegistry renames a workspace in reg and moves its worktree
+// to newPath. The registry is validated before the worktree is moved so a
+// name collision never leaves the worktree and registry out of sync.
+// Intended to be called from within registry.Update.
+func renameWorkspaceInRegistry(reg *registry.Registry, rootPath, oldName, newName, newPath string) error {
+	repo := reg.FindByPath(rootPath)
+	if repo == nil {
+		return fmt.Errorf("repo not found in registry for path: %s", rootPath)
+	}
+	ws := repo.FindWorkspace(oldName)
+	if ws == nil {
+		return fmt.Errorf("workspace %q not found (see available: fr8 ws list)", oldName)
+	}
+	oldPath := ws.Path
+	if err := repo.RenameWorkspace(oldName, newName); err != nil {
+		return err
+	}
+	if err := git.WorktreeMove(rootPath, oldPath, newPath); err != nil {
+		return fmt.Errorf("moving worktree: %w", err)
+	}
+	repo.FindWorkspace(newName).Path = newPath
+	return nil
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/jsonout"
 	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
 )
 
@@ -124,22 +126,29 @@ func runRepoList(cmd *cobra.Command, args []string) error {
 }
 
 func repoWorkspaces(repo registry.Repo) []workspaceListItem {
-	hasTmux := tmux.Available() == nil
+	rn := runner.Default()
+	hasRunner := rn.Available() == nil
 	items := make([]workspaceListItem, 0, len(repo.Workspaces))
 	for _, ws := range repo.Workspaces {
 		running := false
-		if hasTmux {
+		if hasRunner {
 			sessionName := tmux.SessionName(repo.Name, ws.Name)
-			running = tmux.IsRunning(sessionName)
+			running = rn.IsRunning(sessionName)
 		}
 		branch, _ := git.CurrentBranch(ws.Path)
 		items = append(items, workspaceListItem{
-			Name:      ws.Name,
-			Branch:    branch,
-			Port:      ws.Port,
-			Path:      ws.Path,
-			Running:   running,
-			CreatedAt: ws.CreatedAt,
+			Name:           ws.Name,
+			Branch:         branch,
+			Port:           ws.Port,
+			Path:           ws.Path,
+			Running:        running,
+			LastCrash:      crashedSession(tmux.SessionName(repo.Name, ws.Name), running),
+			CreatedAt:      ws.CreatedAt,
+			Origin:         ws.Origin,
+			BranchSwitched: ws.BranchSwitched(branch),
+			Note:           ws.Note,
+			Labels:         ws.Labels,
+			Pinned:         ws.Pinned,
 		})
 	}
 	return items
@@ -174,6 +183,12 @@ func runRepoAdd(cmd *cobra.Command, args []string) error {
 
 	// Resolve to root worktree
 	rootPath, err := git.RootWorktreePath(dir)
+	if errors.Is(err, git.ErrRootUnknown) {
+		// Adopting a bare repo: the worktree being added becomes its root
+		if rootPath, err = git.Toplevel(dir); err == nil {
+			err = git.SetRootWorktree(dir, rootPath)
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("finding root worktree: %w", err)
 	}
@@ -188,19 +203,13 @@ func runRepoAdd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	reg, err := registry.Load(regPath)
+	err = registry.Update(regPath, func(reg *registry.Registry) error {
+		return reg.Add(registry.Repo{Name: name, Path: rootPath})
+	})
 	if err != nil {
-		return fmt.Errorf("loading registry: %w", err)
-	}
-
-	if err := reg.Add(registry.Repo{Name: name, Path: rootPath}); err != nil {
 		return err
 	}
 
-	if err := reg.Save(regPath); err != nil {
-		return fmt.Errorf("saving registry: %w", err)
-	}
-
 	if jsonout.Enabled {
 		return jsonout.Write(struct {
 			Action string `json:"action"`
@@ -219,19 +228,13 @@ func runRepoRemove(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	reg, err := registry.Load(regPath)
+	err = registry.Update(regPath, func(reg *registry.Registry) error {
+		return reg.Remove(args[0])
+	})
 	if err != nil {
-		return fmt.Errorf("loading registry: %w", err)
-	}
-
-	if err := reg.Remove(args[0]); err != nil {
 		return err
 	}
 
-	if err := reg.Save(regPath); err != nil {
-		return fmt.Errorf("saving registry: %w", err)
-	}
-
 	if jsonout.Enabled {
 		return jsonout.Write(struct {
 			Action string `json:"action"`
@@ -265,13 +268,48 @@ func repoNameCompletion(cmd *cobra.Command, args []string, toComplete string) ([
 // workspaceListItem is the JSON schema for a workspace in list output.
 // Used by both ws list and repo list --workspaces.
 type workspaceListItem struct {
-	Repo      string    `json:"repo,omitempty"`
-	Name      string    `json:"name"`
-	Branch    string    `json:"branch"`
-	Port      int       `json:"port"`
-	Path      string    `json:"path"`
-	Running   bool      `json:"running"`
-	CreatedAt time.Time `json:"created_at"`
+	Repo           string           `json:"repo,omitempty"`
+	Name           string           `json:"name"`
+	Branch         string           `json:"branch"`
+	Port           int              `json:"port"`
+	Path           string           `json:"path"`
+	Running        bool             `json:"running"`
+	LastCrash      *runner.Exit     `json:"last_crash,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	Origin         *registry.Origin `json:"origin,omitempty"`
+	BranchSwitched bool             `json:"branch_switched,omitempty"`
+	Note           string           `json:"note,omitempty"`
+	Labels         []string         `json:"labels,omitempty"`
+	Pinned         bool             `json:"pinned,omitempty"`
+}
+
+// crashedSession returns the last crash in a session that is not running,
+// or nil.
+func crashedSession(sessionName string, running bool) *runner.Exit {
+	if running {
+		return nil
+	}
+	return runner.LastCrash(sessionName)
+}
+
+// shortNote shortens a workspace note to fit a table column.
+func shortNote(note string) string {
+	const max = 40
+	if r := []rune(note); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return note
+}
+
+// runMark renders the RUNNING column of workspace tables.
+func runMark(item workspaceListItem) string {
+	if item.Running {
+		return "●"
+	}
+	if item.LastCrash != nil {
+		return item.LastCrash.Summary()
+	}
+	return ""
 }
 
 func (w workspaceListItem) Concise() any {
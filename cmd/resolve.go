@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/protocollar/fr8/internal/config"
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/registry"
 	"github.com/protocollar/fr8/internal/workspace"
@@ -78,3 +79,83 @@ func resolveWorkspace(name string) (*registry.Workspace, string, error) {
 	fmt.Fprintf(os.Stderr, "(resolved from repo %q)\n", filepath.Base(rootPath))
 	return ws, rootPath, nil
 }
+
+// registeredRepoName returns the registry name of the repo at rootPath,
+// falling back to the directory name when it isn't registered.
+func registeredRepoName(rootPath string) string {
+	if regPath, err := registry.DefaultPath(); err == nil {
+		if reg, err := registry.Load(regPath); err == nil {
+			if repo := reg.FindByPath(rootPath); repo != nil {
+				return repo.Name
+			}
+		}
+	}
+	return filepath.Base(rootPath)
+}
+
+// loadConfig loads the config of the repo at rootPath, with the user
+// config's overrides for its registered name.
+func loadConfig(rootPath string) (*config.Config, error) {
+	return config.Load(rootPath, registeredRepoName(rootPath))
+}
+
+// updateWorkspace applies fn to a workspace of the repo at rootPath while
+// holding the registry lock, and returns the workspace as saved.
+func updateWorkspace(rootPath, name string, fn func(*registry.Workspace) error) (registry.Workspace, error) {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return registry.Workspace{}, err
+	}
+	var updated registry.Workspace
+	err = registry.Update(regPath, func(reg *registry.Registry) error {
+		repo := reg.FindByPath(rootPath)
+		if repo == nil {
+			return fmt.Errorf("repo not found in registry for path: %s", rootPath)
+		}
+		ws := repo.FindWorkspace(name)
+		if ws == nil {
+			return fmt.Errorf("workspace %q not found (see available: fr8 ws list)", name)
+		}
+		if err := fn(ws); err != nil {
+			return err
+		}
+		updated = *ws
+		return nil
+	})
+	return updated, err
+}
+
+// currentRepo returns the --repo repo, or the registered repo containing the
+// working directory.
+func currentRepo() (*registry.Repo, error) {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading registry: %w", err)
+	}
+
+	if resolveRepo != "" {
+		repo := reg.Find(resolveRepo)
+		if repo == nil {
+			return nil, fmt.Errorf("repo %q not found in registry (see repos: fr8 repo list)", resolveRepo)
+		}
+		return repo, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	repo := reg.FindRepoByWorkspacePath(cwd)
+	if repo == nil {
+		if rootPath, err := git.RootWorktreePath(cwd); err == nil {
+			repo = reg.FindByPath(rootPath)
+		}
+	}
+	if repo == nil {
+		return nil, fmt.Errorf("not inside a registered repo (use --repo <name>)")
+	}
+	return repo, nil
+}
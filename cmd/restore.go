@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/env"
+	"github.com/protocollar/fr8/internal/filesync"
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/port"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/snapshot"
+)
+
+var restoreNoSetup bool
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreNoSetup, "no-setup", false, "skip running the setup script")
+	workspaceCmd.AddCommand(restoreCmd)
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Bring back a soft-archived workspace",
+	Long: `Recreates a workspace archived with fr8 ws archive --soft: checks its branch
+out into a new worktree at the same path, syncs files, re-applies the
+uncommitted changes saved when it was archived and runs the setup script.
+
+The workspace keeps its port if it is still free. If the branch was deleted
+since, it is recreated at the commit the workspace was on.`,
+	Example: `  fr8 ws archived list
+  fr8 ws restore my-feature
+  fr8 ws restore my-feature --no-setup`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: archivedNameCompletion,
+	RunE:              runRestore,
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	repo, err := currentRepo()
+	if err != nil {
+		return err
+	}
+	rootPath, err := git.RootWorktreePath(repo.Path)
+	if err != nil {
+		rootPath = repo.Path
+	}
+	cfg, err := loadConfig(rootPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return fmt.Errorf("finding state path: %w", err)
+	}
+
+	// The worktree is created while holding the registry lock so the port and
+	// name can't be claimed concurrently.
+	var archived registry.ArchivedWorkspace
+	var ws registry.Workspace
+	err = registry.Update(regPath, func(reg *registry.Registry) error {
+		r := reg.Find(repo.Name)
+		if r == nil {
+			return fmt.Errorf("repo %q not found in registry", repo.Name)
+		}
+		a := r.FindArchived(name)
+		if a == nil {
+			return fmt.Errorf("archived workspace %q not found (see available: fr8 ws archived list)", name)
+		}
+		if r.FindWorkspace(name) != nil {
+			return fmt.Errorf("workspace %q already exists (rename it first: fr8 ws rename %s <new-name>)", name, name)
+		}
+		if a.Branch == "" || a.Branch == "HEAD" {
+			return fmt.Errorf("workspace %q was archived on a detached HEAD and can't be restored automatically (its changes are in %s)", name, a.Snapshot)
+		}
+		if _, err := os.Stat(a.Path); err == nil {
+			return fmt.Errorf("cannot restore %q: %s already exists", name, a.Path)
+		}
+
+		restored := a.Workspace
+		if slices.Contains(reg.AllAllocatedPorts(), restored.Port) {
+			p, err := port.Allocate(reg.AllAllocatedPorts(), cfg.BasePort, cfg.PortRange)
+			if err != nil {
+				return fmt.Errorf("allocating port: %w", err)
+			}
+			restored.Port = p
+		}
+
+		_, _ = fmt.Fprintf(jsonout.MsgOut(), "Restoring workspace %q...\n", name)
+		if err := os.MkdirAll(filepath.Dir(restored.Path), 0755); err != nil {
+			return fmt.Errorf("creating worktree directory: %w", err)
+		}
+		if git.BranchExists(rootPath, a.Branch) {
+			if err := git.WorktreeAdd(rootPath, restored.Path, a.Branch, false, ""); err != nil {
+				return fmt.Errorf("creating worktree: %w", err)
+			}
+		} else {
+			_, _ = fmt.Fprintf(jsonout.MsgOut(), "Recreating branch %s at %s\n", a.Branch, shortSHA(a.HeadCommit))
+			if err := git.WorktreeAdd(rootPath, restored.Path, a.Branch, true, a.HeadCommit); err != nil {
+				return fmt.Errorf("creating worktree: %w", err)
+			}
+		}
+
+		if err := r.AddWorkspace(restored); err != nil {
+			_ = git.WorktreeRemove(rootPath, restored.Path)
+			return fmt.Errorf("saving workspace: %w", err)
+		}
+		archived = *a
+		_ = r.RemoveArchived(name)
+		ws = restored
+		return nil
+	})
+	if err != nil {
+		if ws.Path != "" {
+			// The worktree was added but writing the registry failed
+			_ = git.WorktreeRemove(rootPath, ws.Path)
+			return fmt.Errorf("saving state: %w", err)
+		}
+		return err
+	}
+
+	// Sync files
+	_, _ = fmt.Fprintf(jsonout.MsgOut(), "Syncing files...\n")
+	changes, err := filesync.Sync(rootPath, ws.Path, filesync.Options{})
+	printSyncChanges(jsonout.MsgOut(), changes, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: file sync failed: %v\n", err)
+	}
+
+	// Re-apply uncommitted changes; the snapshot is kept if that fails
+	changesRestored := false
+	if archived.Dirty {
+		if head, err := git.HeadCommit(ws.Path); err == nil && archived.HeadCommit != "" && head != archived.HeadCommit {
+			fmt.Fprintf(os.Stderr, "Warning: branch %s has moved since the workspace was archived; applying changes on top of %s\n", archived.Branch, shortSHA(head))
+		}
+		_, _ = fmt.Fprintf(jsonout.MsgOut(), "Re-applying uncommitted changes...\n")
+		if err := snapshot.Restore(archived.Snapshot, ws.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			fmt.Fprintf(os.Stderr, "The uncommitted changes are kept in %s\n", archived.Snapshot)
+		} else {
+			changesRestored = true
+		}
+	}
+	if !archived.Dirty || changesRestored {
+		if err := snapshot.Remove(archived.Snapshot); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// Written after the snapshot, which may hold a copy from before the
+	// workspace's port changed
+	if err := writeEnvFile(&ws, rootPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// Run setup script
+	if !restoreNoSetup && cfg.Scripts.Setup != "" {
+		_, _ = fmt.Fprintf(jsonout.MsgOut(), "Running setup script: %s\n", cfg.Scripts.Setup)
+		defaultBranch, _ := git.DefaultBranch(rootPath)
+		envVars, envErr := env.Build(&ws, rootPath, defaultBranch, cfg)
+		warnEnv(envErr)
+		if err := runScript(cfg.Scripts.Setup, ws.Path, envVars); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: setup script failed: %v\n", err)
+			fmt.Fprintf(os.Stderr, "You can re-run setup with: cd %s && %s\n", ws.Path, cfg.Scripts.Setup)
+		}
+	}
+
+	if jsonout.Enabled {
+		return jsonout.Write(struct {
+			Action    string `json:"action"`
+			Workspace struct {
+				Name   string `json:"name"`
+				Path   string `json:"path"`
+				Branch string `json:"branch"`
+				Port   int    `json:"port"`
+			} `json:"workspace"`
+			ChangesRestored bool `json:"changes_restored"`
+		}{Action: "restored", Workspace: struct {
+			Name   string `json:"name"`
+			Path   string `json:"path"`
+			Branch string `json:"branch"`
+			Port   int    `json:"port"`
+		}{Name: ws.Name, Path: ws.Path, Branch: archived.Branch, Port: ws.Port}, ChangesRestored: changesRestored})
+	}
+
+	fmt.Println()
+	fmt.Printf("Workspace restored:\n")
+	fmt.Printf("  Name:    %s\n", ws.Name)
+	fmt.Printf("  Branch:  %s\n", archived.Branch)
+	fmt.Printf("  Ports:   %d-%d (%d ports)\n", ws.Port, ws.Port+cfg.PortRange-1, cfg.PortRange)
+	fmt.Printf("  Path:    %s\n", shortenHomePath(ws.Path))
+	if changesRestored {
+		fmt.Printf("  Changes: uncommitted changes re-applied\n")
+	}
+	return nil
+}
+
+// shortSHA abbreviates a commit SHA for display.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -39,22 +39,28 @@ Configure via fr8.json (or conductor.json) in your repo root.`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		jsonout.Enabled = jsonOutput
-		jsonout.Concise = conciseOutput
+		return applyOutputFlags()
+	},
+}
 
-		if conciseOutput && !jsonOutput {
-			return fmt.Errorf("--concise requires --json")
-		}
+// applyOutputFlags configures jsonout from --json and --concise. Commands
+// that parse their own flags (DisableFlagParsing) call it after parsing.
+func applyOutputFlags() error {
+	jsonout.Enabled = jsonOutput
+	jsonout.Concise = conciseOutput
 
-		if jsonOutput {
-			jsonout.SetMsgOut(io.Discard)
-		} else if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
-			// Non-TTY: route human messages to stderr so piped stdout stays clean
-			jsonout.SetMsgOut(os.Stderr)
-		}
+	if conciseOutput && !jsonOutput {
+		return fmt.Errorf("--concise requires --json")
+	}
 
-		return nil
-	},
+	if jsonOutput {
+		jsonout.SetMsgOut(io.Discard)
+	} else if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+		// Non-TTY: route human messages to stderr so piped stdout stays clean
+		jsonout.SetMsgOut(os.Stderr)
+	}
+
+	return nil
 }
 
 func init() {
@@ -4,22 +4,34 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/config"
-	"github.com/protocollar/fr8/internal/env"
 	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/health"
 	"github.com/protocollar/fr8/internal/jsonout"
 	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
+	"github.com/protocollar/fr8/internal/workspace"
 )
 
 var runAll bool
 var runIfNotRunning bool
+var runService string
+var runWait bool
+var runWaitTimeout time.Duration
+var runLabels []string
 
 func init() {
 	runCmd.Flags().BoolVarP(&runAll, "all", "A", false, "Start all workspaces in the current repo")
 	runCmd.Flags().BoolVar(&runIfNotRunning, "if-not-running", false, "succeed silently if already running")
+	runCmd.Flags().StringVar(&runService, "service", "", "start only this service (adds it to a running session)")
+	runCmd.Flags().BoolVar(&runWait, "wait", false, "wait until the workspace passes its healthcheck")
+	runCmd.Flags().DurationVar(&runWaitTimeout, "wait-timeout", defaultWaitTimeout, "give up waiting after this long (with --wait)")
+	runCmd.Flags().StringSliceVar(&runLabels, "label", nil, "with --all, only start workspaces with this label (repeatable)")
 	workspaceCmd.AddCommand(runCmd)
 }
 
@@ -28,7 +40,10 @@ var runCmd = &cobra.Command{
 	Short: "Run the dev server in a background tmux session",
 	Example: `  fr8 ws run
   fr8 ws run my-feature
-  fr8 ws run --all`,
+  fr8 ws run --all
+  fr8 ws run --all --label review
+  fr8 ws run my-feature --service worker
+  fr8 ws run my-feature --wait`,
 	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: workspaceNameCompletion,
 	RunE:              runRun,
@@ -39,10 +54,20 @@ func runRun(cmd *cobra.Command, args []string) error {
 		if len(args) > 0 {
 			return fmt.Errorf("cannot use --all with a workspace name")
 		}
+		if runService != "" {
+			return fmt.Errorf("cannot use --all with --service")
+		}
+		if runWait {
+			return fmt.Errorf("cannot use --all with --wait")
+		}
 		return runRunAll()
 	}
+	if len(runLabels) > 0 {
+		return fmt.Errorf("--label requires --all")
+	}
 
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return err
 	}
 
@@ -56,28 +81,78 @@ func runRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	cfg, err := config.Load(rootPath)
+	cfg, err := loadConfig(rootPath)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
-	if cfg.Scripts.Run == "" {
-		return fmt.Errorf("no run script configured (add \"scripts.run\" to fr8.json)")
+	if !cfg.HasRun() {
+		return fmt.Errorf("no run script configured (add \"scripts.run\" or \"services\" to fr8.json)")
 	}
 
 	defaultBranch, _ := git.DefaultBranch(rootPath)
-	envVars := env.BuildFr8Only(ws, rootPath, defaultBranch)
 
 	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
 
-	if tmux.IsRunning(sessionName) {
+	if runService != "" {
+		if _, ok := cfg.Services[runService]; !ok {
+			return fmt.Errorf("service %q not found in fr8.json", runService)
+		}
+		if rn.HasProcess(sessionName, runService) {
+			if runIfNotRunning {
+				healthState, err := runWaitHealthy(cmd, rn, cfg, ws, rootPath)
+				if err != nil {
+					return err
+				}
+				if jsonout.Enabled {
+					return jsonout.Write(struct {
+						Action    string `json:"action"`
+						Workspace string `json:"workspace"`
+						Session   string `json:"session"`
+						Service   string `json:"service"`
+						Health    string `json:"health,omitempty"`
+					}{Action: "already_running", Workspace: ws.Name, Session: sessionName, Service: runService, Health: healthState})
+				}
+				fmt.Printf("Service %q is already running in %q.\n", runService, ws.Name)
+				return nil
+			}
+			return fmt.Errorf("service %q is already running in %q", runService, sessionName)
+		}
+		if err := workspace.StartService(rn, cfg, ws, rootPath, defaultBranch, runService); err != nil {
+			return err
+		}
+		healthState, err := runWaitHealthy(cmd, rn, cfg, ws, rootPath)
+		if err != nil {
+			return err
+		}
+		if jsonout.Enabled {
+			return jsonout.Write(struct {
+				Action    string `json:"action"`
+				Workspace string `json:"workspace"`
+				Session   string `json:"session"`
+				Service   string `json:"service"`
+				Health    string `json:"health,omitempty"`
+			}{Action: "started", Workspace: ws.Name, Session: sessionName, Service: runService, Health: healthState})
+		}
+		fmt.Printf("Started service %q in %q.\n", runService, ws.Name)
+		fmt.Printf("  Logs: fr8 ws logs %s --service %s\n", ws.Name, runService)
+		fmt.Printf("  Stop: fr8 ws stop %s --service %s\n", ws.Name, runService)
+		return nil
+	}
+
+	if rn.IsRunning(sessionName) {
 		if runIfNotRunning {
+			healthState, err := runWaitHealthy(cmd, rn, cfg, ws, rootPath)
+			if err != nil {
+				return err
+			}
 			if jsonout.Enabled {
 				return jsonout.Write(struct {
 					Action    string `json:"action"`
 					Workspace string `json:"workspace"`
 					Session   string `json:"session"`
-				}{Action: "already_running", Workspace: ws.Name, Session: sessionName})
+					Health    string `json:"health,omitempty"`
+				}{Action: "already_running", Workspace: ws.Name, Session: sessionName, Health: healthState})
 			}
 			fmt.Printf("Workspace %q is already running.\n", ws.Name)
 			return nil
@@ -85,27 +160,54 @@ func runRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("session %q is already running (use fr8 ws attach to connect)", sessionName)
 	}
 
-	if err := tmux.Start(sessionName, ws.Path, cfg.Scripts.Run, envVars); err != nil {
+	if err := workspace.Start(rn, cfg, ws, rootPath, defaultBranch); err != nil {
+		return err
+	}
+	healthState, err := runWaitHealthy(cmd, rn, cfg, ws, rootPath)
+	if err != nil {
 		return err
 	}
 
 	if jsonout.Enabled {
 		return jsonout.Write(struct {
-			Action    string `json:"action"`
-			Workspace string `json:"workspace"`
-			Session   string `json:"session"`
-		}{Action: "started", Workspace: ws.Name, Session: sessionName})
+			Action    string   `json:"action"`
+			Workspace string   `json:"workspace"`
+			Session   string   `json:"session"`
+			Services  []string `json:"services,omitempty"`
+			Health    string   `json:"health,omitempty"`
+		}{Action: "started", Workspace: ws.Name, Session: sessionName, Services: serviceNames(cfg), Health: healthState})
 	}
 
 	fmt.Printf("Started %q in background.\n", ws.Name)
-	fmt.Printf("  Attach with: fr8 ws attach %s\n", ws.Name)
+	if healthState != "" {
+		fmt.Printf("  Health:      %s\n", healthState)
+	}
+	if names := serviceNames(cfg); len(names) > 0 {
+		fmt.Printf("  Services:    %s\n", strings.Join(names, ", "))
+	}
+	if rn.Name() == runner.TmuxBackend {
+		fmt.Printf("  Attach with: fr8 ws attach %s\n", ws.Name)
+	}
 	fmt.Printf("  Logs:        fr8 ws logs %s\n", ws.Name)
 	fmt.Printf("  Stop:        fr8 ws stop %s\n", ws.Name)
 	return nil
 }
 
+// runWaitHealthy waits for the workspace to become healthy when --wait is
+// set, returning its health for the output ("" without --wait).
+func runWaitHealthy(cmd *cobra.Command, rn runner.Runner, cfg *config.Config, ws *registry.Workspace, rootPath string) (string, error) {
+	if !runWait {
+		return "", nil
+	}
+	if err := waitHealthy(cmd.Context(), rn, cfg, ws, rootPath, runWaitTimeout); err != nil {
+		return "", err
+	}
+	return health.Healthy, nil
+}
+
 func runRunAll() error {
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return err
 	}
 
@@ -146,13 +248,13 @@ func runRunAll() error {
 		return nil
 	}
 
-	cfg, err := config.Load(rootPath)
+	cfg, err := loadConfig(rootPath)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
-	if cfg.Scripts.Run == "" {
-		return fmt.Errorf("no run script configured (add \"scripts.run\" to fr8.json)")
+	if !cfg.HasRun() {
+		return fmt.Errorf("no run script configured (add \"scripts.run\" or \"services\" to fr8.json)")
 	}
 
 	defaultBranch, _ := git.DefaultBranch(rootPath)
@@ -164,16 +266,18 @@ func runRunAll() error {
 
 	for i := range repo.Workspaces {
 		ws := &repo.Workspaces[i]
+		if !ws.HasLabels(runLabels) {
+			continue
+		}
 		sessionName := tmux.SessionName(repoName, ws.Name)
 
-		if tmux.IsRunning(sessionName) {
+		if rn.IsRunning(sessionName) {
 			skipped++
 			alreadyRunning = append(alreadyRunning, ws.Name)
 			continue
 		}
 
-		envVars := env.BuildFr8Only(ws, rootPath, defaultBranch)
-		if err := tmux.Start(sessionName, ws.Path, cfg.Scripts.Run, envVars); err != nil {
+		if err := workspace.Start(rn, cfg, ws, rootPath, defaultBranch); err != nil {
 			if !jsonout.Enabled {
 				fmt.Fprintf(os.Stderr, "Warning: failed to start %q: %v\n", ws.Name, err)
 			}
@@ -211,6 +315,19 @@ func runRunAll() error {
 	return nil
 }
 
+// serviceNames returns the configured service names in start order, or nil
+// when the config uses a single run script.
+func serviceNames(cfg *config.Config) []string {
+	if len(cfg.Services) == 0 {
+		return nil
+	}
+	order, err := cfg.ServiceOrder()
+	if err != nil {
+		return nil
+	}
+	return order
+}
+
 type runFailedItem struct {
 	Workspace string `json:"workspace"`
 	Error     string `json:"error"`
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+var scanDepth int
+var scanYes bool
+var scanDryRun bool
+
+func init() {
+	repoScanCmd.Flags().IntVar(&scanDepth, "depth", 3, "how many directory levels below dir to search")
+	repoScanCmd.Flags().BoolVarP(&scanYes, "yes", "y", false, "register every unregistered repo found without asking")
+	repoScanCmd.Flags().BoolVar(&scanDryRun, "dry-run", false, "only list the repos found")
+	repoCmd.AddCommand(repoScanCmd)
+}
+
+var repoScanCmd = &cobra.Command{
+	Use:   "scan [dir]",
+	Short: "Find fr8 repos under a directory and register them",
+	Long: `Searches dir (default: the current directory) for git repos with an
+fr8.json or conductor.json and registers the ones fr8 doesn't know yet, so
+that port allocation takes every repo's workspaces into account.
+
+Asks before registering each repo; --yes (and --json) registers them all.
+Repos are registered under their directory name, and skipped when another
+repo already has that name (register them with fr8 repo add --name).
+Hidden directories and node_modules are not searched.`,
+	Example: `  fr8 repo scan ~/Code
+  fr8 repo scan ~/Code --depth 2 --yes
+  fr8 repo scan ~/Code --dry-run --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRepoScan,
+}
+
+// scanItem is a repo found by fr8 repo scan.
+type scanItem struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Status string `json:"status"` // registered, already_registered, unregistered or skipped
+	Reason string `json:"reason,omitempty"`
+}
+
+func runRepoScan(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolving path: %w", err)
+	}
+	if scanDepth < 0 {
+		return fmt.Errorf("--depth must not be negative")
+	}
+
+	paths, err := scanRepos(dir, scanDepth)
+	if err != nil {
+		return err
+	}
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return err
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return fmt.Errorf("loading registry: %w", err)
+	}
+	items := classifyScan(reg, paths)
+
+	register := !scanDryRun && (scanYes || jsonout.Enabled)
+	if !scanDryRun && !register && isInteractive() {
+		for i := range items {
+			if items[i].Status != "unregistered" {
+				continue
+			}
+			fmt.Printf("Register %q (%s)? [y/N] ", items[i].Name, shortenHomePath(items[i].Path))
+			var response string
+			_, _ = fmt.Scanln(&response)
+			if response == "y" || response == "Y" {
+				items[i].Status = "selected"
+			}
+		}
+		register = true
+	} else if register {
+		for i := range items {
+			if items[i].Status == "unregistered" {
+				items[i].Status = "selected"
+			}
+		}
+	}
+
+	if register {
+		err := registry.Update(regPath, func(reg *registry.Registry) error {
+			for i := range items {
+				if items[i].Status != "selected" {
+					continue
+				}
+				if err := reg.Add(registry.Repo{Name: items[i].Name, Path: items[i].Path}); err != nil {
+					items[i].Status = "skipped"
+					items[i].Reason = err.Error()
+					continue
+				}
+				items[i].Status = "registered"
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("saving state: %w", err)
+		}
+	}
+	for i := range items {
+		if items[i].Status == "selected" {
+			items[i].Status = "unregistered"
+		}
+	}
+
+	if jsonout.Enabled {
+		if items == nil {
+			items = []scanItem{}
+		}
+		return jsonout.Write(items)
+	}
+
+	if len(items) == 0 {
+		fmt.Printf("No repos with fr8.json or conductor.json found under %s.\n", shortenHomePath(dir))
+		return nil
+	}
+	if isInteractive() && register {
+		fmt.Println()
+	}
+	unregistered := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tPATH\tSTATUS")
+	for _, item := range items {
+		status := strings.ReplaceAll(item.Status, "_", " ")
+		if item.Reason != "" {
+			status += ": " + item.Reason
+		}
+		if item.Status == "unregistered" {
+			unregistered++
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", item.Name, shortenHomePath(item.Path), status)
+	}
+	_ = w.Flush()
+	if unregistered > 0 && !register {
+		fmt.Printf("\nRegister them with: fr8 repo scan %s --yes\n", shortenHomePath(dir))
+	}
+	return nil
+}
+
+// classifyScan names the repos found at paths and marks the ones reg
+// already knows. Repos whose name is taken by another repo are skipped.
+func classifyScan(reg *registry.Registry, paths []string) []scanItem {
+	var items []scanItem
+	names := make(map[string]bool)
+	for _, p := range paths {
+		if r := reg.FindByPath(p); r != nil {
+			items = append(items, scanItem{Name: r.Name, Path: p, Status: "already_registered"})
+			continue
+		}
+		item := scanItem{Name: filepath.Base(p), Path: p, Status: "unregistered"}
+		if r := reg.Find(item.Name); r != nil || names[item.Name] {
+			item.Status = "skipped"
+			item.Reason = fmt.Sprintf("name %q is taken (use: fr8 repo add %s --name <name>)", item.Name, p)
+		}
+		names[item.Name] = true
+		items = append(items, item)
+	}
+	return items
+}
+
+// scanRepos returns the root worktrees of the git repos with an fr8 config
+// under dir, searching at most depth levels down. It doesn't descend into
+// repos, hidden directories or node_modules.
+func scanRepos(dir string, depth int) ([]string, error) {
+	var repos []string
+	base := strings.Count(filepath.Clean(dir), string(filepath.Separator))
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return fs.SkipDir // unreadable directory
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+			return fs.SkipDir
+		}
+
+		if fi, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+			// A .git file is a linked worktree or a submodule, unless it's
+			// the root worktree of a bare repo
+			if fi.IsDir() || isRootWorktree(path) {
+				if hasConfigFile(path) {
+					if resolved, err := filepath.EvalSymlinks(path); err == nil {
+						path = resolved
+					}
+					repos = append(repos, path)
+				}
+			}
+			return fs.SkipDir
+		}
+		if strings.Count(path, string(filepath.Separator))-base >= depth {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", dir, err)
+	}
+	return repos, nil
+}
+
+func hasConfigFile(dir string) bool {
+	for _, name := range config.FileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isRootWorktree(dir string) bool {
+	root, err := git.RootWorktreePath(dir)
+	return err == nil && canonicalPath(root) == canonicalPath(dir)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func TestScanRepos(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := func(rel, configFile string) string {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Join(p, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if configFile != "" {
+			if err := os.WriteFile(filepath.Join(p, configFile), []byte("{}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return p
+	}
+	api := repo("api", "fr8.json")
+	repo("no-config", "")
+	legacy := repo(filepath.Join("clients", "acme", "legacy"), "conductor.json")
+	repo(filepath.Join("api", "vendor", "nested"), "fr8.json")
+	repo(filepath.Join(".cache", "hidden"), "fr8.json")
+	repo(filepath.Join("web", "node_modules", "pkg"), "fr8.json")
+
+	got, err := scanRepos(root, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{api, legacy}; !slices.Equal(got, want) {
+		t.Errorf("scanRepos(depth 3) = %q, want %q", got, want)
+	}
+
+	got, err = scanRepos(root, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{api}; !slices.Equal(got, want) {
+		t.Errorf("scanRepos(depth 2) = %q, want %q", got, want)
+	}
+}
+
+func TestClassifyScan(t *testing.T) {
+	reg := &registry.Registry{Repos: []registry.Repo{
+		{Name: "api", Path: "/code/api"},
+		{Name: "web", Path: "/old/web"},
+	}}
+	items := classifyScan(reg, []string{"/code/api", "/code/web", "/code/tools", "/clients/tools"})
+
+	want := []struct{ name, status string }{
+		{"api", "already_registered"},
+		{"web", "skipped"},
+		{"tools", "unregistered"},
+		{"tools", "skipped"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("classifyScan() = %+v", items)
+	}
+	for i, w := range want {
+		if items[i].Name != w.name || items[i].Status != w.status {
+			t.Errorf("items[%d] = %+v, want %s %s", i, items[i], w.name, w.status)
+		}
+	}
+}
@@ -47,7 +47,9 @@ func runShell(cmd *cobra.Command, args []string) error {
 	}
 
 	defaultBranch, _ := git.DefaultBranch(rootPath)
-	envVars := env.Build(ws, rootPath, defaultBranch)
+	cfg, _ := loadConfig(rootPath)
+	envVars, envErr := env.Build(ws, rootPath, defaultBranch, cfg)
+	warnEnv(envErr)
 
 	// Use the user's preferred shell
 	userShell := os.Getenv("SHELL")
@@ -6,11 +6,15 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/config"
 	"github.com/protocollar/fr8/internal/env"
 	"github.com/protocollar/fr8/internal/gh"
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
+	"github.com/protocollar/fr8/internal/workspace"
 )
 
 func init() {
@@ -28,20 +32,31 @@ var statusCmd = &cobra.Command{
 }
 
 type workspaceStatusJSON struct {
-	Name      string            `json:"name"`
-	Path      string            `json:"path"`
-	Branch    string            `json:"branch"`
-	Port      int               `json:"port"`
-	PortEnd   int               `json:"port_end"`
-	Dirty     bool              `json:"dirty"`
-	Staged    int               `json:"staged"`
-	Modified  int               `json:"modified"`
-	Untracked int               `json:"untracked"`
-	Running   bool              `json:"running"`
-	CreatedAt time.Time         `json:"created_at"`
-	Env       map[string]string `json:"env"`
-	LastCommit *git.CommitInfo  `json:"last_commit,omitempty"`
-	PR         *gh.PRInfo       `json:"pr,omitempty"`
+	Name           string                   `json:"name"`
+	Path           string                   `json:"path"`
+	Branch         string                   `json:"branch"`
+	Port           int                      `json:"port"`
+	PortEnd        int                      `json:"port_end"`
+	Dirty          bool                     `json:"dirty"`
+	Staged         int                      `json:"staged"`
+	Modified       int                      `json:"modified"`
+	Untracked      int                      `json:"untracked"`
+	Running        bool                     `json:"running"`
+	Health         string                   `json:"health,omitempty"`
+	LastCrash      *runner.Exit             `json:"last_crash,omitempty"`
+	Services       []workspace.ServiceState `json:"services,omitempty"`
+	Ports          map[string]int           `json:"ports,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+	Origin         *registry.Origin         `json:"origin,omitempty"`
+	BranchSwitched bool                     `json:"branch_switched,omitempty"`
+	Note           string                   `json:"note,omitempty"`
+	Labels         []string                 `json:"labels,omitempty"`
+	Pinned         bool                     `json:"pinned,omitempty"`
+	ExpiresAt      *time.Time               `json:"expires_at,omitempty"`
+	Env            map[string]string        `json:"env"`
+	EnvWarnings    []string                 `json:"env_warnings,omitempty"`
+	LastCommit     *git.CommitInfo          `json:"last_commit,omitempty"`
+	PR             *gh.PRInfo               `json:"pr,omitempty"`
 }
 
 func (w workspaceStatusJSON) Concise() any {
@@ -49,9 +64,10 @@ func (w workspaceStatusJSON) Concise() any {
 		Name    string `json:"name"`
 		Port    int    `json:"port"`
 		Running bool   `json:"running"`
+		Health  string `json:"health,omitempty"`
 		Dirty   bool   `json:"dirty"`
 		Path    string `json:"path"`
-	}{Name: w.Name, Port: w.Port, Running: w.Running, Dirty: w.Dirty, Path: w.Path}
+	}{Name: w.Name, Port: w.Port, Running: w.Running, Health: w.Health, Dirty: w.Dirty, Path: w.Path}
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
@@ -81,42 +97,69 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		pr, _ = gh.PRStatus(ws.Path, branch)
 	}
 
+	rn := runner.Default()
+	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
 	running := false
-	if tmux.Available() == nil {
-		sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
-		running = tmux.IsRunning(sessionName)
+	if rn.Available() == nil {
+		running = rn.IsRunning(sessionName)
 	}
+	lastCrash := crashedSession(sessionName, running)
 
-	if jsonout.Enabled {
-		vars := env.BuildFr8Only(ws, rootPath, defaultBranch)
-		envMap := make(map[string]string)
-		for _, v := range vars {
-			parts := strings.SplitN(v, "=", 2)
-			if len(parts) == 2 && strings.HasPrefix(parts[0], "FR8_") {
-				envMap[parts[0]] = parts[1]
-			}
+	var services []workspace.ServiceState
+	var healthState string
+	cfg, _ := loadConfig(rootPath)
+	if cfg != nil {
+		services = workspace.ServiceStates(rn, cfg, ws, rootPath)
+		healthState = workspace.Health(cmd.Context(), rn, cfg, ws, rootPath)
+	}
+
+	var expiresAt *time.Time
+	if cfg != nil {
+		if t := ws.Expiry(cfg.TTLDuration()); !t.IsZero() {
+			expiresAt = &t
 		}
+	}
+
+	if jsonout.Enabled {
+		vars, envErr := env.BuildFr8Only(ws, rootPath, defaultBranch, cfg)
+		warnEnv(envErr)
+		envMap := env.Map(vars)
 		return jsonout.Write(workspaceStatusJSON{
-			Name:       ws.Name,
-			Path:       ws.Path,
-			Branch:     branch,
-			Port:       ws.Port,
-			PortEnd:    ws.Port + 9,
-			Dirty:      dc.Dirty(),
-			Staged:     dc.Staged,
-			Modified:   dc.Modified,
-			Untracked:  dc.Untracked,
-			Running:    running,
-			CreatedAt:  ws.CreatedAt,
-			Env:        envMap,
-			LastCommit: lastCommitPtr,
-			PR:         pr,
+			Name:           ws.Name,
+			Path:           ws.Path,
+			Branch:         branch,
+			Port:           ws.Port,
+			PortEnd:        ws.Port + 9,
+			Dirty:          dc.Dirty(),
+			Staged:         dc.Staged,
+			Modified:       dc.Modified,
+			Untracked:      dc.Untracked,
+			Running:        running,
+			Health:         healthState,
+			LastCrash:      lastCrash,
+			Services:       services,
+			Ports:          namedPorts(cfg, ws.Port),
+			CreatedAt:      ws.CreatedAt,
+			Origin:         ws.Origin,
+			BranchSwitched: ws.BranchSwitched(branch),
+			Note:           ws.Note,
+			Labels:         ws.Labels,
+			Pinned:         ws.Pinned,
+			ExpiresAt:      expiresAt,
+			Env:            envMap,
+			EnvWarnings:    envWarnings(envErr),
+			LastCommit:     lastCommitPtr,
+			PR:             pr,
 		})
 	}
 
 	fmt.Printf("Workspace: %s\n", ws.Name)
 	fmt.Printf("  Path:           %s\n", ws.Path)
-	fmt.Printf("  Branch:         %s\n", branch)
+	if ws.BranchSwitched(branch) {
+		fmt.Printf("  Branch:         %s (created on %s)\n", branch, ws.Origin.Branch)
+	} else {
+		fmt.Printf("  Branch:         %s\n", branch)
+	}
 	if dc.Dirty() {
 		fmt.Printf("  Status:         dirty (%d staged, %d modified, %d untracked)\n", dc.Staged, dc.Modified, dc.Untracked)
 	} else {
@@ -124,6 +167,21 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Printf("  Port:           %d (range %d-%d)\n", ws.Port, ws.Port, ws.Port+9)
 	fmt.Printf("  Created:        %s\n", ws.CreatedAt.Format("2006-01-02 15:04:05"))
+	if ws.Origin != nil {
+		fmt.Printf("  Origin:         %s\n", describeOrigin(ws.Origin))
+	}
+	if len(ws.Labels) > 0 {
+		fmt.Printf("  Labels:         %s\n", strings.Join(ws.Labels, ", "))
+	}
+	if ws.Note != "" {
+		fmt.Printf("  Note:           %s\n", ws.Note)
+	}
+	switch {
+	case ws.Pinned:
+		fmt.Printf("  Pinned:         yes (fr8 ws gc leaves it alone)\n")
+	case expiresAt != nil:
+		fmt.Printf("  Expires:        %s\n", expiresAt.Local().Format("2006-01-02 15:04"))
+	}
 	if lastCommitPtr != nil {
 		fmt.Printf("  Last Commit:    %s (%s)\n", lastCommit.Subject, lastCommit.Time.Format("2006-01-02 15:04"))
 	}
@@ -141,16 +199,81 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  FR8_ROOT_PATH       %s\n", rootPath)
 	fmt.Printf("  FR8_DEFAULT_BRANCH  %s\n", defaultBranch)
 	fmt.Printf("  FR8_PORT            %d\n", ws.Port)
+	if cfg != nil {
+		for _, name := range cfg.PortNames() {
+			fmt.Printf("  %-19s %d\n", config.PortEnvName(name), ws.Port+cfg.Ports[name])
+		}
+	}
 
 	// Process status
 	fmt.Println()
-	if tmux.Available() == nil {
-		if running {
+	if rn.Available() == nil {
+		if running && rn.Name() == runner.TmuxBackend {
 			fmt.Printf("Process: running (fr8 ws attach %s)\n", ws.Name)
+		} else if running {
+			fmt.Printf("Process: running (fr8 ws logs %s)\n", ws.Name)
+		} else if lastCrash != nil {
+			fmt.Printf("Process: %s (fr8 ws logs %s)\n", lastCrash.Summary(), ws.Name)
 		} else {
 			fmt.Printf("Process: not running (fr8 ws run %s)\n", ws.Name)
 		}
+		if healthState != "" {
+			fmt.Printf("Health:  %s (%s)\n", healthState, workspace.HealthCheck(cfg, ws))
+		}
+		for _, svc := range services {
+			state, detail := "stopped", ""
+			switch {
+			case svc.Running && svc.LastExit != nil:
+				state, detail = "running", "  (restarted after "+svc.LastExit.Detail()+")"
+			case svc.Running:
+				state = "running"
+			case svc.LastExit != nil:
+				state, detail = svc.LastExit.State(), "  ("+svc.LastExit.Detail()+")"
+			}
+			fmt.Printf("  %-18s %-8s port %d%s\n", svc.Name, state, svc.Port, detail)
+		}
 	}
 
 	return nil
 }
+
+// namedPorts resolves the config's named ports to absolute ports for a
+// workspace whose block starts at base. Returns nil when none are configured.
+func namedPorts(cfg *config.Config, base int) map[string]int {
+	if cfg == nil || len(cfg.Ports) == 0 {
+		return nil
+	}
+	ports := make(map[string]int, len(cfg.Ports))
+	for name, offset := range cfg.Ports {
+		ports[name] = base + offset
+	}
+	return ports
+}
+
+// describeOrigin summarizes how a workspace was created, e.g.
+// "PR #42 at 1a2b3c4d5e6f (fr8 ws new -p 42)".
+func describeOrigin(o *registry.Origin) string {
+	var desc string
+	switch o.Source {
+	case registry.SourcePR:
+		desc = "PR"
+		if o.PR != 0 {
+			desc += fmt.Sprintf(" #%d", o.PR)
+		}
+	case registry.SourceRemote:
+		desc = "remote branch " + o.Branch
+	case registry.SourceNew:
+		desc = "new branch " + o.Branch
+	case registry.SourceAdopted:
+		desc = "adopted worktree on branch " + o.Branch
+	default:
+		desc = "branch " + o.Branch
+	}
+	if o.BaseCommit != "" {
+		desc += " at " + o.BaseCommit[:min(12, len(o.BaseCommit))]
+	}
+	if o.CreatedBy != "" {
+		desc += " (" + o.CreatedBy + ")"
+	}
+	return desc
+}
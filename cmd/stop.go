@@ -3,24 +3,36 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/spf13/cobra"
 	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
 )
 
 var stopAll bool
 var stopIfRunning bool
+var stopService string
+var stopLabels []string
 
 func init() {
 	stopCmd.Flags().BoolVarP(&stopAll, "all", "A", false, "Stop all running fr8 sessions")
 	stopCmd.Flags().BoolVar(&stopIfRunning, "if-running", false, "succeed silently if not running")
+	stopCmd.Flags().StringVar(&stopService, "service", "", "stop only this service, leaving the rest running")
+	stopCmd.Flags().StringSliceVar(&stopLabels, "label", nil, "with --all, only stop workspaces with this label (repeatable)")
 	workspaceCmd.AddCommand(stopCmd)
 }
 
 var stopCmd = &cobra.Command{
 	Use:               "stop [name]",
-	Short:             "Stop a workspace's background tmux session",
+	Short:             "Stop a workspace's background session",
+	Example: `  fr8 ws stop
+  fr8 ws stop my-feature
+  fr8 ws stop my-feature --service worker
+  fr8 ws stop --all
+  fr8 ws stop --all --label review`,
 	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: workspaceNameCompletion,
 	RunE:              runStop,
@@ -31,10 +43,17 @@ func runStop(cmd *cobra.Command, args []string) error {
 		if len(args) > 0 {
 			return fmt.Errorf("cannot use --all with a workspace name")
 		}
+		if stopService != "" {
+			return fmt.Errorf("cannot use --all with --service")
+		}
 		return runStopAll()
 	}
+	if len(stopLabels) > 0 {
+		return fmt.Errorf("--label requires --all")
+	}
 
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return err
 	}
 
@@ -49,7 +68,10 @@ func runStop(cmd *cobra.Command, args []string) error {
 	}
 
 	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
-	if !tmux.IsRunning(sessionName) {
+	if stopService != "" {
+		return stopWorkspaceService(rn, ws.Name, sessionName, stopService)
+	}
+	if !rn.IsRunning(sessionName) {
 		if stopIfRunning {
 			if jsonout.Enabled {
 				return jsonout.Write(struct {
@@ -72,7 +94,7 @@ func runStop(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	if err := tmux.Stop(sessionName); err != nil {
+	if err := rn.Stop(sessionName); err != nil {
 		return err
 	}
 
@@ -88,15 +110,73 @@ func runStop(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// stopWorkspaceService stops a single service in a workspace session.
+func stopWorkspaceService(rn runner.Runner, wsName, sessionName, service string) error {
+	type result struct {
+		Action    string `json:"action"`
+		Workspace string `json:"workspace"`
+		Session   string `json:"session"`
+		Service   string `json:"service"`
+	}
+
+	if !rn.HasProcess(sessionName, service) {
+		if jsonout.Enabled {
+			return jsonout.Write(result{Action: "not_running", Workspace: wsName, Session: sessionName, Service: service})
+		}
+		fmt.Printf("Service %q is not running in %q.\n", service, wsName)
+		return nil
+	}
+
+	if err := rn.StopProcess(sessionName, service); err != nil {
+		return err
+	}
+
+	if jsonout.Enabled {
+		return jsonout.Write(result{Action: "stopped", Workspace: wsName, Session: sessionName, Service: service})
+	}
+	fmt.Printf("Stopped service %q in %q.\n", service, wsName)
+	return nil
+}
+
+// labeledSessions returns the session names of every registered workspace
+// that has all of labels.
+func labeledSessions(labels []string) (map[string]bool, error) {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading registry: %w", err)
+	}
+	names := make(map[string]bool)
+	for _, repo := range reg.Repos {
+		for _, ws := range repo.Workspaces {
+			if ws.HasLabels(labels) {
+				names[tmux.SessionName(tmux.RepoName(repo.Path), ws.Name)] = true
+			}
+		}
+	}
+	return names, nil
+}
+
 func runStopAll() error {
-	if err := tmux.Available(); err != nil {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
 		return err
 	}
 
-	sessions, err := tmux.ListFr8Sessions()
+	sessions, err := rn.List()
 	if err != nil {
 		return fmt.Errorf("listing sessions: %w", err)
 	}
+	if len(stopLabels) > 0 {
+		labeled, err := labeledSessions(stopLabels)
+		if err != nil {
+			return err
+		}
+		sessions = slices.DeleteFunc(sessions, func(s runner.Session) bool { return !labeled[s.Name] })
+	}
 
 	if len(sessions) == 0 {
 		if jsonout.Enabled {
@@ -112,7 +192,7 @@ func runStopAll() error {
 	var stopped []string
 	var failed []runFailedItem
 	for _, s := range sessions {
-		if err := tmux.Stop(s.Name); err != nil {
+		if err := rn.Stop(s.Name); err != nil {
 			if !jsonout.Enabled {
 				fmt.Fprintf(os.Stderr, "Warning: failed to stop %q: %v\n", s.Name, err)
 			}
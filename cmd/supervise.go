@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/runner"
+)
+
+func init() {
+	rootCmd.AddCommand(superviseCmd)
+}
+
+// superviseCmd is started in the background by the native runner to own a
+// single workspace process. It is not meant to be run by hand.
+var superviseCmd = &cobra.Command{
+	Use:                runner.SuperviseCommand + " <run-dir> <name> <work-dir> <log-file> <policy> <command>",
+	Short:              "Supervise a workspace process for the native runner",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runner.Supervise(args)
+	},
+}
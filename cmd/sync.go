@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/filesync"
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+var syncAll bool
+var syncDryRun bool
+var syncReverse bool
+var syncForce bool
+
+func init() {
+	syncCmd.Flags().BoolVarP(&syncAll, "all", "a", false, "sync every workspace of the repo")
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "only show the files that differ")
+	syncCmd.Flags().BoolVar(&syncReverse, "reverse", false, "copy the workspace's changed files back to the root worktree")
+	syncCmd.Flags().BoolVar(&syncForce, "force", false, "with --reverse, also overwrite root files changed since the workspace's copy")
+	workspaceCmd.AddCommand(syncCmd)
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [name]",
+	Short: "Re-copy .worktreeinclude files into a workspace",
+	Long: `Copies the files listed in .worktreeinclude from the root worktree into a
+workspace again, the same way fr8 ws new does, e.g. after a credentials file
+was rotated. Only files whose content differs are copied.
+
+With --reverse, the workspace's copies of those files are copied back to the
+root worktree instead, so a change made in one workspace can be shared with
+the next ones. Symlinked files are skipped in both directions once linked.
+Root files modified after the workspace's copy was made are listed and left
+alone unless --force is given.
+
+The workspace name is optional if you're inside a workspace directory.`,
+	Example: `  fr8 ws sync my-feature
+  fr8 ws sync --all --dry-run
+  fr8 ws sync my-feature --reverse`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: workspaceNameCompletion,
+	RunE:              runSync,
+}
+
+// syncResult is the outcome of syncing one workspace.
+type syncResult struct {
+	Workspace string            `json:"workspace"`
+	Reverse   bool              `json:"reverse"`
+	DryRun    bool              `json:"dry_run"`
+	Files     []filesync.Change `json:"files"`
+	Error     string            `json:"error,omitempty"`
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	if syncAll && len(args) > 0 {
+		return fmt.Errorf("cannot use --all with a workspace name")
+	}
+	if syncAll && syncReverse {
+		return fmt.Errorf("cannot use --reverse with --all; pick the workspace to copy from")
+	}
+	if syncForce && !syncReverse {
+		return fmt.Errorf("--force only applies to --reverse")
+	}
+
+	var rootPath string
+	var targets []registry.Workspace
+	if syncAll {
+		repo, err := currentRepo()
+		if err != nil {
+			return err
+		}
+		rootPath, err = git.RootWorktreePath(repo.Path)
+		if err != nil {
+			rootPath = repo.Path
+		}
+		targets = repo.Workspaces
+	} else {
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+		ws, root, err := resolveWorkspace(name)
+		if err != nil {
+			return err
+		}
+		rootPath = root
+		targets = []registry.Workspace{*ws}
+	}
+
+	opts := filesync.Options{DryRun: syncDryRun, Reverse: syncReverse, Force: syncForce}
+	results := []syncResult{}
+	failed := 0
+	for _, ws := range targets {
+		res := syncWorkspace(&ws, rootPath, opts)
+		if res.Error != "" {
+			failed++
+		}
+		results = append(results, res)
+	}
+
+	if jsonout.Enabled {
+		if err := jsonout.Write(results); err != nil {
+			return err
+		}
+	} else if len(results) == 0 {
+		fmt.Println("No workspaces to sync.")
+	} else {
+		for _, res := range results {
+			printSyncResult(res)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to sync %d workspace(s)", failed)
+	}
+	return nil
+}
+
+// syncWorkspace re-applies .worktreeinclude to a workspace.
+func syncWorkspace(ws *registry.Workspace, rootPath string, opts filesync.Options) syncResult {
+	res := syncResult{Workspace: ws.Name, Reverse: opts.Reverse, DryRun: opts.DryRun}
+	if _, err := os.Stat(ws.Path); err != nil {
+		res.Files = []filesync.Change{}
+		res.Error = fmt.Sprintf("worktree %s is missing (see: fr8 repo reconcile)", ws.Path)
+		return res
+	}
+	changes, err := filesync.Sync(rootPath, ws.Path, opts)
+	if err != nil {
+		res.Error = err.Error()
+	}
+	if changes == nil {
+		changes = []filesync.Change{}
+	}
+	res.Files = changes
+	return res
+}
+
+func printSyncResult(res syncResult) {
+	target := fmt.Sprintf("workspace %q", res.Workspace)
+	upToDate := fmt.Sprintf("Workspace %q is up to date.", res.Workspace)
+	if res.Reverse {
+		target = fmt.Sprintf("the root worktree from %q", res.Workspace)
+		upToDate = fmt.Sprintf("The root worktree is up to date with %q.", res.Workspace)
+	}
+	var copied, skipped []filesync.Change
+	for _, c := range res.Files {
+		if c.Action == "skip" {
+			skipped = append(skipped, c)
+		} else {
+			copied = append(copied, c)
+		}
+	}
+	switch {
+	case res.Error != "" && len(res.Files) == 0:
+		fmt.Printf("Failed to sync %s: %s\n", target, res.Error)
+		return
+	case len(res.Files) == 0:
+		fmt.Println(upToDate)
+		return
+	case len(copied) == 0:
+	case res.DryRun:
+		fmt.Printf("Would sync %d file(s) into %s:\n", len(copied), target)
+	default:
+		fmt.Printf("Synced %d file(s) into %s:\n", len(copied), target)
+	}
+	printSyncChanges(os.Stdout, copied, res.DryRun)
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped %d file(s) changed in the root worktree since %q copied them (overwrite with --force):\n", len(skipped), res.Workspace)
+		for _, c := range skipped {
+			fmt.Printf("  %s\n", c.Path)
+		}
+	}
+	if res.Error != "" {
+		fmt.Printf("  Failed: %s\n", res.Error)
+	}
+}
+
+// printSyncChanges lists the files a filesync.Sync copied or linked.
+func printSyncChanges(w io.Writer, changes []filesync.Change, dryRun bool) {
+	for _, c := range changes {
+		verb := "Copied"
+		if c.Mode == filesync.ModeSymlink {
+			verb = "Linked"
+		}
+		if dryRun {
+			verb = "Would copy"
+			if c.Mode == filesync.ModeSymlink {
+				verb = "Would link"
+			}
+		}
+		suffix := ""
+		if c.Action == "create" {
+			suffix = " (new)"
+		}
+		_, _ = fmt.Fprintf(w, "  %s %s%s\n", verb, c.Path, suffix)
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/exitcode"
+	"github.com/protocollar/fr8/internal/health"
+	"github.com/protocollar/fr8/internal/jsonout"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
+	"github.com/protocollar/fr8/internal/workspace"
+)
+
+// defaultWaitTimeout is how long fr8 ws wait and fr8 ws run --wait wait for
+// a workspace to become healthy.
+const defaultWaitTimeout = 60 * time.Second
+
+var waitTimeout time.Duration
+
+func init() {
+	waitCmd.Flags().DurationVar(&waitTimeout, "timeout", defaultWaitTimeout, "give up after this long")
+	workspaceCmd.AddCommand(waitCmd)
+}
+
+var waitCmd = &cobra.Command{
+	Use:   "wait [name]",
+	Short: "Wait until a running workspace passes its healthcheck",
+	Long: `Wait until a running workspace passes the healthcheck in fr8.json.
+
+Without a healthcheck, waits until the workspace's base port (FR8_PORT)
+accepts TCP connections. Fails early if the workspace stops or a service
+crashes, and exits with code 9 if it is not healthy before --timeout.`,
+	Example: `  fr8 ws wait
+  fr8 ws wait my-feature --timeout 2m
+  fr8 ws run my-feature --wait`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: workspaceNameCompletion,
+	RunE:              runWsWait,
+}
+
+func runWsWait(cmd *cobra.Command, args []string) error {
+	rn := runner.Default()
+	if err := rn.Available(); err != nil {
+		return err
+	}
+
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	ws, rootPath, err := resolveWorkspace(name)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(rootPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	start := time.Now()
+	if err := waitHealthy(cmd.Context(), rn, cfg, ws, rootPath, waitTimeout); err != nil {
+		return err
+	}
+	elapsed := time.Since(start).Round(100 * time.Millisecond)
+
+	if jsonout.Enabled {
+		return jsonout.Write(struct {
+			Workspace string `json:"workspace"`
+			Health    string `json:"health"`
+			Check     string `json:"check"`
+			Elapsed   string `json:"elapsed"`
+		}{Workspace: ws.Name, Health: health.Healthy, Check: workspace.HealthCheck(cfg, ws).String(), Elapsed: elapsed.String()})
+	}
+	fmt.Printf("Workspace %q is healthy (after %s).\n", ws.Name, elapsed)
+	return nil
+}
+
+// waitHealthy waits up to timeout for a running workspace to pass its
+// healthcheck, printing what it is waiting for.
+func waitHealthy(ctx context.Context, rn runner.Runner, cfg *config.Config, ws *registry.Workspace, rootPath string, timeout time.Duration) error {
+	_, _ = fmt.Fprintf(jsonout.MsgOut(), "Waiting for %q (%s)...\n", ws.Name, workspace.HealthCheck(cfg, ws))
+	return waitForHealth(ctx, rn, cfg, ws, rootPath, timeout)
+}
+
+// waitForHealth waits up to timeout for a running workspace to pass its
+// healthcheck. A timeout is reported with exit code exitcode.Unhealthy.
+func waitForHealth(ctx context.Context, rn runner.Runner, cfg *config.Config, ws *registry.Workspace, rootPath string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := workspace.WaitHealthy(ctx, rn, cfg, ws, rootPath)
+	if errors.Is(err, health.ErrTimeout) {
+		return exitcode.Wrap("unhealthy", exitcode.Unhealthy, fmt.Errorf("workspace %q is not healthy after %s: %w", ws.Name, timeout, err))
+	}
+	return err
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/protocollar/fr8/internal/runner"
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+}
+
+// watchCmd wraps each process the tmux runner starts, restarting it per its
+// restart policy and recording how it exits. It is not meant to be run by hand.
+var watchCmd = &cobra.Command{
+	Use:                runner.WatchCommand + " <run-dir> <name> <policy> <command>",
+	Short:              "Run a workspace process under its restart policy",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runner.Watch(args)
+	},
+}
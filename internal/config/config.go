@@ -1,19 +1,34 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 )
 
 // Config represents the fr8.json (or conductor.json) configuration.
 type Config struct {
-	Scripts      Scripts `json:"scripts"`
-	PortRange    int     `json:"port_range"`
-	BasePort     int     `json:"base_port"`
-	WorktreePath string  `json:"worktree_path"`
+	Scripts      Scripts            `json:"scripts"`
+	Services     map[string]Service `json:"services,omitempty"`
+	Ports        map[string]int     `json:"ports,omitempty"`
+	Env          map[string]string  `json:"env,omitempty"`
+	Restart      string             `json:"restart,omitempty"`
+	Healthcheck  *Healthcheck       `json:"healthcheck,omitempty"`
+	TTL          string             `json:"ttl,omitempty"`
+	PortRange    int                `json:"port_range"`
+	BasePort     int                `json:"base_port"`
+	WorktreePath string             `json:"worktree_path"`
+
+	origins map[string]string // dotted key -> layer that set it; see Origin
 }
 
 // UnmarshalJSON supports both snake_case (preferred) and legacy camelCase keys.
@@ -30,6 +45,42 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	if v, ok := raw["services"]; ok {
+		if err := json.Unmarshal(v, &c.Services); err != nil {
+			return fmt.Errorf("parsing services: %w", err)
+		}
+	}
+
+	if v, ok := raw["ports"]; ok {
+		if err := json.Unmarshal(v, &c.Ports); err != nil {
+			return fmt.Errorf("parsing ports: %w", err)
+		}
+	}
+
+	if v, ok := raw["env"]; ok {
+		if err := json.Unmarshal(v, &c.Env); err != nil {
+			return fmt.Errorf("parsing env: %w", err)
+		}
+	}
+
+	if v, ok := raw["restart"]; ok {
+		if err := json.Unmarshal(v, &c.Restart); err != nil {
+			return fmt.Errorf("parsing restart: %w", err)
+		}
+	}
+
+	if v, ok := raw["healthcheck"]; ok {
+		if err := json.Unmarshal(v, &c.Healthcheck); err != nil {
+			return fmt.Errorf("parsing healthcheck: %w", err)
+		}
+	}
+
+	if v, ok := raw["ttl"]; ok {
+		if err := json.Unmarshal(v, &c.TTL); err != nil {
+			return fmt.Errorf("parsing ttl: %w", err)
+		}
+	}
+
 	// port_range (preferred) or portRange (legacy)
 	if v, ok := raw["port_range"]; ok {
 		if err := json.Unmarshal(v, &c.PortRange); err != nil {
@@ -73,6 +124,435 @@ type Scripts struct {
 	Archive string `json:"archive"`
 }
 
+// Service defines one long-running process started by fr8 ws run.
+type Service struct {
+	Command    string            `json:"command"`
+	PortOffset int               `json:"port_offset,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	DependsOn  []string          `json:"depends_on,omitempty"`
+	Restart    string            `json:"restart,omitempty"`
+}
+
+// Restart policies for background processes, set with "restart" at the top
+// level of fr8.json or per service.
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// RestartPolicies lists the accepted restart policies.
+var RestartPolicies = []string{RestartNever, RestartOnFailure, RestartAlways}
+
+// RestartPolicy returns the restart policy for a service, falling back to the
+// top-level policy and then to RestartNever. An empty service name returns
+// the policy for scripts.run.
+func (c *Config) RestartPolicy(service string) string {
+	if svc, ok := c.Services[service]; ok && svc.Restart != "" {
+		return svc.Restart
+	}
+	if c.Restart != "" {
+		return c.Restart
+	}
+	return RestartNever
+}
+
+// ValidateRestart checks that every restart policy is one of RestartPolicies.
+func (c *Config) ValidateRestart() []error {
+	valid := func(policy string) bool {
+		if policy == "" {
+			return true
+		}
+		for _, p := range RestartPolicies {
+			if policy == p {
+				return true
+			}
+		}
+		return false
+	}
+
+	var errs []error
+	if !valid(c.Restart) {
+		errs = append(errs, fmt.Errorf("restart: unknown policy %q (use %s)", c.Restart, strings.Join(RestartPolicies, ", ")))
+	}
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if policy := c.Services[name].Restart; !valid(policy) {
+			errs = append(errs, fmt.Errorf("services.%s.restart: unknown policy %q (use %s)", name, policy, strings.Join(RestartPolicies, ", ")))
+		}
+	}
+	return errs
+}
+
+// Healthcheck defines how fr8 decides a running workspace is ready. With a
+// path it sends an HTTP GET to http://localhost:<port><path>; otherwise it
+// opens a TCP connection to the port. The port is the workspace's base port
+// plus port_offset.
+type Healthcheck struct {
+	Path        string `json:"path,omitempty"`
+	PortOffset  int    `json:"port_offset,omitempty"`
+	Status      int    `json:"status,omitempty"`       // expected HTTP status; 0 accepts any 2xx or 3xx
+	Timeout     string `json:"timeout,omitempty"`      // per check, default 2s
+	Interval    string `json:"interval,omitempty"`     // between checks, default 1s
+	StartPeriod string `json:"start_period,omitempty"` // reported as starting until then, default 60s
+}
+
+// Healthcheck defaults, used when a duration is not set.
+const (
+	DefaultHealthTimeout     = 2 * time.Second
+	DefaultHealthInterval    = time.Second
+	DefaultHealthStartPeriod = 60 * time.Second
+)
+
+// TimeoutDuration returns the per-check timeout.
+func (h *Healthcheck) TimeoutDuration() time.Duration {
+	return parseDuration(h.Timeout, DefaultHealthTimeout)
+}
+
+// IntervalDuration returns the delay between checks.
+func (h *Healthcheck) IntervalDuration() time.Duration {
+	return parseDuration(h.Interval, DefaultHealthInterval)
+}
+
+// StartPeriodDuration returns how long after starting a failing workspace is
+// reported as starting rather than unhealthy.
+func (h *Healthcheck) StartPeriodDuration() time.Duration {
+	return parseDuration(h.StartPeriod, DefaultHealthStartPeriod)
+}
+
+// parseDuration parses s, returning def if it is empty or invalid.
+func parseDuration(s string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+// ValidateHealthcheck checks the healthcheck section.
+func (c *Config) ValidateHealthcheck() []error {
+	h := c.Healthcheck
+	if h == nil {
+		return nil
+	}
+
+	var errs []error
+	if h.Path != "" && !strings.HasPrefix(h.Path, "/") {
+		errs = append(errs, fmt.Errorf("healthcheck.path: %q must start with /", h.Path))
+	}
+	if h.PortOffset < 0 || h.PortOffset >= c.PortRange {
+		errs = append(errs, fmt.Errorf("healthcheck.port_offset: offset %d is outside port_range %d (must be 0-%d)", h.PortOffset, c.PortRange, c.PortRange-1))
+	}
+	if h.Status != 0 && (h.Status < 100 || h.Status > 599) {
+		errs = append(errs, fmt.Errorf("healthcheck.status: %d is not an HTTP status code", h.Status))
+	}
+	if h.Status != 0 && h.Path == "" {
+		errs = append(errs, fmt.Errorf("healthcheck.status: only applies to HTTP checks (set healthcheck.path)"))
+	}
+	for _, d := range []struct{ key, value string }{
+		{"timeout", h.Timeout},
+		{"interval", h.Interval},
+		{"start_period", h.StartPeriod},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d.value); err != nil || v <= 0 {
+			errs = append(errs, fmt.Errorf("healthcheck.%s: %q is not a positive duration (e.g. 2s, 1m)", d.key, d.value))
+		}
+	}
+	return errs
+}
+
+// ParseDuration parses a duration such as "36h" or "14d". On top of the
+// units time.ParseDuration accepts, a whole number of days can be given with
+// a "d" suffix.
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// TTLDuration returns how long workspaces live before fr8 ws gc archives
+// them, or 0 if ttl is unset or invalid.
+func (c *Config) TTLDuration() time.Duration {
+	if c.TTL == "" {
+		return 0
+	}
+	if d, err := ParseDuration(c.TTL); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// ValidateTTL checks that ttl, if set, is a positive duration.
+func (c *Config) ValidateTTL() []error {
+	if c.TTL == "" {
+		return nil
+	}
+	if d, err := ParseDuration(c.TTL); err != nil || d <= 0 {
+		return []error{fmt.Errorf("ttl: %q is not a positive duration (e.g. 14d, 36h)", c.TTL)}
+	}
+	return nil
+}
+
+// Hash returns a short digest of the resolved config, used to tell whether
+// the config has changed since a workspace was created.
+func (c *Config) Hash() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// HasRun reports whether the config defines anything for fr8 ws run to start.
+func (c *Config) HasRun() bool {
+	return c.Scripts.Run != "" || len(c.Services) > 0
+}
+
+// ServiceOrder returns the service names sorted so that every service comes
+// after the services it depends on. Services without dependencies between
+// them are ordered by name. Returns an error for unknown dependencies or cycles.
+func (c *Config) ServiceOrder() ([]string, error) {
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("service dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		state[name] = visiting
+		deps := append([]string(nil), c.Services[name].DependsOn...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if _, ok := c.Services[dep]; !ok {
+				return fmt.Errorf("service %q depends on unknown service %q", name, dep)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// ValidateServices checks the services section: every service needs a
+// command and a name usable in session names, port offsets must fall inside
+// the port range, and dependencies must exist and be acyclic.
+func (c *Config) ValidateServices() []error {
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		svc := c.Services[name]
+		if strings.TrimSpace(svc.Command) == "" {
+			errs = append(errs, fmt.Errorf("services.%s: command is required", name))
+		}
+		if strings.ContainsAny(name, ":. ") {
+			errs = append(errs, fmt.Errorf("services.%s: name must not contain ':', '.' or spaces", name))
+		}
+		if svc.PortOffset < 0 || svc.PortOffset >= c.PortRange {
+			errs = append(errs, fmt.Errorf("services.%s: port_offset %d is outside port_range %d", name, svc.PortOffset, c.PortRange))
+		}
+	}
+	if _, err := c.ServiceOrder(); err != nil {
+		errs = append(errs, fmt.Errorf("services: %w", err))
+	}
+	return errs
+}
+
+// PortNames returns the names from the ports section, sorted.
+func (c *Config) PortNames() []string {
+	names := make([]string, 0, len(c.Ports))
+	for name := range c.Ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PortEnvName returns the environment variable for a named port,
+// e.g. "vite" -> "FR8_PORT_VITE" and "web-api" -> "FR8_PORT_WEB_API".
+func PortEnvName(name string) string {
+	var b strings.Builder
+	b.WriteString("FR8_PORT_")
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// ValidatePorts checks the ports section against the port range. Every
+// offset must fall inside the workspace's block and every name must map
+// to a distinct environment variable.
+func (c *Config) ValidatePorts() []error {
+	var errs []error
+	seen := make(map[string]string)
+	for _, name := range c.PortNames() {
+		offset := c.Ports[name]
+		if name == "" {
+			errs = append(errs, fmt.Errorf("ports: empty port name"))
+			continue
+		}
+		if offset < 0 || offset >= c.PortRange {
+			errs = append(errs, fmt.Errorf("ports.%s: offset %d is outside port_range %d (must be 0-%d)", name, offset, c.PortRange, c.PortRange-1))
+		}
+		envName := PortEnvName(name)
+		if other, ok := seen[envName]; ok {
+			errs = append(errs, fmt.Errorf("ports.%s: conflicts with ports.%s (both export %s)", name, other, envName))
+		}
+		seen[envName] = name
+	}
+	return errs
+}
+
+// EnvData is what the templates in the env section can refer to, e.g.
+// {{.Name}} or {{add .Port 1}}.
+type EnvData struct {
+	Name          string         // workspace name
+	Path          string         // workspace path
+	RootPath      string         // root worktree path
+	Repo          string         // repo directory name
+	DefaultBranch string         // e.g. main
+	Port          int            // first port of the workspace's block
+	Ports         map[string]int // named ports, e.g. {{.Ports.redis}}
+}
+
+// envFuncs are the functions available in env templates.
+var envFuncs = template.FuncMap{
+	"add":   func(a, b int) int { return a + b },
+	"sub":   func(a, b int) int { return a - b },
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	// snake turns a name into an identifier, e.g. for database names:
+	// "fix-login" -> "fix_login"
+	"snake": func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		}, s)
+	},
+}
+
+// EnvNames returns the names from the env section, sorted.
+func (c *Config) EnvNames() []string {
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpandEnv expands the env section's templates for a workspace and returns
+// the name and value of each variable, sorted by name. Variables whose
+// template fails are left out and reported in the error.
+func (c *Config) ExpandEnv(data EnvData) ([][2]string, error) {
+	var vars [][2]string
+	var errs []error
+	for _, name := range c.EnvNames() {
+		value, err := expandEnvValue(name, c.Env[name], data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		vars = append(vars, [2]string{name, value})
+	}
+	return vars, errors.Join(errs...)
+}
+
+func expandEnvValue(name, value string, data EnvData) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	tmpl, err := template.New(name).Funcs(envFuncs).Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("env.%s: %w", name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("env.%s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// ValidateEnv checks the env section: names must be valid variable names
+// not reserved by fr8, and templates must expand.
+func (c *Config) ValidateEnv() []error {
+	var errs []error
+	sample := EnvData{Name: "workspace", Path: "/path", RootPath: "/root", Repo: "repo", DefaultBranch: "main", Port: c.BasePort, Ports: c.Ports}
+	for _, name := range c.EnvNames() {
+		switch {
+		case !validEnvName(name):
+			errs = append(errs, fmt.Errorf("env.%s: not a valid environment variable name", name))
+			continue
+		case strings.HasPrefix(name, "FR8_") || strings.HasPrefix(name, "CONDUCTOR_"):
+			errs = append(errs, fmt.Errorf("env.%s: FR8_* and CONDUCTOR_* variables are set by fr8", name))
+			continue
+		}
+		if _, err := expandEnvValue(name, c.Env[name], sample); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func validEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
 // legacyKeys are the deprecated camelCase config keys and their snake_case replacements.
 var legacyKeys = map[string]string{
 	"portRange":    "port_range",
@@ -149,28 +629,36 @@ func MigrateKeys(path string) ([]string, error) {
 	return migrated, nil
 }
 
-// Load reads fr8.json from rootPath, falling back to conductor.json.
-// Returns config with defaults applied.
-func Load(rootPath string) (*Config, error) {
-	cfg := &Config{}
+// FileNames are the config files Load looks for in a repo, in order.
+var FileNames = []string{"fr8.json", "conductor.json"}
 
-	for _, name := range []string{"fr8.json", "conductor.json"} {
-		p := filepath.Join(rootPath, name)
-		data, err := os.ReadFile(p)
-		if err != nil {
-			if os.IsNotExist(err) {
-				continue
-			}
-			return nil, fmt.Errorf("reading %s: %w", name, err)
-		}
-		if err := json.Unmarshal(data, cfg); err != nil {
-			return nil, fmt.Errorf("parsing %s: %w", name, err)
-		}
-		applyDefaults(cfg)
-		return cfg, nil
+// Load reads the config for the repo at rootPath. Settings are layered,
+// each overriding the ones before it: defaults, the user config's
+// "defaults", its "repos" entry for this repo, fr8.json (or conductor.json),
+// fr8.local.json and FR8_CONFIG_* environment variables. Objects such as
+// scripts are merged key by key. repoName is the repo's name in the
+// registry, which selects its "repos" entry; if empty, the name of the
+// rootPath directory is used.
+func Load(rootPath, repoName string) (*Config, error) {
+	layers, err := loadLayers(rootPath, repoName)
+	if err != nil {
+		return nil, err
 	}
 
-	// No config file found — use defaults
+	merged := make(map[string]any)
+	origins := make(map[string]string)
+	for _, l := range layers {
+		mergeLayer(merged, l.values, "", l.origin, origins)
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("merging config: %w", err)
+	}
+	cfg := &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	cfg.origins = origins
 	applyDefaults(cfg)
 	return cfg, nil
 }
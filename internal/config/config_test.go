@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadFr8Json(t *testing.T) {
@@ -17,7 +18,7 @@ func TestLoadFr8Json(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	cfg, err := Load(dir)
+	cfg, err := Load(dir, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -45,7 +46,7 @@ func TestLoadLegacyCamelCaseKeys(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	cfg, err := Load(dir)
+	cfg, err := Load(dir, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -131,7 +132,7 @@ func TestMigrateKeys(t *testing.T) {
 	}
 
 	// Verify the config still loads correctly
-	cfg, err := Load(dir)
+	cfg, err := Load(dir, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -171,7 +172,7 @@ func TestSnakeCaseTakesPrecedenceOverCamelCase(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	cfg, err := Load(dir)
+	cfg, err := Load(dir, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -188,7 +189,7 @@ func TestLoadFallbackToConductorJson(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	cfg, err := Load(dir)
+	cfg, err := Load(dir, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -206,7 +207,7 @@ func TestLoadFr8JsonTakesPrecedence(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	cfg, err := Load(dir)
+	cfg, err := Load(dir, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -218,7 +219,7 @@ func TestLoadFr8JsonTakesPrecedence(t *testing.T) {
 func TestLoadNoConfigFile(t *testing.T) {
 	dir := t.TempDir()
 
-	cfg, err := Load(dir)
+	cfg, err := Load(dir, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -242,7 +243,7 @@ func TestLoadInvalidJson(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err := Load(dir)
+	_, err := Load(dir, "")
 	if err == nil {
 		t.Fatal("expected error for invalid JSON")
 	}
@@ -307,3 +308,360 @@ func TestResolveWorktreePathTilde(t *testing.T) {
 		t.Errorf("ResolveWorktreePath = %q, want %q", got, want)
 	}
 }
+
+func TestLoadServices(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fr8.json"), []byte(`{
+		"services": {
+			"web": {"command": "bin/rails s", "depends_on": ["redis"]},
+			"redis": {"command": "redis-server --port $FR8_SERVICE_PORT", "port_offset": 1},
+			"worker": {"command": "bin/jobs", "env": {"QUEUE": "default"}}
+		}
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Services) != 3 {
+		t.Fatalf("len(Services) = %d, want 3", len(cfg.Services))
+	}
+	if cfg.Services["redis"].PortOffset != 1 {
+		t.Errorf("redis PortOffset = %d, want 1", cfg.Services["redis"].PortOffset)
+	}
+	if cfg.Services["worker"].Env["QUEUE"] != "default" {
+		t.Errorf("worker Env[QUEUE] = %q, want default", cfg.Services["worker"].Env["QUEUE"])
+	}
+	if !cfg.HasRun() {
+		t.Error("HasRun() = false, want true with services defined")
+	}
+}
+
+func TestServiceOrder(t *testing.T) {
+	cfg := &Config{Services: map[string]Service{
+		"web":    {Command: "web", DependsOn: []string{"redis", "db"}},
+		"worker": {Command: "worker", DependsOn: []string{"redis"}},
+		"redis":  {Command: "redis"},
+		"db":     {Command: "db"},
+	}}
+
+	order, err := cfg.ServiceOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"db", "redis", "web", "worker"}
+	if len(order) != len(want) {
+		t.Fatalf("ServiceOrder() = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("ServiceOrder() = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestServiceOrderCycle(t *testing.T) {
+	cfg := &Config{Services: map[string]Service{
+		"a": {Command: "a", DependsOn: []string{"b"}},
+		"b": {Command: "b", DependsOn: []string{"a"}},
+	}}
+	if _, err := cfg.ServiceOrder(); err == nil {
+		t.Error("expected error for dependency cycle")
+	}
+}
+
+func TestServiceOrderUnknownDependency(t *testing.T) {
+	cfg := &Config{Services: map[string]Service{
+		"web": {Command: "web", DependsOn: []string{"db"}},
+	}}
+	if _, err := cfg.ServiceOrder(); err == nil {
+		t.Error("expected error for unknown dependency")
+	}
+}
+
+func TestHasRun(t *testing.T) {
+	if (&Config{}).HasRun() {
+		t.Error("HasRun() = true for empty config")
+	}
+	if !(&Config{Scripts: Scripts{Run: "make run"}}).HasRun() {
+		t.Error("HasRun() = false with scripts.run set")
+	}
+}
+
+func TestLoadPorts(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fr8.json"), []byte(`{
+		"ports": {"web": 0, "redis": 1, "postgres": 2}
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Ports["postgres"] != 2 {
+		t.Errorf("Ports[postgres] = %d, want 2", cfg.Ports["postgres"])
+	}
+	names := cfg.PortNames()
+	if len(names) != 3 || names[0] != "postgres" || names[2] != "web" {
+		t.Errorf("PortNames() = %v, want sorted [postgres redis web]", names)
+	}
+}
+
+func TestPortEnvName(t *testing.T) {
+	tests := map[string]string{
+		"web":      "FR8_PORT_WEB",
+		"vite-dev": "FR8_PORT_VITE_DEV",
+		"db.main":  "FR8_PORT_DB_MAIN",
+		"Redis2":   "FR8_PORT_REDIS2",
+	}
+	for name, want := range tests {
+		if got := PortEnvName(name); got != want {
+			t.Errorf("PortEnvName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestValidatePorts(t *testing.T) {
+	cfg := &Config{PortRange: 10, Ports: map[string]int{"web": 0, "redis": 9}}
+	if errs := cfg.ValidatePorts(); len(errs) != 0 {
+		t.Errorf("ValidatePorts() = %v, want none", errs)
+	}
+
+	cfg = &Config{PortRange: 10, Ports: map[string]int{"web": 10, "neg": -1}}
+	if errs := cfg.ValidatePorts(); len(errs) != 2 {
+		t.Errorf("ValidatePorts() = %v, want 2 out-of-range errors", errs)
+	}
+
+	cfg = &Config{PortRange: 10, Ports: map[string]int{"web-api": 1, "web_api": 2}}
+	if errs := cfg.ValidatePorts(); len(errs) != 1 {
+		t.Errorf("ValidatePorts() = %v, want 1 conflict error", errs)
+	}
+}
+
+func TestValidateServices(t *testing.T) {
+	cfg := &Config{
+		PortRange: 10,
+		Services: map[string]Service{
+			"web":   {Command: "bin/web", DependsOn: []string{"redis"}},
+			"redis": {Command: "redis-server", PortOffset: 1},
+		},
+	}
+	if errs := cfg.ValidateServices(); len(errs) != 0 {
+		t.Errorf("ValidateServices() = %v, want none", errs)
+	}
+
+	// worker: missing command, offset out of range; web: unknown dependency
+	cfg = &Config{
+		PortRange: 10,
+		Services: map[string]Service{
+			"web":    {Command: "bin/web", DependsOn: []string{"db"}},
+			"worker": {Command: " ", PortOffset: 10},
+		},
+	}
+	if errs := cfg.ValidateServices(); len(errs) != 3 {
+		t.Errorf("ValidateServices() = %v, want 3 errors", errs)
+	}
+
+	cfg = &Config{
+		PortRange: 10,
+		Services: map[string]Service{
+			"web.api": {Command: "bin/api", DependsOn: []string{"web.api"}},
+		},
+	}
+	if errs := cfg.ValidateServices(); len(errs) != 2 {
+		t.Errorf("ValidateServices() = %v, want invalid name and cycle errors", errs)
+	}
+}
+
+func TestRestartPolicy(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fr8.json"), []byte(`{
+		"restart": "on-failure",
+		"services": {
+			"web": {"command": "bin/rails s"},
+			"worker": {"command": "bin/jobs", "restart": "always"}
+		}
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.RestartPolicy("web"); got != RestartOnFailure {
+		t.Errorf("RestartPolicy(web) = %q, want top-level %q", got, RestartOnFailure)
+	}
+	if got := cfg.RestartPolicy("worker"); got != RestartAlways {
+		t.Errorf("RestartPolicy(worker) = %q, want %q", got, RestartAlways)
+	}
+	if got := (&Config{}).RestartPolicy(""); got != RestartNever {
+		t.Errorf("default RestartPolicy = %q, want %q", got, RestartNever)
+	}
+}
+
+func TestValidateRestart(t *testing.T) {
+	cfg := &Config{Restart: "always", Services: map[string]Service{"web": {Restart: "never"}}}
+	if errs := cfg.ValidateRestart(); len(errs) != 0 {
+		t.Errorf("ValidateRestart() = %v, want none", errs)
+	}
+
+	cfg = &Config{Restart: "sometimes", Services: map[string]Service{"web": {Restart: "on-crash"}}}
+	if errs := cfg.ValidateRestart(); len(errs) != 2 {
+		t.Errorf("ValidateRestart() = %v, want 2 errors", errs)
+	}
+}
+
+func TestLoadHealthcheck(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fr8.json"), []byte(`{
+		"healthcheck": {"path": "/up", "port_offset": 1, "interval": "250ms"}
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := cfg.Healthcheck
+	if h == nil {
+		t.Fatal("Healthcheck = nil, want parsed block")
+	}
+	if h.Path != "/up" || h.PortOffset != 1 {
+		t.Errorf("Healthcheck = %+v, want path /up and port_offset 1", h)
+	}
+	if got := h.IntervalDuration(); got != 250*time.Millisecond {
+		t.Errorf("IntervalDuration() = %v, want 250ms", got)
+	}
+	if got := h.TimeoutDuration(); got != DefaultHealthTimeout {
+		t.Errorf("TimeoutDuration() = %v, want default %v", got, DefaultHealthTimeout)
+	}
+	if got := h.StartPeriodDuration(); got != DefaultHealthStartPeriod {
+		t.Errorf("StartPeriodDuration() = %v, want default %v", got, DefaultHealthStartPeriod)
+	}
+}
+
+func TestValidateHealthcheck(t *testing.T) {
+	cfg := &Config{PortRange: 10, Healthcheck: &Healthcheck{Path: "/up", Status: 204, Timeout: "5s"}}
+	if errs := cfg.ValidateHealthcheck(); len(errs) != 0 {
+		t.Errorf("ValidateHealthcheck() = %v, want none", errs)
+	}
+
+	cfg = &Config{PortRange: 10, Healthcheck: &Healthcheck{Path: "up", PortOffset: 10, Status: 42, Interval: "soon"}}
+	if errs := cfg.ValidateHealthcheck(); len(errs) != 4 {
+		t.Errorf("ValidateHealthcheck() = %v, want 4 errors", errs)
+	}
+
+	cfg = &Config{PortRange: 10, Healthcheck: &Healthcheck{Status: 200}}
+	if errs := cfg.ValidateHealthcheck(); len(errs) != 1 {
+		t.Errorf("ValidateHealthcheck() = %v, want status-without-path error", errs)
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := &Config{Scripts: Scripts{Setup: "bin/setup"}, PortRange: 10}
+	b := &Config{Scripts: Scripts{Setup: "bin/setup"}, PortRange: 10}
+	if a.Hash() == "" || a.Hash() != b.Hash() {
+		t.Errorf("Hash() = %q and %q, want equal non-empty hashes", a.Hash(), b.Hash())
+	}
+
+	b.Scripts.Setup = "bin/setup --fast"
+	if a.Hash() == b.Hash() {
+		t.Errorf("Hash() = %q for different configs, want different hashes", a.Hash())
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"36h", 36 * time.Hour, false},
+		{"14d", 14 * 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"1.5d", 0, true},
+		{"d", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v, error %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestTTL(t *testing.T) {
+	cfg := &Config{TTL: "7d"}
+	if got := cfg.TTLDuration(); got != 7*24*time.Hour {
+		t.Errorf("TTLDuration() = %v, want 168h", got)
+	}
+	if errs := cfg.ValidateTTL(); len(errs) != 0 {
+		t.Errorf("ValidateTTL() = %v, want none", errs)
+	}
+
+	for _, ttl := range []string{"forever", "0d", "-1h"} {
+		cfg := &Config{TTL: ttl}
+		if got := cfg.TTLDuration(); got != 0 {
+			t.Errorf("TTLDuration() for %q = %v, want 0", ttl, got)
+		}
+		if errs := cfg.ValidateTTL(); len(errs) != 1 {
+			t.Errorf("ValidateTTL() for %q = %v, want 1 error", ttl, errs)
+		}
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	cfg := &Config{Env: map[string]string{
+		"DATABASE_URL": "postgres://localhost/{{snake .Name}}_dev",
+		"REDIS_URL":    "redis://localhost:{{.Ports.redis}}",
+		"VITE_PORT":    "{{add .Port 2}}",
+		"PLAIN":        "value",
+		"BROKEN":       "{{.Ports.missing}}",
+	}}
+	data := EnvData{Name: "fix-login", Port: 60010, Ports: map[string]int{"redis": 60011}}
+
+	vars, err := cfg.ExpandEnv(data)
+	if err == nil {
+		t.Error("expected an error for env.BROKEN")
+	}
+	want := [][2]string{
+		{"DATABASE_URL", "postgres://localhost/fix_login_dev"},
+		{"PLAIN", "value"},
+		{"REDIS_URL", "redis://localhost:60011"},
+		{"VITE_PORT", "60012"},
+	}
+	if len(vars) != len(want) {
+		t.Fatalf("ExpandEnv() = %v, want %v", vars, want)
+	}
+	for i := range want {
+		if vars[i] != want[i] {
+			t.Errorf("vars[%d] = %v, want %v", i, vars[i], want[i])
+		}
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	cfg := &Config{
+		BasePort: 60000,
+		Ports:    map[string]int{"redis": 1},
+		Env: map[string]string{
+			"REDIS_URL":  "redis://localhost:{{.Ports.redis}}",
+			"FR8_PORT":   "1",
+			"1BAD":       "x",
+			"UNKNOWN":    "{{.Branch}}",
+			"UNCLOSED":   "{{.Name",
+			"LOWER_case": "ok",
+		},
+	}
+	// FR8_PORT reserved, 1BAD invalid name, UNKNOWN no such field, UNCLOSED parse error
+	if errs := cfg.ValidateEnv(); len(errs) != 4 {
+		t.Errorf("ValidateEnv() = %v, want 4 errors", errs)
+	}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/protocollar/fr8/internal/userconfig"
+)
+
+// LocalFileName is the uncommitted config layered over fr8.json, for
+// settings that only apply to one checkout.
+const LocalFileName = "fr8.local.json"
+
+// OriginDefault is the origin of values no config layer sets.
+const OriginDefault = "default"
+
+// envKeys are the top-level settings that can be overridden with an
+// environment variable (see EnvName).
+var envKeys = []string{"base_port", "port_range", "worktree_path", "ttl", "restart"}
+
+// EnvName returns the environment variable overriding a top-level setting,
+// e.g. "base_port" -> "FR8_CONFIG_BASE_PORT".
+func EnvName(key string) string {
+	return "FR8_CONFIG_" + strings.ToUpper(key)
+}
+
+// layer is one source of config values. Values are decoded JSON with
+// legacy camelCase keys renamed.
+type layer struct {
+	origin string
+	values map[string]any
+}
+
+// loadLayers returns the config layers for the repo at rootPath, lowest
+// precedence first: the user config's defaults, its entry for this repo,
+// fr8.json (or conductor.json), fr8.local.json and environment variables.
+// See Load for repoName.
+func loadLayers(rootPath, repoName string) ([]layer, error) {
+	var layers []layer
+
+	if path, err := userconfig.DefaultPath(); err == nil {
+		origin := shortenHome(path)
+		uc, err := userconfig.Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", origin, err)
+		}
+		if len(uc.Defaults) > 0 {
+			l, err := parseLayer(origin, uc.Defaults)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s defaults: %w", origin, err)
+			}
+			layers = append(layers, l)
+		}
+		if len(uc.Repos) > 0 {
+			name := repoName
+			if name == "" {
+				name = filepath.Base(rootPath)
+			}
+			if data, ok := uc.Repos[name]; ok {
+				key := "repos." + name
+				l, err := parseLayer(origin+" "+key, data)
+				if err != nil {
+					return nil, fmt.Errorf("parsing %s %s: %w", origin, key, err)
+				}
+				layers = append(layers, l)
+			}
+		}
+	}
+
+	for _, names := range [][]string{FileNames, {LocalFileName}} {
+		for _, name := range names {
+			data, err := os.ReadFile(filepath.Join(rootPath, name))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, fmt.Errorf("reading %s: %w", name, err)
+			}
+			l, err := parseLayer(name, data)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", name, err)
+			}
+			layers = append(layers, l)
+			break
+		}
+	}
+
+	for _, key := range envKeys {
+		v, ok := os.LookupEnv(EnvName(key))
+		if !ok || v == "" {
+			continue
+		}
+		var value any = v
+		if key == "base_port" || key == "port_range" {
+			n, err := json.Number(v).Int64()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %q is not a number", EnvName(key), v)
+			}
+			value = json.Number(fmt.Sprint(n))
+		}
+		layers = append(layers, layer{origin: "env " + EnvName(key), values: map[string]any{key: value}})
+	}
+	return layers, nil
+}
+
+// parseLayer decodes a config layer, checking that it is a valid config on
+// its own so errors point at the file they come from.
+func parseLayer(origin string, data []byte) (layer, error) {
+	if err := json.Unmarshal(data, &Config{}); err != nil {
+		return layer{}, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var values map[string]any
+	if err := dec.Decode(&values); err != nil {
+		return layer{}, err
+	}
+	if values == nil {
+		return layer{}, fmt.Errorf("expected a JSON object")
+	}
+	for old, new := range legacyKeys {
+		if v, ok := values[old]; ok {
+			if _, exists := values[new]; !exists {
+				values[new] = v
+			}
+			delete(values, old)
+		}
+	}
+	return layer{origin: origin, values: values}, nil
+}
+
+// mergeLayer merges src into dst, recording in origins which layer set each
+// value. Objects are merged key by key; anything else, including an empty
+// object, replaces the value underneath and the origins recorded below it.
+func mergeLayer(dst, src map[string]any, prefix, origin string, origins map[string]string) {
+	for key, v := range src {
+		path := prefix + key
+		srcObj, srcIsObj := v.(map[string]any)
+		dstObj, dstIsObj := dst[key].(map[string]any)
+		if srcIsObj && dstIsObj && len(srcObj) > 0 {
+			mergeLayer(dstObj, srcObj, path+".", origin, origins)
+			continue
+		}
+		for p := range origins {
+			if strings.HasPrefix(p, path+".") {
+				delete(origins, p)
+			}
+		}
+		delete(origins, path)
+		if srcIsObj && len(srcObj) > 0 {
+			// Copy so later layers merging into it don't modify src
+			obj := make(map[string]any, len(srcObj))
+			mergeLayer(obj, srcObj, path+".", origin, origins)
+			dst[key] = obj
+			continue
+		}
+		dst[key] = v
+		origins[path] = origin
+	}
+}
+
+// Origin returns which config layer set a value, given as a dotted key such
+// as "base_port" or "scripts.setup": a file name, the user config, an
+// environment variable or OriginDefault.
+func (c *Config) Origin(key string) string {
+	for k := key; k != ""; {
+		if o, ok := c.origins[k]; ok {
+			return o
+		}
+		i := strings.LastIndex(k, ".")
+		if i < 0 {
+			break
+		}
+		k = k[:i]
+	}
+	return OriginDefault
+}
+
+// shortenHome replaces the home directory prefix of p with ~.
+func shortenHome(p string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, p); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return p
+}
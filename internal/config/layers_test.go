@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLayers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "fr8")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FR8_CONFIG_DIR", configDir)
+	t.Setenv(EnvName("ttl"), "3d")
+
+	root := filepath.Join(t.TempDir(), "api")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(configDir, "config.json"): `{
+			"openers": [{"name": "vscode", "command": "code"}],
+			"defaults": {"worktree_path": "~/worktrees", "base_port": 61000, "scripts": {"archive": "bin/cleanup"}},
+			"repos": {"api": {"base_port": 62000}, "web": {"base_port": 63000}}
+		}`,
+		filepath.Join(root, "fr8.json"):    `{"scripts": {"setup": "bin/setup", "run": "bin/dev"}, "portRange": 20}`,
+		filepath.Join(root, LocalFileName): `{"scripts": {"run": "bin/dev --debug"}}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Load(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userOrigin := filepath.Join("~", ".config", "fr8", "config.json")
+	tests := []struct {
+		key, got, want, origin string
+	}{
+		{"worktree_path", cfg.WorktreePath, "~/worktrees", userOrigin},
+		{"base_port", fmt.Sprint(cfg.BasePort), "62000", userOrigin + " repos.api"},
+		{"port_range", fmt.Sprint(cfg.PortRange), "20", "fr8.json"},
+		{"scripts.setup", cfg.Scripts.Setup, "bin/setup", "fr8.json"},
+		{"scripts.run", cfg.Scripts.Run, "bin/dev --debug", LocalFileName},
+		{"scripts.archive", cfg.Scripts.Archive, "bin/cleanup", userOrigin},
+		{"ttl", cfg.TTL, "3d", "env FR8_CONFIG_TTL"},
+		{"restart", cfg.Restart, "", OriginDefault},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.key, tt.got, tt.want)
+		}
+		if origin := cfg.Origin(tt.key); origin != tt.origin {
+			t.Errorf("Origin(%q) = %q, want %q", tt.key, origin, tt.origin)
+		}
+	}
+
+	// A repo registered under another name gets that name's entry
+	cfg, err = Load(root, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BasePort != 63000 {
+		t.Errorf("base_port for repo web = %d, want 63000", cfg.BasePort)
+	}
+}
+
+func TestLoadLayersInvalidEnv(t *testing.T) {
+	t.Setenv("FR8_CONFIG_DIR", t.TempDir())
+	t.Setenv(EnvName("base_port"), "lots")
+
+	if _, err := Load(t.TempDir(), ""); err == nil {
+		t.Fatal("expected error for non-numeric FR8_CONFIG_BASE_PORT")
+	}
+}
+
+func TestMergeLayerReplacesObjects(t *testing.T) {
+	merged := map[string]any{}
+	origins := map[string]string{}
+	mergeLayer(merged, map[string]any{"services": map[string]any{"web": map[string]any{"command": "a"}}}, "", "fr8.json", origins)
+	mergeLayer(merged, map[string]any{"services": map[string]any{}}, "", "fr8.local.json", origins)
+
+	if services := merged["services"].(map[string]any); len(services) != 0 {
+		t.Errorf("services = %v, want empty (replaced by an empty object)", services)
+	}
+	if _, ok := origins["services.web.command"]; ok {
+		t.Errorf("origins = %v, want services.web.command removed", origins)
+	}
+	if origins["services"] != "fr8.local.json" {
+		t.Errorf("origins[services] = %q, want fr8.local.json", origins["services"])
+	}
+}
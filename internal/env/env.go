@@ -3,14 +3,21 @@ package env
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/protocollar/fr8/internal/config"
 	"github.com/protocollar/fr8/internal/registry"
 )
 
 // Build returns a complete environment variable slice for running scripts
 // in the given workspace. Includes both FR8_* and CONDUCTOR_* (compat) vars,
-// merged with the current process environment.
-func Build(ws *registry.Workspace, rootPath, defaultBranch string) []string {
+// merged with the current process environment. cfg may be nil; when set,
+// named ports are exported as FR8_PORT_<NAME> and the variables in its env
+// section are expanded and added. The error reports the env variables whose
+// template failed to expand; they are left out of the otherwise complete
+// result.
+func Build(ws *registry.Workspace, rootPath, defaultBranch string, cfg *config.Config) ([]string, error) {
 	fr8Vars := map[string]string{
 		"FR8_WORKSPACE_NAME": ws.Name,
 		"FR8_WORKSPACE_PATH": ws.Path,
@@ -24,8 +31,12 @@ func Build(ws *registry.Workspace, rootPath, defaultBranch string) []string {
 		"CONDUCTOR_DEFAULT_BRANCH": defaultBranch,
 		"CONDUCTOR_PORT":           fmt.Sprintf("%d", ws.Port),
 	}
+	for _, kv := range portVars(ws, cfg) {
+		fr8Vars[kv[0]] = kv[1]
+	}
 
-	// Start with current env, then override with fr8 vars.
+	// Start with current env, then override with the env section of
+	// fr8.json and then fr8 vars.
 	envMap := make(map[string]string)
 	for _, e := range os.Environ() {
 		for i := range e {
@@ -35,6 +46,10 @@ func Build(ws *registry.Workspace, rootPath, defaultBranch string) []string {
 			}
 		}
 	}
+	cfgVars, err := configVars(ws, rootPath, defaultBranch, cfg)
+	for _, kv := range cfgVars {
+		envMap[kv[0]] = kv[1]
+	}
 	for k, v := range fr8Vars {
 		envMap[k] = v
 	}
@@ -43,14 +58,16 @@ func Build(ws *registry.Workspace, rootPath, defaultBranch string) []string {
 	for k, v := range envMap {
 		result = append(result, k+"="+v)
 	}
-	return result
+	return result, err
 }
 
-// BuildFr8Only returns only the FR8_* and CONDUCTOR_* environment variables
+// BuildFr8Only returns only the workspace's own environment variables: the
+// FR8_* and CONDUCTOR_* variables and those from the env section of cfg
 // (not merged with the current process env). Used for tmux sessions where
-// the user's shell environment is inherited automatically.
-func BuildFr8Only(ws *registry.Workspace, rootPath, defaultBranch string) []string {
-	return []string{
+// the user's shell environment is inherited automatically. cfg may be nil.
+// Errors are reported as by Build.
+func BuildFr8Only(ws *registry.Workspace, rootPath, defaultBranch string, cfg *config.Config) ([]string, error) {
+	vars := []string{
 		"FR8_WORKSPACE_NAME=" + ws.Name,
 		"FR8_WORKSPACE_PATH=" + ws.Path,
 		"FR8_ROOT_PATH=" + rootPath,
@@ -62,4 +79,67 @@ func BuildFr8Only(ws *registry.Workspace, rootPath, defaultBranch string) []stri
 		"CONDUCTOR_DEFAULT_BRANCH=" + defaultBranch,
 		fmt.Sprintf("CONDUCTOR_PORT=%d", ws.Port),
 	}
+	for _, kv := range portVars(ws, cfg) {
+		vars = append(vars, kv[0]+"="+kv[1])
+	}
+	cfgVars, err := configVars(ws, rootPath, defaultBranch, cfg)
+	for _, kv := range cfgVars {
+		vars = append(vars, kv[0]+"="+kv[1])
+	}
+	return vars, err
+}
+
+// Map converts vars to a map, leaving out the CONDUCTOR_* compatibility
+// variables.
+func Map(vars []string) map[string]string {
+	m := make(map[string]string)
+	for _, v := range vars {
+		k, value, ok := strings.Cut(v, "=")
+		if ok && !strings.HasPrefix(k, "CONDUCTOR_") {
+			m[k] = value
+		}
+	}
+	return m
+}
+
+// configVars expands the env section of cfg for the workspace, sorted by
+// name. Variables whose template fails to expand are left out and reported
+// in the error. FR8_* and CONDUCTOR_* names can't be overridden.
+func configVars(ws *registry.Workspace, rootPath, defaultBranch string, cfg *config.Config) ([][2]string, error) {
+	if cfg == nil || len(cfg.Env) == 0 {
+		return nil, nil
+	}
+	ports := make(map[string]int, len(cfg.Ports))
+	for name, offset := range cfg.Ports {
+		ports[name] = ws.Port + offset
+	}
+	expanded, err := cfg.ExpandEnv(config.EnvData{
+		Name:          ws.Name,
+		Path:          ws.Path,
+		RootPath:      rootPath,
+		Repo:          filepath.Base(rootPath),
+		DefaultBranch: defaultBranch,
+		Port:          ws.Port,
+		Ports:         ports,
+	})
+	vars := expanded[:0]
+	for _, kv := range expanded {
+		if !strings.HasPrefix(kv[0], "FR8_") && !strings.HasPrefix(kv[0], "CONDUCTOR_") {
+			vars = append(vars, kv)
+		}
+	}
+	return vars, err
+}
+
+// portVars returns the FR8_PORT_<NAME> pairs for the config's named ports,
+// sorted by port name.
+func portVars(ws *registry.Workspace, cfg *config.Config) [][2]string {
+	if cfg == nil {
+		return nil
+	}
+	var vars [][2]string
+	for _, name := range cfg.PortNames() {
+		vars = append(vars, [2]string{config.PortEnvName(name), fmt.Sprintf("%d", ws.Port+cfg.Ports[name])})
+	}
+	return vars
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/protocollar/fr8/internal/config"
 	"github.com/protocollar/fr8/internal/registry"
 )
 
@@ -15,7 +16,10 @@ func TestBuildContainsAllVars(t *testing.T) {
 		Port: 5000,
 	}
 
-	result := Build(ws, "/Users/me/project", "main")
+	result, err := Build(ws, "/Users/me/project", "main", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	expected := map[string]string{
 		"FR8_WORKSPACE_NAME":       "test-ws",
@@ -46,7 +50,10 @@ func TestBuildContainsAllVars(t *testing.T) {
 
 func TestBuildPreservesExistingEnv(t *testing.T) {
 	ws := &registry.Workspace{Name: "ws", Path: "/tmp/ws", Port: 5000, CreatedAt: time.Now()}
-	result := Build(ws, "/root", "main")
+	result, err := Build(ws, "/root", "main", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	envMap := toMap(result)
 
@@ -58,7 +65,10 @@ func TestBuildPreservesExistingEnv(t *testing.T) {
 
 func TestBuildFr8OverridesConductor(t *testing.T) {
 	ws := &registry.Workspace{Name: "ws", Path: "/tmp/ws", Port: 5000}
-	result := Build(ws, "/root", "main")
+	result, err := Build(ws, "/root", "main", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	envMap := toMap(result)
 
@@ -75,7 +85,10 @@ func TestBuildFr8OnlyContainsOnlyFr8Vars(t *testing.T) {
 		Port: 5000,
 	}
 
-	result := BuildFr8Only(ws, "/Users/me/project", "main")
+	result, err := BuildFr8Only(ws, "/Users/me/project", "main", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Should have exactly 10 vars (5 FR8 + 5 CONDUCTOR)
 	if len(result) != 10 {
@@ -111,7 +124,10 @@ func TestBuildFr8OnlyContainsOnlyFr8Vars(t *testing.T) {
 
 func TestBuildFr8OnlyExcludesProcessEnv(t *testing.T) {
 	ws := &registry.Workspace{Name: "ws", Path: "/tmp/ws", Port: 5000}
-	result := BuildFr8Only(ws, "/root", "main")
+	result, err := BuildFr8Only(ws, "/root", "main", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	envMap := toMap(result)
 
@@ -124,6 +140,31 @@ func TestBuildFr8OnlyExcludesProcessEnv(t *testing.T) {
 	}
 }
 
+func TestBuildExportsNamedPorts(t *testing.T) {
+	ws := &registry.Workspace{Name: "ws", Path: "/tmp/ws", Port: 5000}
+	cfg := &config.Config{PortRange: 10, Ports: map[string]int{"web": 0, "redis": 1, "vite-dev": 3}}
+
+	for name, build := range map[string]func(*registry.Workspace, string, string, *config.Config) ([]string, error){
+		"Build":        Build,
+		"BuildFr8Only": BuildFr8Only,
+	} {
+		result, err := build(ws, "/root", "main", cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		envMap := toMap(result)
+		for k, want := range map[string]string{
+			"FR8_PORT_WEB":      "5000",
+			"FR8_PORT_REDIS":    "5001",
+			"FR8_PORT_VITE_DEV": "5003",
+		} {
+			if got := envMap[k]; got != want {
+				t.Errorf("%s: %s = %q, want %q", name, k, got, want)
+			}
+		}
+	}
+}
+
 func toMap(environ []string) map[string]string {
 	m := make(map[string]string)
 	for _, e := range environ {
@@ -133,3 +174,63 @@ func toMap(environ []string) map[string]string {
 	}
 	return m
 }
+
+func TestBuildFr8OnlyIncludesConfigEnv(t *testing.T) {
+	ws := &registry.Workspace{Name: "fix-login", Path: "/tmp/ws", Port: 60010}
+	cfg := &config.Config{
+		Ports: map[string]int{"redis": 1},
+		Env: map[string]string{
+			"REDIS_URL": "redis://localhost:{{.Ports.redis}}",
+			"REPO":      "{{.Repo}}",
+			"FR8_PORT":  "1", // reserved, ignored
+		},
+	}
+
+	vars, err := BuildFr8Only(ws, "/code/app", "main", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envMap := toMap(vars)
+	if got := envMap["REDIS_URL"]; got != "redis://localhost:60011" {
+		t.Errorf("REDIS_URL = %q, want redis://localhost:60011", got)
+	}
+	if got := envMap["REPO"]; got != "app" {
+		t.Errorf("REPO = %q, want app", got)
+	}
+	if got := envMap["FR8_PORT"]; got != "60010" {
+		t.Errorf("FR8_PORT = %q, want 60010 (env can't override fr8 variables)", got)
+	}
+
+	m := Map(vars)
+	if _, ok := m["CONDUCTOR_PORT"]; ok {
+		t.Error("Map() should leave out CONDUCTOR_* variables")
+	}
+	if m["REDIS_URL"] == "" || m["FR8_PORT"] == "" {
+		t.Errorf("Map() = %v, want FR8_* and env variables", m)
+	}
+}
+
+func TestBuildReportsEnvThatFailsToExpand(t *testing.T) {
+	ws := &registry.Workspace{Name: "ws", Path: "/tmp/ws", Port: 5000}
+	cfg := &config.Config{Env: map[string]string{
+		"GOOD": "{{.Name}}",
+		"BAD":  "{{.Ports.missing}}",
+	}}
+
+	for name, build := range map[string]func(*registry.Workspace, string, string, *config.Config) ([]string, error){
+		"Build":        Build,
+		"BuildFr8Only": BuildFr8Only,
+	} {
+		result, err := build(ws, "/root", "main", cfg)
+		if err == nil || !strings.Contains(err.Error(), "env.BAD") {
+			t.Errorf("%s: err = %v, want it to name env.BAD", name, err)
+		}
+		envMap := toMap(result)
+		if _, ok := envMap["BAD"]; ok {
+			t.Errorf("%s: expected BAD to be left out", name)
+		}
+		if envMap["GOOD"] != "ws" || envMap["FR8_PORT"] != "5000" {
+			t.Errorf("%s: expected the other variables to be set, got %v", name, envMap)
+		}
+	}
+}
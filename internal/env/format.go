@@ -0,0 +1,116 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Formats lists the output formats supported by Format.
+var Formats = []string{"sh", "fish", "nu", "dotenv", "json", "docker"}
+
+// Format renders vars (KEY=value pairs, as returned by BuildFr8Only) in the
+// given format:
+//
+//   - sh: export statements for bash and zsh
+//   - fish: set -gx statements
+//   - nu: $env assignments for Nushell
+//   - dotenv: a .env file, quoting values where needed
+//   - json: an object without the CONDUCTOR_* compat vars (see Map)
+//   - docker: an env file for docker run --env-file, which takes values
+//     literally and can't hold newlines
+func Format(vars []string, format string) (string, error) {
+	if format == "json" {
+		data, err := json.Marshal(Map(vars))
+		if err != nil {
+			return "", fmt.Errorf("marshaling JSON: %w", err)
+		}
+		return string(data) + "\n", nil
+	}
+
+	var line func(k, v string) (string, error)
+	switch format {
+	case "sh":
+		line = func(k, v string) (string, error) { return "export " + k + "=" + ShellQuote(v), nil }
+	case "fish":
+		line = func(k, v string) (string, error) { return "set -gx " + k + " " + FishQuote(v), nil }
+	case "nu":
+		line = func(k, v string) (string, error) { return "$env." + k + " = " + nuQuote(v), nil }
+	case "dotenv":
+		line = func(k, v string) (string, error) { return k + "=" + dotenvQuote(v), nil }
+	case "docker":
+		line = func(k, v string) (string, error) {
+			if strings.ContainsAny(v, "\r\n") {
+				return "", fmt.Errorf("%s contains a newline, which docker env files can't hold", k)
+			}
+			return k + "=" + v, nil
+		}
+	default:
+		return "", fmt.Errorf("unknown format %q (want one of: %s)", format, strings.Join(Formats, ", "))
+	}
+
+	var b strings.Builder
+	for _, kv := range vars {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		l, err := line(k, v)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// ShellQuote single-quotes s for POSIX shells such as bash and zsh.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// FishQuote single-quotes s for fish, where \ and ' are escaped with \.
+func FishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// nuQuote double-quotes s for Nushell.
+func nuQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u{%x}`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// dotenvQuote quotes s for a .env file. Plain values are left bare; others
+// are single-quoted, which dotenv parsers read literally, or double-quoted
+// with escapes when they contain a single quote or a newline.
+func dotenvQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-.,/:@%+") == "" {
+		return s
+	}
+	if !strings.ContainsAny(s, "'\r\n") {
+		return "'" + s + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`).Replace(s) + `"`
+}
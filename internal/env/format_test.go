@@ -0,0 +1,65 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	vars := []string{
+		"FR8_WORKSPACE_NAME=ws",
+		"CONDUCTOR_WORKSPACE_NAME=ws",
+		"GREETING=it's $HOME",
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"sh", "export FR8_WORKSPACE_NAME='ws'\nexport CONDUCTOR_WORKSPACE_NAME='ws'\nexport GREETING='it'\"'\"'s $HOME'\n"},
+		{"fish", "set -gx FR8_WORKSPACE_NAME 'ws'\nset -gx CONDUCTOR_WORKSPACE_NAME 'ws'\nset -gx GREETING 'it\\'s $HOME'\n"},
+		{"nu", "$env.FR8_WORKSPACE_NAME = \"ws\"\n$env.CONDUCTOR_WORKSPACE_NAME = \"ws\"\n$env.GREETING = \"it's $HOME\"\n"},
+		{"dotenv", "FR8_WORKSPACE_NAME=ws\nCONDUCTOR_WORKSPACE_NAME=ws\nGREETING=\"it's \\$HOME\"\n"},
+		{"docker", "FR8_WORKSPACE_NAME=ws\nCONDUCTOR_WORKSPACE_NAME=ws\nGREETING=it's $HOME\n"},
+		{"json", `{"FR8_WORKSPACE_NAME":"ws","GREETING":"it's $HOME"}` + "\n"},
+	}
+	for _, tt := range tests {
+		got, err := Format(vars, tt.format)
+		if err != nil {
+			t.Errorf("Format(%s) error: %v", tt.format, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Format(%s) =\n%s\nwant:\n%s", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestFormatUnknown(t *testing.T) {
+	_, err := Format(nil, "yaml")
+	if err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("expected unknown format error, got %v", err)
+	}
+}
+
+func TestFormatDockerRejectsNewlines(t *testing.T) {
+	if _, err := Format([]string{"MOTD=a\nb"}, "docker"); err == nil {
+		t.Error("expected error for a value with a newline")
+	}
+}
+
+func TestDotenvQuote(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain-value_1.2", "plain-value_1.2"},
+		{"", "''"},
+		{"two words", "'two words'"},
+		{"$HOME", "'$HOME'"},
+		{"a\nb", `"a\nb"`},
+		{`say "hi" it's`, `"say \"hi\" it's"`},
+	}
+	for _, tt := range tests {
+		if got := dotenvQuote(tt.in); got != tt.want {
+			t.Errorf("dotenvQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
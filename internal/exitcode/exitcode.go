@@ -12,6 +12,7 @@ const (
 	InteractiveOnly = 6
 	TmuxUnavailable = 7
 	ConfigError     = 8
+	Unhealthy       = 9
 )
 
 // ExitError wraps an error with a semantic exit code and machine-readable code string.
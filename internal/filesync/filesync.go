@@ -3,70 +3,335 @@ package filesync
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
 )
 
-// Sync copies files matching .worktreeinclude patterns from rootPath to worktreePath.
-// Files that already exist with identical content are skipped.
-func Sync(rootPath, worktreePath string) error {
-	// Look for .worktreeinclude in worktree first, then root
-	var includeFile string
-	for _, base := range []string{worktreePath, rootPath} {
-		p := filepath.Join(base, ".worktreeinclude")
+// Mode is how a matched file is brought into the worktree.
+type Mode string
+
+const (
+	ModeCopy    Mode = "copy"    // byte-for-byte copy (the default)
+	ModeReflink Mode = "reflink" // copy-on-write clone, falling back to a copy
+	ModeSymlink Mode = "symlink" // symlink to the file in the root worktree
+)
+
+// Rule is a parsed .worktreeinclude line. Lines follow .gitignore syntax,
+// except that patterns are always relative to the repo root: "!pattern"
+// excludes files an earlier rule included, "pattern/" only matches
+// directories, and a matched directory brings its whole tree. Include rules
+// may start with a mode, e.g. "symlink:config/master.key".
+type Rule struct {
+	Mode    Mode
+	Pattern string
+	Negate  bool
+	Dir     bool
+}
+
+// ParseRule parses a .worktreeinclude line. Lines without a known mode
+// prefix are copied.
+func ParseRule(line string) Rule {
+	r := Rule{Mode: ModeCopy}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.Negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\`):
+		line = line[1:] // escaped leading "!" or "#"
+	default:
+		if mode, pattern, ok := strings.Cut(line, ":"); ok {
+			switch m := Mode(mode); m {
+			case ModeCopy, ModeReflink, ModeSymlink:
+				r.Mode = m
+				line = pattern
+			}
+		}
+	}
+	if strings.HasSuffix(line, "/") {
+		r.Dir = true
+		line = strings.TrimRight(line, "/")
+	}
+	r.Pattern = strings.TrimPrefix(line, "/")
+	return r
+}
+
+// String returns the rule in .worktreeinclude syntax.
+func (r Rule) String() string {
+	s := r.Pattern
+	if r.Dir {
+		s += "/"
+	}
+	switch {
+	case r.Negate:
+		s = "!" + s
+	case r.Mode != ModeCopy:
+		s = string(r.Mode) + ":" + s
+	}
+	return s
+}
+
+// Valid reports whether the rule's pattern is a valid glob.
+func (r Rule) Valid() bool {
+	return r.Pattern != "" && doublestar.ValidatePattern(r.Pattern)
+}
+
+// matches reports whether the rule matches rel (slash-separated, relative
+// to the repo root) or one of its parent directories.
+func (r Rule) matches(rel string, isDir bool) bool {
+	for p := rel; p != "." && p != "/"; p, isDir = path.Dir(p), true {
+		if (isDir || !r.Dir) && matchPattern(r.Pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(pattern, name string) bool {
+	ok, err := doublestar.Match(pattern, name)
+	return err == nil && ok
+}
+
+// File is a file selected for syncing by a .worktreeinclude.
+type File struct {
+	Path string // slash-separated, relative to the repo root
+	Mode Mode
+}
+
+// FindIncludeFile returns the first .worktreeinclude found in dirs, or ""
+// if there is none.
+func FindIncludeFile(dirs ...string) string {
+	for _, dir := range dirs {
+		p := filepath.Join(dir, ".worktreeinclude")
 		if _, err := os.Stat(p); err == nil {
-			includeFile = p
-			break
+			return p
+		}
+	}
+	return ""
+}
+
+// ParseFile reads the rules from a .worktreeinclude, skipping blank lines
+// and comments.
+func ParseFile(path string) ([]Rule, error) {
+	lines, err := parseIncludeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]Rule, len(lines))
+	for i, line := range lines {
+		rules[i] = ParseRule(line)
+	}
+	return rules, nil
+}
+
+// Match returns the files under rootPath selected by rules. As in
+// .gitignore, the last rule matching a file (or one of its directories)
+// decides whether it is included, and with which mode.
+func Match(rootPath string, rules []Rule) ([]File, error) {
+	fsys := os.DirFS(rootPath)
+	var files []File
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.Negate {
+			continue
+		}
+		matches, err := doublestar.Glob(fsys, rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", rule, err)
+		}
+		for _, m := range matches {
+			err := fs.WalkDir(fsys, m, func(rel string, d fs.DirEntry, err error) error {
+				if err != nil {
+					if d != nil && d.IsDir() {
+						return fs.SkipDir // unreadable directory
+					}
+					return nil
+				}
+				if d.IsDir() {
+					return nil
+				}
+				if rel == m && rule.Dir {
+					return nil
+				}
+				if info, err := fs.Stat(fsys, rel); err != nil || !info.Mode().IsRegular() {
+					return nil
+				}
+				if seen[rel] {
+					return nil
+				}
+				seen[rel] = true
+				if last, ok := lastMatch(rules, rel); ok {
+					files = append(files, File{Path: rel, Mode: last.Mode})
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
+	return files, nil
+}
 
+// lastMatch returns the last rule matching rel, and false if there is none
+// or it is a negation.
+func lastMatch(rules []Rule, rel string) (Rule, bool) {
+	var last *Rule
+	for i := range rules {
+		if rules[i].matches(rel, false) {
+			last = &rules[i]
+		}
+	}
+	if last == nil || last.Negate {
+		return Rule{}, false
+	}
+	return *last, true
+}
+
+// Unmatched returns the valid rules whose pattern matches nothing under
+// rootPath, which usually means a typo or a file that was renamed.
+func Unmatched(rootPath string, rules []Rule) []Rule {
+	fsys := os.DirFS(rootPath)
+	var unmatched []Rule
+	for _, rule := range rules {
+		if !rule.Valid() {
+			continue
+		}
+		matches, _ := doublestar.Glob(fsys, rule.Pattern)
+		found := false
+		for _, m := range matches {
+			if info, err := fs.Stat(fsys, m); err == nil && (info.IsDir() || !rule.Dir) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, rule)
+		}
+	}
+	return unmatched
+}
+
+// Options changes what Sync does.
+type Options struct {
+	DryRun  bool // report the files that differ without writing them
+	Reverse bool // copy changed files from the worktree back to the root
+	Force   bool // with Reverse, also overwrite root files newer than the worktree's
+}
+
+// Change is a file Sync wrote, or would write with DryRun.
+type Change struct {
+	Path   string `json:"path"` // slash-separated, relative to the repo root
+	Mode   Mode   `json:"mode"`
+	Action string `json:"action"` // "create", "update" or "skip"
+}
+
+// Sync copies files matching .worktreeinclude patterns from rootPath to worktreePath,
+// and returns the files it copied or linked. Files that already exist with
+// identical content are skipped. Copies keep the source file's permissions
+// and modification time.
+//
+// With Reverse, files matching the patterns in worktreePath are copied back
+// to rootPath instead; symlinked files are left alone since they already
+// point at the root. A root file modified after the worktree's copy was made
+// is not overwritten unless opts.Force is set, and is returned with the
+// action "skip".
+func Sync(rootPath, worktreePath string, opts Options) ([]Change, error) {
+	// Look for .worktreeinclude in worktree first, then root
+	includeFile := FindIncludeFile(worktreePath, rootPath)
 	if includeFile == "" {
-		return nil // no .worktreeinclude, nothing to sync
+		return nil, nil // no .worktreeinclude, nothing to sync
 	}
 
-	patterns, err := parseIncludeFile(includeFile)
+	parsed, err := ParseFile(includeFile)
 	if err != nil {
-		return fmt.Errorf("parsing .worktreeinclude: %w", err)
+		return nil, fmt.Errorf("parsing .worktreeinclude: %w", err)
+	}
+	var rules []Rule
+	for _, rule := range parsed {
+		if !rule.Valid() {
+			fmt.Fprintf(os.Stderr, "  Warning: invalid pattern %q\n", rule.String())
+			continue
+		}
+		rules = append(rules, rule)
 	}
 
-	for _, pattern := range patterns {
-		matches, err := doublestar.Glob(os.DirFS(rootPath), pattern)
+	from, to := rootPath, worktreePath
+	if opts.Reverse {
+		from, to = worktreePath, rootPath
+	}
+	files, err := Match(from, rules)
+	if err != nil {
+		return nil, fmt.Errorf("matching .worktreeinclude: %w", err)
+	}
+
+	var changes []Change
+	for _, f := range files {
+		rel := filepath.FromSlash(f.Path)
+		src := filepath.Join(from, rel)
+		dst := filepath.Join(to, rel)
+
+		info, err := os.Stat(src)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: invalid pattern %q: %v\n", pattern, err)
 			continue
 		}
 
-		for _, rel := range matches {
-			src := filepath.Join(rootPath, rel)
-			dst := filepath.Join(worktreePath, rel)
-
-			info, err := os.Stat(src)
-			if err != nil || info.IsDir() {
+		if f.Mode == ModeSymlink {
+			if opts.Reverse || isLinkTo(dst, src) {
 				continue
 			}
+		} else if filesEqual(src, dst) {
+			continue
+		}
+		if opts.Reverse && isLinkTo(src, dst) {
+			continue // a file that was symlinked by an earlier rule
+		}
 
-			if filesEqual(src, dst) {
-				continue
-			}
+		change := Change{Path: f.Path, Mode: f.Mode, Action: "update"}
+		dstInfo, err := os.Lstat(dst)
+		if os.IsNotExist(err) {
+			change.Action = "create"
+		} else if err == nil && opts.Reverse && !opts.Force && dstInfo.ModTime().After(info.ModTime()) {
+			change.Action = "skip"
+		}
+		if opts.DryRun || change.Action == "skip" {
+			changes = append(changes, change)
+			continue
+		}
 
-			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
-				return fmt.Errorf("creating directory for %s: %w", rel, err)
-			}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return changes, fmt.Errorf("creating directory for %s: %w", rel, err)
+		}
 
-			if err := copyFile(src, dst, info.Mode()); err != nil {
-				return fmt.Errorf("copying %s: %w", rel, err)
+		switch f.Mode {
+		case ModeSymlink:
+			if err := linkFile(src, dst); err != nil {
+				return changes, fmt.Errorf("linking %s: %w", rel, err)
 			}
-
-			fmt.Printf("  Copied %s\n", rel)
+			changes = append(changes, change)
+			continue
+		case ModeReflink:
+			err = cloneFile(src, dst, info.Mode())
+		default:
+			err = copyFile(src, dst, info.Mode())
+		}
+		if err != nil {
+			return changes, fmt.Errorf("copying %s: %w", rel, err)
+		}
+		if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+			return changes, fmt.Errorf("copying %s: %w", rel, err)
 		}
+		changes = append(changes, change)
 	}
 
-	return nil
+	return changes, nil
 }
 
 func parseIncludeFile(path string) ([]string, error) {
@@ -88,21 +353,64 @@ func parseIncludeFile(path string) ([]string, error) {
 	return patterns, scanner.Err()
 }
 
+// filesEqual reports whether a and b are regular files with the same
+// content. Contents are hashed as streams so large files aren't read into
+// memory.
 func filesEqual(a, b string) bool {
 	infoA, errA := os.Stat(a)
-	infoB, errB := os.Stat(b)
+	infoB, errB := os.Lstat(b)
 	if errA != nil || errB != nil {
 		return false
 	}
-	if infoA.Size() != infoB.Size() {
+	if !infoB.Mode().IsRegular() || infoA.Size() != infoB.Size() {
 		return false
 	}
-	dataA, errA := os.ReadFile(a)
-	dataB, errB := os.ReadFile(b)
+	hashA, errA := hashFile(a)
+	hashB, errB := hashFile(b)
 	if errA != nil || errB != nil {
 		return false
 	}
-	return bytes.Equal(dataA, dataB)
+	return bytes.Equal(hashA, hashB)
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// isLinkTo reports whether dst is a symlink pointing at src.
+func isLinkTo(dst, src string) bool {
+	target, err := os.Readlink(dst)
+	return err == nil && target == src
+}
+
+// linkFile replaces dst with a symlink to src.
+func linkFile(src, dst string) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(src, dst)
+}
+
+// cloneFile makes dst a copy-on-write clone of src where the filesystem
+// supports it (btrfs, XFS, APFS), and a regular copy otherwise.
+func cloneFile(src, dst string, mode os.FileMode) error {
+	if err := removeSymlink(dst); err != nil {
+		return err
+	}
+	if err := reflink(src, dst, mode); err == nil {
+		return os.Chmod(dst, mode.Perm())
+	}
+	return copyFile(src, dst, mode)
 }
 
 func copyFile(src, dst string, mode os.FileMode) error {
@@ -112,12 +420,30 @@ func copyFile(src, dst string, mode os.FileMode) error {
 	}
 	defer func() { _ = sf.Close() }()
 
+	if err := removeSymlink(dst); err != nil {
+		return err
+	}
+
 	df, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = df.Close() }()
 
-	_, err = io.Copy(df, sf)
-	return err
+	if _, err := io.Copy(df, sf); err != nil {
+		return err
+	}
+	// O_TRUNC keeps an existing file's permissions, and a new file's are
+	// masked by the umask
+	return df.Chmod(mode.Perm())
+}
+
+// removeSymlink removes dst if it is a symlink, e.g. one left by an earlier
+// symlink: rule, so that writing dst doesn't write through to the root
+// worktree's file.
+func removeSymlink(dst string) error {
+	if fi, err := os.Lstat(dst); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		return os.Remove(dst)
+	}
+	return nil
 }
@@ -3,7 +3,9 @@ package filesync
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestParseIncludeFile(t *testing.T) {
@@ -200,7 +202,7 @@ func TestSyncCopiesFiles(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := Sync(root, worktree); err != nil {
+	if _, err := Sync(root, worktree, Options{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -231,7 +233,7 @@ func TestSyncSkipsIdentical(t *testing.T) {
 	info, _ := os.Stat(filepath.Join(worktree, ".env"))
 	modBefore := info.ModTime()
 
-	if err := Sync(root, worktree); err != nil {
+	if _, err := Sync(root, worktree, Options{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -247,7 +249,7 @@ func TestSyncNoIncludeFile(t *testing.T) {
 	worktree := t.TempDir()
 
 	// No .worktreeinclude — should be a no-op
-	if err := Sync(root, worktree); err != nil {
+	if _, err := Sync(root, worktree, Options{}); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -266,7 +268,7 @@ func TestSyncCreatesDirectories(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := Sync(root, worktree); err != nil {
+	if _, err := Sync(root, worktree, Options{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -275,3 +277,349 @@ func TestSyncCreatesDirectories(t *testing.T) {
 		t.Error("expected nested directory and file to be created")
 	}
 }
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		line string
+		want Rule
+	}{
+		{".env*", Rule{Mode: ModeCopy, Pattern: ".env*"}},
+		{"copy:.mcp.json", Rule{Mode: ModeCopy, Pattern: ".mcp.json"}},
+		{"symlink:config/master.key", Rule{Mode: ModeSymlink, Pattern: "config/master.key"}},
+		{"reflink:vendor/cache/**", Rule{Mode: ModeReflink, Pattern: "vendor/cache/**"}},
+		{"weird:name", Rule{Mode: ModeCopy, Pattern: "weird:name"}},
+		{"!.env.production.local", Rule{Mode: ModeCopy, Pattern: ".env.production.local", Negate: true}},
+		{"node_modules/", Rule{Mode: ModeCopy, Pattern: "node_modules", Dir: true}},
+		{"reflink:/vendor/cache/", Rule{Mode: ModeReflink, Pattern: "vendor/cache", Dir: true}},
+		{`\!important`, Rule{Mode: ModeCopy, Pattern: "!important"}},
+	}
+	for _, tt := range tests {
+		if got := ParseRule(tt.line); got != tt.want {
+			t.Errorf("ParseRule(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSyncSymlinkMode(t *testing.T) {
+	root := t.TempDir()
+	worktree := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(root, "config", "master.key")
+	if err := os.WriteFile(src, []byte("key123"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A stale copy from before the rule switched to symlink mode
+	if err := os.MkdirAll(filepath.Join(worktree, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(worktree, "config", "master.key")
+	if err := os.WriteFile(dst, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".worktreeinclude"), []byte("symlink:config/master.key\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Sync(root, worktree, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := os.Readlink(dst)
+	if err != nil {
+		t.Fatalf("expected %s to be a symlink: %v", dst, err)
+	}
+	if target != src {
+		t.Errorf("symlink target = %q, want %q", target, src)
+	}
+}
+
+func TestSyncCopyReplacesSymlink(t *testing.T) {
+	root := t.TempDir()
+	worktree := t.TempDir()
+
+	src := filepath.Join(root, ".env")
+	if err := os.WriteFile(src, []byte("SECRET=123"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(worktree, ".env")
+	if err := os.Symlink(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".worktreeinclude"), []byte("reflink:.env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Sync(root, worktree, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Mode().IsRegular() {
+		t.Errorf("expected %s to be a regular file, got %v", dst, info.Mode())
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "SECRET=123" {
+		t.Errorf("content = %q, want %q", got, "SECRET=123")
+	}
+}
+
+func TestMatchNegationAndDirectories(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{
+		".env",
+		".env.local",
+		".env.production.local",
+		"node_modules/left-pad/index.js",
+		"node_modules/left-pad/package.json",
+		"node_modules/.cache/big",
+		"config/master.key",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules := []Rule{
+		ParseRule(".env*"),
+		ParseRule("!.env.production.local"),
+		ParseRule("reflink:node_modules/"),
+		ParseRule("!node_modules/.cache/"),
+		ParseRule("config/"), // config/master.key is a file, but config is a directory
+		ParseRule("symlink:config/master.key"),
+	}
+	files, err := Match(root, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []File{
+		{Path: ".env", Mode: ModeCopy},
+		{Path: ".env.local", Mode: ModeCopy},
+		{Path: "node_modules/left-pad/index.js", Mode: ModeReflink},
+		{Path: "node_modules/left-pad/package.json", Mode: ModeReflink},
+		{Path: "config/master.key", Mode: ModeSymlink},
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("Match() = %+v, want %+v", files, want)
+	}
+}
+
+func TestUnmatched(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("A=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []Rule{
+		ParseRule(".env*"),
+		ParseRule("config/master.key"),
+		ParseRule(".env/"), // .env is a file
+		ParseRule("[invalid"),
+	}
+	var got []string
+	for _, r := range Unmatched(root, rules) {
+		got = append(got, r.String())
+	}
+	if want := []string{"config/master.key", ".env/"}; !slices.Equal(got, want) {
+		t.Errorf("Unmatched() = %q, want %q", got, want)
+	}
+}
+
+func TestSyncPreservesModeAndModTime(t *testing.T) {
+	root := t.TempDir()
+	worktree := t.TempDir()
+
+	src := filepath.Join(root, "bin", "setup")
+	if err := os.MkdirAll(filepath.Dir(src), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("#!/bin/sh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(src, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	// An existing copy with different content and permissions
+	dst := filepath.Join(worktree, "bin", "setup")
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".worktreeinclude"), []byte("bin/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Sync(root, worktree, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("mode = %v, want 0700", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("mtime = %v, want %v", info.ModTime(), mtime)
+	}
+}
+
+func TestSyncReportsChanges(t *testing.T) {
+	root := t.TempDir()
+	worktree := t.TempDir()
+
+	for name, content := range map[string]string{".env": "A=1", ".env.local": "B=2"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(worktree, ".env"), []byte("A=0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".worktreeinclude"), []byte(".env*\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Change{
+		{Path: ".env", Mode: ModeCopy, Action: "update"},
+		{Path: ".env.local", Mode: ModeCopy, Action: "create"},
+	}
+	changes, err := Sync(root, worktree, Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("Sync(DryRun) = %+v, want %+v", changes, want)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, ".env.local")); !os.IsNotExist(err) {
+		t.Error("expected dry run not to copy .env.local")
+	}
+
+	changes, err = Sync(root, worktree, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("Sync() = %+v, want %+v", changes, want)
+	}
+
+	changes, err = Sync(root, worktree, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("second Sync() = %+v, want no changes", changes)
+	}
+}
+
+func TestSyncReverse(t *testing.T) {
+	root := t.TempDir()
+	worktree := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("A=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, ".env"), []byte("A=2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, ".env.local"), []byte("B=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	key := filepath.Join(root, "config", "master.key")
+	if err := os.WriteFile(key, []byte("key"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(worktree, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(key, filepath.Join(worktree, "config", "master.key")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".worktreeinclude"), []byte(".env*\nsymlink:config/master.key\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Sync(root, worktree, Options{Reverse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Path: ".env", Mode: ModeCopy, Action: "update"},
+		{Path: ".env.local", Mode: ModeCopy, Action: "create"},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("Sync(Reverse) = %+v, want %+v", changes, want)
+	}
+	got, err := os.ReadFile(filepath.Join(root, ".env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "A=2" {
+		t.Errorf("root .env = %q, want %q", got, "A=2")
+	}
+}
+
+func TestSyncReverseSkipsNewerRootFiles(t *testing.T) {
+	root := t.TempDir()
+	worktree := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(root, ".worktreeinclude"), []byte(".env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rootEnv := filepath.Join(root, ".env")
+	if err := os.WriteFile(rootEnv, []byte("A=root"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, ".env"), []byte("A=worktree"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The root's copy was changed after the worktree's
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(worktree, ".env"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Sync(root, worktree, Options{Reverse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{{Path: ".env", Mode: ModeCopy, Action: "skip"}}
+	if !slices.Equal(changes, want) {
+		t.Errorf("Sync(Reverse) = %+v, want %+v", changes, want)
+	}
+	if got, _ := os.ReadFile(rootEnv); string(got) != "A=root" {
+		t.Errorf("root .env = %q, want it left alone", got)
+	}
+
+	changes, err = Sync(root, worktree, Options{Reverse: true, Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Change{{Path: ".env", Mode: ModeCopy, Action: "update"}}
+	if !slices.Equal(changes, want) {
+		t.Errorf("Sync(Reverse, Force) = %+v, want %+v", changes, want)
+	}
+	if got, _ := os.ReadFile(rootEnv); string(got) != "A=worktree" {
+		t.Errorf("root .env = %q, want it overwritten with --force", got)
+	}
+}
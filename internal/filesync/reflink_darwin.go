@@ -0,0 +1,19 @@
+package filesync
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// reflink clones src into dst with clonefile(2), which APFS supports.
+// clonefile won't overwrite, so an existing dst is removed first.
+func reflink(src, dst string, mode os.FileMode) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW); err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode)
+}
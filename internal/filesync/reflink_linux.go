@@ -0,0 +1,25 @@
+package filesync
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// reflink clones src into dst with the FICLONE ioctl. It fails on
+// filesystems without reflink support, such as ext4 and tmpfs.
+func reflink(src, dst string, mode os.FileMode) error {
+	sf, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = sf.Close() }()
+
+	df, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = df.Close() }()
+
+	return unix.IoctlFileClone(int(df.Fd()), int(sf.Fd()))
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package filesync
+
+import (
+	"errors"
+	"os"
+)
+
+// reflink is not supported on this platform; callers fall back to a copy.
+func reflink(src, dst string, mode os.FileMode) error {
+	return errors.ErrUnsupported
+}
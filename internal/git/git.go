@@ -12,10 +12,14 @@ import (
 
 // Worktree represents a git worktree entry.
 type Worktree struct {
-	Path   string `json:"path"`
-	HEAD   string `json:"head"`
-	Branch string `json:"branch"`
-	Bare   bool   `json:"bare"`
+	Path        string `json:"path"`
+	HEAD        string `json:"head"`
+	Branch      string `json:"branch"`
+	Bare        bool   `json:"bare"`
+	Locked      bool   `json:"locked,omitempty"`
+	LockReason  string `json:"lock_reason,omitempty"`
+	Prunable    bool   `json:"prunable,omitempty"`
+	PruneReason string `json:"prune_reason,omitempty"`
 }
 
 // WorktreeList returns all worktrees for the repo at dir.
@@ -56,6 +60,26 @@ func WorktreeMove(dir, oldPath, newPath string) error {
 	return nil
 }
 
+// WorktreePrune removes the administrative files of worktrees whose
+// directory no longer exists (unless they are locked).
+func WorktreePrune(dir string) error {
+	_, err := run(dir, "worktree", "prune")
+	if err != nil {
+		return fmt.Errorf("git worktree prune: %w", err)
+	}
+	return nil
+}
+
+// WorktreeRepair reconnects the worktree at path with the repo after either
+// of them was moved by hand.
+func WorktreeRepair(dir, path string) error {
+	_, err := run(dir, "worktree", "repair", path)
+	if err != nil {
+		return fmt.Errorf("git worktree repair: %w", err)
+	}
+	return nil
+}
+
 // WorktreeRemove removes the worktree at path.
 func WorktreeRemove(dir, path string) error {
 	_, err := run(dir, "worktree", "remove", path, "--force")
@@ -78,7 +102,34 @@ func CommonDir(dir string) (string, error) {
 	return filepath.Clean(p), nil
 }
 
-// RootWorktreePath returns the path to the main (first) worktree.
+// WorktreeID returns the name of the linked worktree at dir's administrative
+// directory under .git/worktrees. Git names it after the directory the
+// worktree was first added at, and keeps it when the worktree is moved.
+func WorktreeID(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--git-dir")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse --git-dir: %w", err)
+	}
+	p := filepath.Clean(strings.TrimSpace(out))
+	if filepath.Base(filepath.Dir(p)) != "worktrees" {
+		return "", fmt.Errorf("%s is not a linked worktree", dir)
+	}
+	return filepath.Base(p), nil
+}
+
+// RootConfigKey is the git config key recording which worktree of a bare
+// repo is its root, since a bare repo has no main worktree of its own.
+const RootConfigKey = "fr8.root"
+
+// ErrRootUnknown is returned by RootWorktreePath for a bare repo with
+// several worktrees and none recorded as the root.
+var ErrRootUnknown = errors.New("bare repo has several worktrees and none is recorded as the root")
+
+// RootWorktreePath returns the path to the main worktree. A bare repo's root
+// is the worktree recorded under RootConfigKey (see SetRootWorktree), or else
+// the one at the bare repo's path without ".git" (app for app.git), or else
+// its only worktree. Git lists linked worktrees by path, so their order says
+// nothing about which came first.
 func RootWorktreePath(dir string) (string, error) {
 	wts, err := WorktreeList(dir)
 	if err != nil {
@@ -87,7 +138,53 @@ func RootWorktreePath(dir string) (string, error) {
 	if len(wts) == 0 {
 		return "", fmt.Errorf("no worktrees found")
 	}
-	return wts[0].Path, nil
+	if !wts[0].Bare {
+		return wts[0].Path, nil
+	}
+
+	linked := wts[1:]
+	listed := func(path string) (string, bool) {
+		for _, wt := range linked {
+			if filepath.Clean(wt.Path) == filepath.Clean(path) {
+				return wt.Path, true
+			}
+		}
+		return "", false
+	}
+	if out, err := run(dir, "config", "--get", RootConfigKey); err == nil {
+		if p, ok := listed(strings.TrimSpace(out)); ok {
+			return p, nil
+		}
+	}
+	if strings.HasSuffix(wts[0].Path, ".git") {
+		if p, ok := listed(strings.TrimSuffix(wts[0].Path, ".git")); ok {
+			return p, nil
+		}
+	}
+	switch len(linked) {
+	case 0:
+		return wts[0].Path, nil
+	case 1:
+		return linked[0].Path, nil
+	}
+	return "", fmt.Errorf("%s: %w (record it with: git config %s <path>)", wts[0].Path, ErrRootUnknown, RootConfigKey)
+}
+
+// SetRootWorktree records path as the root worktree of the bare repo at dir.
+func SetRootWorktree(dir, path string) error {
+	if _, err := run(dir, "config", RootConfigKey, path); err != nil {
+		return fmt.Errorf("git config: %w", err)
+	}
+	return nil
+}
+
+// Toplevel returns the top-level directory of the worktree containing dir.
+func Toplevel(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse --show-toplevel: %w", err)
+	}
+	return strings.TrimSpace(out), nil
 }
 
 // DefaultBranch returns "main" or "master", whichever exists.
@@ -163,6 +260,15 @@ type CommitInfo struct {
 	Time    time.Time `json:"time"`
 }
 
+// HeadCommit returns the full SHA of the commit checked out in dir.
+func HeadCommit(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse HEAD: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
 // LastCommit returns the subject and timestamp of the most recent commit.
 func LastCommit(dir string) (CommitInfo, error) {
 	out, err := run(dir, "log", "-1", "--format=%s|||%ct")
@@ -186,6 +292,55 @@ func LastCommit(dir string) (CommitInfo, error) {
 	}, nil
 }
 
+// Diff returns a binary patch of the uncommitted changes to tracked files in
+// dir: the staged changes if cached is true, the unstaged ones otherwise.
+func Diff(dir string, cached bool) ([]byte, error) {
+	args := []string{"diff", "--binary"}
+	if cached {
+		args = append(args, "--cached")
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("git diff: %w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	return out, nil
+}
+
+// Apply applies the patch file at patch to the worktree in dir, and also to
+// the index if index is true.
+func Apply(dir, patch string, index bool) error {
+	args := []string{"apply", "--binary"}
+	if index {
+		args = append(args, "--index")
+	}
+	if _, err := run(dir, append(args, patch)...); err != nil {
+		return fmt.Errorf("git apply: %w", err)
+	}
+	return nil
+}
+
+// UntrackedFiles returns the untracked files in dir that are not ignored,
+// relative to dir.
+func UntrackedFiles(dir string) ([]string, error) {
+	out, err := run(dir, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %w", err)
+	}
+	var files []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f != "" {
+			files = append(files, filepath.FromSlash(f))
+		}
+	}
+	return files, nil
+}
+
 // IsInsideWorkTree returns true if dir is inside a git repository.
 func IsInsideWorkTree(dir string) bool {
 	_, err := run(dir, "rev-parse", "--is-inside-work-tree")
@@ -213,6 +368,37 @@ func Fetch(dir, remote string) error {
 	return nil
 }
 
+// Clone clones url into path. A bare clone is set up to fetch remote
+// branches into refs/remotes/origin like a regular clone, so that worktrees
+// added to it can track them.
+func Clone(url, path string, bare bool) error {
+	// "--" keeps a url or path starting with "-" from being read as an
+	// option such as --upload-pack, which would run a command
+	args := []string{"clone", "--", url, path}
+	if bare {
+		args = []string{"clone", "--bare", "--", url, path}
+	}
+	if _, err := run("", args...); err != nil {
+		return fmt.Errorf("git clone: %w", err)
+	}
+	if !bare {
+		return nil
+	}
+	if _, err := run(path, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*"); err != nil {
+		return fmt.Errorf("git config: %w", err)
+	}
+	return Fetch(path, "origin")
+}
+
+// SetUpstream makes branch track upstream (e.g. "origin/main").
+func SetUpstream(dir, branch, upstream string) error {
+	_, err := run(dir, "branch", "--set-upstream-to="+upstream, branch)
+	if err != nil {
+		return fmt.Errorf("git branch --set-upstream-to: %w", err)
+	}
+	return nil
+}
+
 // IsMerged returns true if branch has been merged into target.
 // Uses git merge-base --is-ancestor (exit 0 = merged, exit 1 = not merged).
 func IsMerged(dir, branch, target string) (bool, error) {
@@ -296,6 +482,12 @@ func parsePorcelain(output string) []Worktree {
 			current.Branch = strings.TrimPrefix(ref, "refs/heads/")
 		case line == "bare":
 			current.Bare = true
+		case line == "locked" || strings.HasPrefix(line, "locked "):
+			current.Locked = true
+			current.LockReason = strings.TrimPrefix(strings.TrimPrefix(line, "locked"), " ")
+		case line == "prunable" || strings.HasPrefix(line, "prunable "):
+			current.Prunable = true
+			current.PruneReason = strings.TrimPrefix(strings.TrimPrefix(line, "prunable"), " ")
 		case line == "":
 			if current.Path != "" {
 				worktrees = append(worktrees, current)
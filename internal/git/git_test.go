@@ -1,9 +1,11 @@
 package git
 
 import (
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -54,6 +56,29 @@ func TestParsePorcelain(t *testing.T) {
 				{Path: "/Users/me/project.git", HEAD: "abc123", Bare: true},
 			},
 		},
+		{
+			name: "locked and prunable",
+			input: "worktree /Users/me/project\n" +
+				"HEAD abc123\n" +
+				"branch refs/heads/main\n" +
+				"\n" +
+				"worktree /Volumes/usb/feature\n" +
+				"HEAD def456\n" +
+				"branch refs/heads/feature\n" +
+				"locked on a removable drive\n" +
+				"\n" +
+				"worktree /Users/me/worktrees/gone\n" +
+				"HEAD 789abc\n" +
+				"detached\n" +
+				"locked\n" +
+				"prunable gitdir file points to non-existent location\n" +
+				"\n",
+			expect: []Worktree{
+				{Path: "/Users/me/project", HEAD: "abc123", Branch: "main"},
+				{Path: "/Volumes/usb/feature", HEAD: "def456", Branch: "feature", Locked: true, LockReason: "on a removable drive"},
+				{Path: "/Users/me/worktrees/gone", HEAD: "789abc", Locked: true, Prunable: true, PruneReason: "gitdir file points to non-existent location"},
+			},
+		},
 		{
 			name: "no trailing newline",
 			input: "worktree /Users/me/project\n" +
@@ -173,6 +198,14 @@ func TestWorktreeMoveIntegration(t *testing.T) {
 		t.Errorf("expected new path to exist after move: %v", err)
 	}
 
+	// The worktree keeps the ID it was added under
+	if id, err := WorktreeID(newPath); err != nil || id != "old-ws" {
+		t.Errorf("WorktreeID() = %q, %v, want old-ws", id, err)
+	}
+	if _, err := WorktreeID(dir); err == nil {
+		t.Error("expected error for the main worktree's ID")
+	}
+
 	// Git should list the worktree at the new path
 	wts, err := WorktreeList(dir)
 	if err != nil {
@@ -205,6 +238,136 @@ func TestWorktreeMoveNonexistentIntegration(t *testing.T) {
 	}
 }
 
+func TestWorktreePruneRepairIntegration(t *testing.T) {
+	dir := initTestRepo(t)
+	gonePath := filepath.Join(t.TempDir(), "gone-ws")
+	movedPath := filepath.Join(t.TempDir(), "moved-ws")
+	if err := WorktreeAdd(dir, gonePath, "gone", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := WorktreeAdd(dir, movedPath, "moved", true, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete one worktree and move the other by hand
+	if err := os.RemoveAll(gonePath); err != nil {
+		t.Fatal(err)
+	}
+	newPath := filepath.Join(t.TempDir(), "new-ws")
+	if err := os.Rename(movedPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+
+	wts, err := WorktreeList(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prunable := 0
+	for _, wt := range wts {
+		if wt.Prunable {
+			prunable++
+		}
+	}
+	if prunable != 2 {
+		t.Fatalf("got %d prunable worktrees, want 2: %+v", prunable, wts)
+	}
+
+	if err := WorktreeRepair(dir, newPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := WorktreePrune(dir); err != nil {
+		t.Fatal(err)
+	}
+	wts, err = WorktreeList(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wts) != 2 || wts[1].Branch != "moved" || wts[1].Prunable {
+		t.Errorf("worktrees after repair and prune = %+v, want the main worktree and moved", wts)
+	}
+}
+
+func TestCloneBareIntegration(t *testing.T) {
+	src := initTestRepo(t)
+	branch, err := CurrentBranch(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	bare := filepath.Join(dir, "myapp.git")
+	if err := Clone(src, bare, true); err != nil {
+		t.Fatal(err)
+	}
+	if !RemoteRefExists(bare, "origin/"+branch) {
+		t.Errorf("expected origin/%s after a bare clone", branch)
+	}
+
+	main := filepath.Join(dir, "myapp")
+	if err := WorktreeAdd(bare, main, branch, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetUpstream(main, branch, "origin/"+branch); err != nil {
+		t.Fatal(err)
+	}
+	if upstream, err := TrackingBranch(main, branch); err != nil || upstream != "origin/"+branch {
+		t.Errorf("TrackingBranch() = %q, %v, want origin/%s", upstream, err, branch)
+	}
+
+	// The bare repo isn't a worktree; the one next to it named like it is
+	// the root, even once a worktree whose path sorts first is added
+	first := filepath.Join(dir, "aa", "first")
+	if err := WorktreeAdd(bare, first, "first", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	assertRoot := func(want string, dirs ...string) {
+		t.Helper()
+		for _, d := range dirs {
+			root, err := RootWorktreePath(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotReal, _ := filepath.EvalSymlinks(root)
+			wantReal, _ := filepath.EvalSymlinks(want)
+			if gotReal != wantReal {
+				t.Errorf("RootWorktreePath(%s) = %q, want %q", d, root, want)
+			}
+		}
+	}
+	assertRoot(main, bare, main, first)
+
+	// A root elsewhere is found through the path recorded in the config
+	other := filepath.Join(dir, "zz", "app")
+	if err := WorktreeAdd(bare, other, "other", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := WorktreeMove(bare, main, filepath.Join(dir, "moved")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RootWorktreePath(first); !errors.Is(err, ErrRootUnknown) {
+		t.Fatalf("RootWorktreePath() without a recorded root: err = %v, want ErrRootUnknown", err)
+	}
+	if err := SetRootWorktree(bare, other); err != nil {
+		t.Fatal(err)
+	}
+	assertRoot(other, bare, other, first)
+}
+
+func TestCloneDashURLIsNotAnOption(t *testing.T) {
+	// Read as an option, the url would make git clone the repo at path,
+	// running the command as its upload-pack
+	src := initTestRepo(t)
+	marker := filepath.Join(t.TempDir(), "pwned")
+	for _, bare := range []bool{false, true} {
+		err := Clone("--upload-pack=touch "+marker+"; git-upload-pack", src, bare)
+		if err == nil {
+			t.Errorf("Clone(bare=%v) of a dash-prefixed url succeeded, want an error", bare)
+		}
+		if _, err := os.Stat(marker); err == nil {
+			t.Fatalf("Clone(bare=%v) ran the url as --upload-pack", bare)
+		}
+	}
+}
+
 func TestCommonDirIntegration(t *testing.T) {
 	dir := initTestRepo(t)
 
@@ -243,6 +406,18 @@ func TestCurrentBranchIntegration(t *testing.T) {
 	}
 }
 
+func TestHeadCommitIntegration(t *testing.T) {
+	dir := initTestRepo(t)
+
+	sha, err := HeadCommit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sha) != 40 {
+		t.Errorf("HeadCommit = %q, want a full SHA", sha)
+	}
+}
+
 func TestHasUncommittedChangesIntegration(t *testing.T) {
 	dir := initTestRepo(t)
 
@@ -268,6 +443,73 @@ func TestHasUncommittedChangesIntegration(t *testing.T) {
 	}
 }
 
+func TestDiffApplyUntrackedIntegration(t *testing.T) {
+	dir := initTestRepo(t)
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("a.txt", "one\n")
+	gitRun("add", "a.txt")
+	gitRun("commit", "-m", "add a")
+
+	write("a.txt", "two\n")
+	gitRun("add", "a.txt")
+	write("a.txt", "three\n")
+	write("new.txt", "hello")
+
+	staged, err := Diff(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unstaged, err := Diff(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(staged), "+two") || !strings.Contains(string(unstaged), "+three") {
+		t.Errorf("Diff() = %q (staged), %q (unstaged), want the staged and unstaged changes", staged, unstaged)
+	}
+
+	files, err := UntrackedFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != "new.txt" {
+		t.Errorf("UntrackedFiles() = %v, want [new.txt]", files)
+	}
+
+	patchDir := t.TempDir()
+	for name, data := range map[string][]byte{"staged.patch": staged, "unstaged.patch": unstaged} {
+		if err := os.WriteFile(filepath.Join(patchDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun("reset", "--hard")
+	if err := Apply(dir, filepath.Join(patchDir, "staged.patch"), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(dir, filepath.Join(patchDir, "unstaged.patch"), false); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(got) != "three\n" {
+		t.Errorf("a.txt after Apply = %q, want %q", got, "three\n")
+	}
+	if got, _ := Diff(dir, true); string(got) != string(staged) {
+		t.Errorf("staged changes after Apply = %q, want %q", got, staged)
+	}
+}
+
 func TestIsInsideWorkTreeIntegration(t *testing.T) {
 	dir := initTestRepo(t)
 
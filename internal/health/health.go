@@ -0,0 +1,143 @@
+// Package health checks whether a workspace's server is ready to accept
+// requests, using the healthcheck block in fr8.json.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/protocollar/fr8/internal/config"
+)
+
+// Health states reported for a running workspace.
+const (
+	Healthy   = "healthy"
+	Unhealthy = "unhealthy"
+	Starting  = "starting"
+)
+
+// ErrTimeout is returned by Wait when the check does not pass in time.
+var ErrTimeout = errors.New("timed out")
+
+// Check is a healthcheck resolved against a workspace's port block.
+type Check struct {
+	Port        int
+	Path        string // HTTP path; empty for a TCP check
+	Status      int    // expected HTTP status; 0 accepts any 2xx or 3xx
+	Timeout     time.Duration
+	Interval    time.Duration
+	StartPeriod time.Duration
+}
+
+// New resolves hc for a workspace whose port block starts at basePort. A nil
+// hc checks that basePort accepts TCP connections, with default timings.
+func New(hc *config.Healthcheck, basePort int) Check {
+	if hc == nil {
+		hc = &config.Healthcheck{}
+	}
+	return Check{
+		Port:        basePort + hc.PortOffset,
+		Path:        hc.Path,
+		Status:      hc.Status,
+		Timeout:     hc.TimeoutDuration(),
+		Interval:    hc.IntervalDuration(),
+		StartPeriod: hc.StartPeriodDuration(),
+	}
+}
+
+func (c Check) addr() string {
+	return net.JoinHostPort("localhost", strconv.Itoa(c.Port))
+}
+
+// String describes the check, e.g. "http://localhost:60000/up" or
+// "tcp localhost:60000".
+func (c Check) String() string {
+	if c.Path != "" {
+		return "http://" + c.addr() + c.Path
+	}
+	return "tcp " + c.addr()
+}
+
+// Probe runs the check once.
+func (c Check) Probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	if c.Path == "" {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", c.addr())
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+c.addr()+c.Path, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		// A redirect means the server is up; don't follow it.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if c.Status != 0 {
+		if resp.StatusCode != c.Status {
+			return fmt.Errorf("got HTTP %d, want %d", resp.StatusCode, c.Status)
+		}
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("got HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Wait probes every Interval until the check passes. It stops early with
+// alive's error if alive reports that the workspace has stopped, and with
+// ErrTimeout when ctx's deadline passes.
+func (c Check) Wait(ctx context.Context, alive func() error) error {
+	for {
+		err := c.Probe(ctx)
+		if err == nil {
+			return nil
+		}
+		if alive != nil {
+			if aliveErr := alive(); aliveErr != nil {
+				return aliveErr
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%w waiting for %s: %v", ErrTimeout, c, err)
+			}
+			return ctx.Err()
+		case <-time.After(c.Interval):
+		}
+	}
+}
+
+// State probes once and reports Healthy if the check passes. A failing
+// workspace that started less than StartPeriod ago is Starting; otherwise
+// it is Unhealthy.
+func (c Check) State(ctx context.Context, started time.Time) string {
+	if c.Probe(ctx) == nil {
+		return Healthy
+	}
+	if !started.IsZero() && time.Since(started) < c.StartPeriod {
+		return Starting
+	}
+	return Unhealthy
+}
@@ -0,0 +1,175 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/protocollar/fr8/internal/config"
+)
+
+// serverPort returns the port of an httptest server.
+func serverPort(t *testing.T, s *httptest.Server) int {
+	t.Helper()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// freePort returns a port with nothing listening on it.
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+	return p
+}
+
+func TestNew(t *testing.T) {
+	c := New(&config.Healthcheck{Path: "/up", PortOffset: 2, Interval: "100ms"}, 60000)
+	if c.Port != 60002 || c.Path != "/up" || c.Interval != 100*time.Millisecond {
+		t.Errorf("New() = %+v, want port 60002, path /up, interval 100ms", c)
+	}
+	if got, want := c.String(), "http://localhost:60002/up"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	c = New(nil, 60000)
+	if c.Port != 60000 || c.Path != "" || c.Timeout != config.DefaultHealthTimeout {
+		t.Errorf("New(nil) = %+v, want TCP check on 60000 with defaults", c)
+	}
+	if got, want := c.String(), "tcp localhost:60000"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+
+	c := Check{Port: l.Addr().(*net.TCPAddr).Port, Timeout: time.Second}
+	if err := c.Probe(context.Background()); err != nil {
+		t.Errorf("Probe() = %v, want nil for listening port", err)
+	}
+
+	c.Port = freePort(t)
+	if err := c.Probe(context.Background()); err == nil {
+		t.Error("Probe() = nil, want error for closed port")
+	}
+}
+
+func TestProbeHTTP(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/up":
+			w.WriteHeader(http.StatusNoContent)
+		case "/login":
+			http.Redirect(w, r, "/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+	port := serverPort(t, s)
+
+	tests := []struct {
+		path   string
+		status int
+		ok     bool
+	}{
+		{"/up", 0, true},
+		{"/login", 0, true},
+		{"/down", 0, false},
+		{"/up", 204, true},
+		{"/up", 200, false},
+		{"/down", 503, true},
+	}
+	for _, tt := range tests {
+		c := Check{Port: port, Path: tt.path, Status: tt.status, Timeout: time.Second}
+		err := c.Probe(context.Background())
+		if (err == nil) != tt.ok {
+			t.Errorf("Probe(%s, status %d) = %v, want ok=%v", tt.path, tt.status, err, tt.ok)
+		}
+	}
+}
+
+func TestWait(t *testing.T) {
+	ready := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-ready:
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+
+	c := Check{Port: serverPort(t, s), Path: "/", Timeout: time.Second, Interval: 10 * time.Millisecond}
+	time.AfterFunc(50*time.Millisecond, func() { close(ready) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Wait(ctx, nil); err != nil {
+		t.Errorf("Wait() = %v, want nil once healthy", err)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	c := Check{Port: freePort(t), Timeout: 100 * time.Millisecond, Interval: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := c.Wait(ctx, nil); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Wait() = %v, want ErrTimeout", err)
+	}
+}
+
+func TestWaitStopsWhenNotAlive(t *testing.T) {
+	c := Check{Port: freePort(t), Timeout: 100 * time.Millisecond, Interval: 10 * time.Millisecond}
+	stopped := errors.New("workspace stopped")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Wait(ctx, func() error { return stopped }); !errors.Is(err, stopped) {
+		t.Errorf("Wait() = %v, want alive's error", err)
+	}
+}
+
+func TestState(t *testing.T) {
+	c := Check{Port: freePort(t), Timeout: 100 * time.Millisecond, StartPeriod: time.Minute}
+	if got := c.State(context.Background(), time.Now()); got != Starting {
+		t.Errorf("State() just after start = %q, want %q", got, Starting)
+	}
+	if got := c.State(context.Background(), time.Now().Add(-2*time.Minute)); got != Unhealthy {
+		t.Errorf("State() after start period = %q, want %q", got, Unhealthy)
+	}
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	c.Port = l.Addr().(*net.TCPAddr).Port
+	if got := c.State(context.Background(), time.Now()); got != Healthy {
+		t.Errorf("State() = %q, want %q", got, Healthy)
+	}
+}
@@ -0,0 +1,180 @@
+// Package hook implements fr8's shell hook, which loads a workspace's
+// environment when the shell enters it and unloads it on the way out.
+// The hook runs on every directory change, so it reads a cached Index
+// of the registry rather than asking git where it is.
+package hook
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/protocollar/fr8/internal/env"
+)
+
+// ExportCommand is the hidden fr8 subcommand the hook runs on every
+// directory change, as "fr8 hook export <shell>".
+const ExportCommand = "export"
+
+// Shells lists the shells Script supports.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// DirVar and VarsVar record in the shell which workspace the hook loaded
+// and the names of the variables it exported, so they can be unset again.
+// PrevVar holds the values those variables had before the hook first set
+// them, so they can be restored instead.
+const (
+	DirVar  = "_FR8_HOOK_DIR"
+	VarsVar = "_FR8_HOOK_VARS"
+	PrevVar = "_FR8_HOOK_PREV"
+)
+
+const bashScript = `_fr8_hook() {
+  local previous_exit_status=$?
+  if [[ "$PWD" != "${_FR8_HOOK_PWD-}" ]]; then
+    _FR8_HOOK_PWD=$PWD
+    eval "$(%[1]s hook export bash)"
+  fi
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_fr8_hook;"* ]]; then
+  if [[ "$(declare -p PROMPT_COMMAND 2>&1)" == "declare -a"* ]]; then
+    PROMPT_COMMAND=(_fr8_hook "${PROMPT_COMMAND[@]}")
+  else
+    PROMPT_COMMAND="_fr8_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+  fi
+fi
+`
+
+const zshScript = `_fr8_hook() {
+  eval "$(%[1]s hook export zsh)"
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _fr8_hook
+_fr8_hook
+`
+
+const fishScript = `function __fr8_hook --on-variable PWD
+    %[1]s hook export fish | source
+end
+__fr8_hook
+`
+
+// Script returns the hook to add to the given shell's startup file. fr8 is
+// the path of the fr8 executable the hook calls.
+func Script(shell, fr8 string) (string, error) {
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(bashScript, env.ShellQuote(fr8)), nil
+	case "zsh":
+		return fmt.Sprintf(zshScript, env.ShellQuote(fr8)), nil
+	case "fish":
+		return fmt.Sprintf(fishScript, env.FishQuote(fr8)), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q (want one of: %s)", shell, strings.Join(Shells, ", "))
+}
+
+// Export returns the statements the hook evaluates after a directory change.
+// wsPath and vars are the path and variables of the workspace the shell is
+// now in, both empty outside workspaces; getenv looks up the shell's current
+// environment, including DirVar, VarsVar and PrevVar from the previous call.
+// Variables the previous workspace exported and the new one doesn't set are
+// restored to the value they had before the hook set them, or unset if they
+// had none.
+func Export(shell, wsPath string, vars []string, getenv func(string) (string, bool)) (string, error) {
+	format, unset := "sh", "unset %s\n"
+	switch shell {
+	case "bash", "zsh":
+	case "fish":
+		format, unset = "fish", "set -e %s\n"
+	default:
+		return "", fmt.Errorf("unsupported shell %q (want one of: %s)", shell, strings.Join(Shells, ", "))
+	}
+
+	var names []string
+	for _, v := range vars {
+		if k, _, ok := strings.Cut(v, "="); ok {
+			names = append(names, k)
+		}
+	}
+	prevNames, _ := getenv(VarsVar)
+	exported := strings.Fields(prevNames)
+	prev := decodePrev(getenv)
+
+	var b strings.Builder
+	var exports []string
+	for _, name := range exported {
+		if slices.Contains(names, name) {
+			continue
+		}
+		if value, ok := prev[name]; ok {
+			exports = append(exports, name+"="+value)
+			delete(prev, name)
+		} else {
+			fmt.Fprintf(&b, unset, name)
+		}
+	}
+	// Remember what the shell had before the hook first overrides it
+	for _, name := range names {
+		if slices.Contains(exported, name) {
+			continue
+		}
+		if value, ok := getenv(name); ok {
+			prev[name] = value
+		}
+	}
+
+	_, hadPrev := getenv(PrevVar)
+	if wsPath == "" {
+		fmt.Fprintf(&b, unset, DirVar)
+		fmt.Fprintf(&b, unset, VarsVar)
+		if hadPrev {
+			fmt.Fprintf(&b, unset, PrevVar)
+		}
+	} else {
+		vars = append(slices.Clip(vars), DirVar+"="+wsPath, VarsVar+"="+strings.Join(names, " "))
+		if len(prev) > 0 {
+			encoded, err := encodePrev(prev)
+			if err != nil {
+				return "", err
+			}
+			vars = append(vars, PrevVar+"="+encoded)
+		} else if hadPrev {
+			fmt.Fprintf(&b, unset, PrevVar)
+		}
+		exports = append(exports, vars...)
+	}
+	out, err := env.Format(exports, format)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(out)
+	return b.String(), nil
+}
+
+// encodePrev encodes the saved values for PrevVar. They are base64-encoded
+// JSON so that any value survives in a single variable.
+func encodePrev(prev map[string]string) (string, error) {
+	data, err := json.Marshal(prev)
+	if err != nil {
+		return "", fmt.Errorf("saving previous environment: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// decodePrev returns the values saved in PrevVar, or an empty map if it is
+// unset or unreadable.
+func decodePrev(getenv func(string) (string, bool)) map[string]string {
+	var prev map[string]string
+	if encoded, ok := getenv(PrevVar); ok {
+		if data, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+			_ = json.Unmarshal(data, &prev)
+		}
+	}
+	if prev == nil {
+		prev = map[string]string{}
+	}
+	return prev
+}
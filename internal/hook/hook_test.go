@@ -0,0 +1,193 @@
+package hook
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func TestScript(t *testing.T) {
+	for _, shell := range Shells {
+		script, err := Script(shell, "/opt/fr8 bin/fr8")
+		if err != nil {
+			t.Fatalf("Script(%s): %v", shell, err)
+		}
+		if !strings.Contains(script, "'/opt/fr8 bin/fr8' hook export "+shell) {
+			t.Errorf("Script(%s) doesn't call the quoted executable:\n%s", shell, script)
+		}
+	}
+	if _, err := Script("tcsh", "fr8"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
+
+// lookup returns a getenv function for a shell environment holding vars.
+func lookup(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestExportEnter(t *testing.T) {
+	out, err := Export("bash", "/ws/one", []string{"FR8_WORKSPACE_NAME=one", "FR8_PORT=5000"}, lookup(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "export FR8_WORKSPACE_NAME='one'\n" +
+		"export FR8_PORT='5000'\n" +
+		"export _FR8_HOOK_DIR='/ws/one'\n" +
+		"export _FR8_HOOK_VARS='FR8_WORKSPACE_NAME FR8_PORT'\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestExportSwitchUnsetsVarsTheNewWorkspaceLacks(t *testing.T) {
+	out, err := Export("fish", "/ws/two", []string{"FR8_PORT=5010"}, lookup(map[string]string{
+		"FR8_PORT":     "5000",
+		"DATABASE_URL": "postgres://localhost:5001/one",
+		VarsVar:        "FR8_PORT DATABASE_URL",
+		DirVar:         "/ws/one",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "set -e DATABASE_URL\nset -gx FR8_PORT '5010'\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if strings.Contains(out, "set -e FR8_PORT") || strings.Contains(out, PrevVar) {
+		t.Errorf("FR8_PORT should be replaced, and nothing saved:\n%s", out)
+	}
+}
+
+func TestExportLeave(t *testing.T) {
+	out, err := Export("zsh", "", nil, lookup(map[string]string{
+		"FR8_PORT": "5000",
+		"MSG":      "hi",
+		VarsVar:    "FR8_PORT MSG",
+		DirVar:     "/ws/one",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "unset FR8_PORT\nunset MSG\nunset _FR8_HOOK_DIR\nunset _FR8_HOOK_VARS\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestExportRestoresValuesFromBeforeEntering(t *testing.T) {
+	shell := map[string]string{"DATABASE_URL": "postgres://localhost/dev", "PATH": "/bin"}
+
+	// apply evaluates the export statements in shell
+	apply := func(out string) {
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if name, ok := strings.CutPrefix(line, "unset "); ok {
+				delete(shell, name)
+				continue
+			}
+			kv := strings.TrimPrefix(line, "export ")
+			k, v, _ := strings.Cut(kv, "=")
+			shell[k] = strings.Trim(v, "'")
+		}
+	}
+
+	out, err := Export("bash", "/ws/one", []string{"FR8_PORT=5000", "DATABASE_URL=postgres://localhost:5001/one"}, lookup(shell))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply(out)
+	if shell["DATABASE_URL"] != "postgres://localhost:5001/one" || shell[PrevVar] == "" {
+		t.Fatalf("after entering: %v", shell)
+	}
+
+	// Switching to a workspace without DATABASE_URL brings the shell's back
+	out, err = Export("bash", "/ws/two", []string{"FR8_PORT=5010"}, lookup(shell))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply(out)
+	if shell["DATABASE_URL"] != "postgres://localhost/dev" || shell["FR8_PORT"] != "5010" {
+		t.Fatalf("after switching: %v", shell)
+	}
+	if _, ok := shell[PrevVar]; ok {
+		t.Errorf("expected %s to be unset once nothing is saved, got %q", PrevVar, shell[PrevVar])
+	}
+
+	out, err = Export("bash", "/ws/one", []string{"FR8_PORT=5000", "DATABASE_URL=postgres://localhost:5001/one"}, lookup(shell))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply(out)
+	out, err = Export("bash", "", nil, lookup(shell))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply(out)
+	want := map[string]string{"DATABASE_URL": "postgres://localhost/dev", "PATH": "/bin"}
+	if !maps.Equal(shell, want) {
+		t.Errorf("after leaving: %v, want %v", shell, want)
+	}
+}
+
+func TestLoadIndex(t *testing.T) {
+	dir := t.TempDir()
+	regPath := filepath.Join(dir, "repos.json")
+
+	idx, err := LoadIndex(regPath)
+	if err != nil {
+		t.Fatalf("LoadIndex without a registry: %v", err)
+	}
+	if len(idx.Repos) != 0 {
+		t.Fatalf("expected empty index, got %d repos", len(idx.Repos))
+	}
+
+	reg := &registry.Registry{Repos: []registry.Repo{{
+		Name:       "app",
+		Path:       filepath.Join(dir, "app"),
+		Workspaces: []registry.Workspace{{Name: "one", Path: filepath.Join(dir, "ws", "one"), Port: 5000}},
+		Archived:   []registry.ArchivedWorkspace{{Workspace: registry.Workspace{Name: "old"}}},
+	}}}
+	if err := reg.Save(regPath); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err = LoadIndex(regPath)
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+	if _, err := os.Stat(IndexPath(regPath)); err != nil {
+		t.Fatalf("index not saved: %v", err)
+	}
+	ws, repo := idx.Lookup(filepath.Join(dir, "ws", "one", "src"))
+	if ws == nil || ws.Name != "one" || repo.Name != "app" {
+		t.Fatalf("Lookup = %v, %v; want workspace one in app", ws, repo)
+	}
+	if repo.RootPath != filepath.Join(dir, "app") {
+		t.Errorf("RootPath = %q, want the repo path when git can't tell", repo.RootPath)
+	}
+	if len(repo.Archived) != 0 {
+		t.Errorf("expected archived workspaces to be left out, got %d", len(repo.Archived))
+	}
+	if ws, _ := idx.Lookup(filepath.Join(dir, "app")); ws != nil {
+		t.Errorf("expected no workspace for the repo root, got %q", ws.Name)
+	}
+
+	// Changing the registry rebuilds the index
+	reg.Repos[0].Workspaces = append(reg.Repos[0].Workspaces, registry.Workspace{Name: "two", Path: filepath.Join(dir, "ws", "two"), Port: 5010})
+	if err := reg.Save(regPath); err != nil {
+		t.Fatal(err)
+	}
+	idx, err = LoadIndex(regPath)
+	if err != nil {
+		t.Fatalf("LoadIndex after change: %v", err)
+	}
+	if ws, _ := idx.Lookup(filepath.Join(dir, "ws", "two")); ws == nil || ws.Port != 5010 {
+		t.Errorf("expected rebuilt index to contain workspace two, got %v", ws)
+	}
+}
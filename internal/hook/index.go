@@ -0,0 +1,117 @@
+package hook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+// Index is the registry's workspaces together with what the hook would
+// otherwise need git for: each repo's root worktree and default branch.
+// It is rebuilt whenever the registry file changes.
+type Index struct {
+	RegistryModTime time.Time `json:"registry_mod_time"`
+	RegistrySize    int64     `json:"registry_size"`
+	Repos           []Repo    `json:"repos"`
+}
+
+// Repo is a registered repo in the index. Archived workspaces are left out.
+type Repo struct {
+	registry.Repo
+	RootPath      string `json:"root_path"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+}
+
+// IndexPath returns the path of the index for the registry at regPath,
+// stored next to it.
+func IndexPath(regPath string) string {
+	return filepath.Join(filepath.Dir(regPath), "hook-index.json")
+}
+
+// LoadIndex returns the index for the registry at regPath, rebuilding and
+// saving it when the registry has changed since it was written.
+func LoadIndex(regPath string) (*Index, error) {
+	fi, err := os.Stat(regPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Index{}, nil
+		}
+		return nil, fmt.Errorf("reading registry: %w", err)
+	}
+
+	path := IndexPath(regPath)
+	if data, err := os.ReadFile(path); err == nil {
+		var idx Index
+		if json.Unmarshal(data, &idx) == nil && idx.RegistryModTime.Equal(fi.ModTime()) && idx.RegistrySize == fi.Size() {
+			return &idx, nil
+		}
+	}
+
+	reg, err := registry.Load(regPath)
+	if err != nil {
+		return nil, err
+	}
+	idx := BuildIndex(reg)
+	idx.RegistryModTime = fi.ModTime()
+	idx.RegistrySize = fi.Size()
+	// A stale or missing index only costs the next call a rebuild
+	_ = idx.write(path)
+	return idx, nil
+}
+
+// BuildIndex builds an index of reg, looking up each repo's root worktree
+// and default branch with git.
+func BuildIndex(reg *registry.Registry) *Index {
+	idx := &Index{Repos: make([]Repo, 0, len(reg.Repos))}
+	for _, r := range reg.Repos {
+		r.Archived = nil
+		rootPath, err := git.RootWorktreePath(r.Path)
+		if err != nil {
+			rootPath = r.Path
+		}
+		defaultBranch, _ := git.DefaultBranch(rootPath)
+		idx.Repos = append(idx.Repos, Repo{Repo: r, RootPath: rootPath, DefaultBranch: defaultBranch})
+	}
+	return idx
+}
+
+// Lookup returns the workspace containing dir and its repo, or nil if dir
+// isn't inside a workspace.
+func (idx *Index) Lookup(dir string) (*registry.Workspace, *Repo) {
+	for i := range idx.Repos {
+		if ws := idx.Repos[i].FindWorkspaceByPath(dir); ws != nil {
+			return ws, &idx.Repos[i]
+		}
+	}
+	return nil, nil
+}
+
+// write atomically replaces the index file at path, so shells running the
+// hook concurrently never read a partial file.
+func (idx *Index) write(path string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,394 @@
+// Package logfile records background session output to disk. Each process
+// writes timestamped lines to <state dir>/logs/<repo>/<workspace>/<name>.log,
+// rotated by size, and the log from the previous run is kept alongside.
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+// PipeCommand is the hidden fr8 subcommand that copies its stdin into a log
+// file. tmux pipe-pane runs it for every session window.
+const PipeCommand = "__logpipe"
+
+// DefaultName is the log name used for a session's unnamed process.
+const DefaultName = "run"
+
+// MaxSize is the size at which the current log is rotated.
+var MaxSize int64 = 10 << 20
+
+// Keep is the number of rotated segments kept per log.
+var Keep = 3
+
+const timeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Dir returns the log directory for a workspace (respects FR8_STATE_DIR).
+func Dir(repoName, wsName string) (string, error) {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(regPath), "logs", repoName, wsName), nil
+}
+
+// Path returns the current log file for a process. An empty name is the
+// session's unnamed process.
+func Path(dir, name string) string {
+	return filepath.Join(dir, logName(name)+".log")
+}
+
+// PreviousPath returns the log file kept from the process's previous run.
+func PreviousPath(dir, name string) string {
+	return filepath.Join(dir, logName(name)+".prev.log")
+}
+
+func logName(name string) string {
+	if name == "" {
+		return DefaultName
+	}
+	return name
+}
+
+// segmentPath returns the path of rotated segment n (1 is the newest).
+func segmentPath(path string, n int) string {
+	return path + "." + strconv.Itoa(n)
+}
+
+// StartRun prepares a process's log for a new run: the current log becomes
+// the previous-run log and older rotated segments are discarded.
+func StartRun(dir, name string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating log directory: %w", err)
+	}
+	path := Path(dir, name)
+	for n := 1; n <= Keep; n++ {
+		_ = os.Remove(segmentPath(path, n))
+	}
+	if err := os.Rename(path, PreviousPath(dir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotating log: %w", err)
+	}
+	return nil
+}
+
+// Names returns the process names that have a log (current or previous
+// run) in dir.
+func Names(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range matches {
+		base := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(m), ".log"), ".prev")
+		if !seen[base] {
+			seen[base] = true
+			names = append(names, base)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Exists reports whether a process has a current log, or a previous-run log
+// if previous is set.
+func Exists(dir, name string, previous bool) bool {
+	path := Path(dir, name)
+	if previous {
+		path = PreviousPath(dir, name)
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Move renames a workspace's log directory. A missing directory is not an error.
+func Move(oldDir, newDir string) error {
+	if _, err := os.Stat(oldDir); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(newDir), 0755); err != nil {
+		return fmt.Errorf("creating log directory: %w", err)
+	}
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return fmt.Errorf("moving logs: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes a workspace's log directory.
+func Remove(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("removing logs: %w", err)
+	}
+	return nil
+}
+
+// Writer appends timestamped lines to a log file, rotating it once it grows
+// past MaxSize. Carriage returns before newlines are dropped, so PTY output
+// is stored with plain LF line endings. Partial lines are buffered until a
+// newline arrives or the writer is closed.
+type Writer struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+	buf  []byte
+	now  func() time.Time
+}
+
+// Open opens path for appending.
+func Open(path string) (*Writer, error) {
+	w := &Writer{path: path, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	w.f, w.size = f, info.Size()
+	return nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(w.buf[:i], []byte{'\r'})
+		if err := w.writeLine(line); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Close flushes any partial line and closes the file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		_ = w.writeLine(bytes.TrimSuffix(w.buf, []byte{'\r'}))
+		w.buf = nil
+	}
+	return w.f.Close()
+}
+
+func (w *Writer) writeLine(line []byte) error {
+	entry := make([]byte, 0, len(timeLayout)+len(line)+2)
+	entry = w.now().AppendFormat(entry, timeLayout)
+	entry = append(entry, ' ')
+	entry = append(entry, line...)
+	entry = append(entry, '\n')
+
+	if w.size > 0 && w.size+int64(len(entry)) > MaxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.f.Write(entry)
+	w.size += int64(n)
+	return err
+}
+
+// rotate shifts <path>.N segments up by one and starts a fresh current file.
+func (w *Writer) rotate() error {
+	_ = w.f.Close()
+	_ = os.Remove(segmentPath(w.path, Keep))
+	for n := Keep - 1; n >= 1; n-- {
+		_ = os.Rename(segmentPath(w.path, n), segmentPath(w.path, n+1))
+	}
+	if Keep > 0 {
+		_ = os.Rename(w.path, segmentPath(w.path, 1))
+	} else {
+		_ = os.Remove(w.path)
+	}
+	return w.open()
+}
+
+// Options filters the lines returned by Read.
+type Options struct {
+	Lines    int            // return at most this many lines (the newest); 0 for all
+	Since    time.Time      // only lines written at or after this time
+	Grep     *regexp.Regexp // only lines matching this pattern
+	Previous bool           // read the previous run's log instead of the current one
+}
+
+// Read returns a process's logged output, oldest first, with timestamps
+// removed. Returns an error if no log exists.
+func Read(dir, name string, opts Options) (string, error) {
+	var files []string
+	if opts.Previous {
+		files = []string{PreviousPath(dir, name)}
+	} else {
+		path := Path(dir, name)
+		for n := Keep; n >= 1; n-- {
+			files = append(files, segmentPath(path, n))
+		}
+		files = append(files, path)
+	}
+
+	// Without filters only the end of the newest files needs to be read.
+	tailOnly := opts.Lines > 0 && opts.Since.IsZero() && opts.Grep == nil
+
+	var lines []string
+	found := false
+	for i := len(files) - 1; i >= 0; i-- {
+		var fl []string
+		var err error
+		if tailOnly {
+			fl, err = tailFile(files[i], opts.Lines-len(lines))
+		} else {
+			fl, err = readFile(files[i], opts)
+		}
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+		found = true
+		lines = append(fl, lines...)
+		if tailOnly && len(lines) >= opts.Lines {
+			break
+		}
+	}
+	if !found {
+		if opts.Previous {
+			return "", fmt.Errorf("no previous run logged for %q", logName(name))
+		}
+		return "", fmt.Errorf("no output logged for %q", logName(name))
+	}
+
+	if opts.Lines > 0 && len(lines) > opts.Lines {
+		lines = lines[len(lines)-opts.Lines:]
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func readFile(path string, opts Options) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var lines []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		ts, text := parseLine(sc.Text())
+		if !opts.Since.IsZero() && (ts.IsZero() || ts.Before(opts.Since)) {
+			continue
+		}
+		if opts.Grep != nil && !opts.Grep.MatchString(text) {
+			continue
+		}
+		lines = append(lines, text)
+	}
+	return lines, sc.Err()
+}
+
+// tailFile returns the last n lines of a file, reading backwards in chunks so
+// large logs are not loaded in full.
+func tailFile(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	const chunk = 32 * 1024
+	var buf []byte
+	off := info.Size()
+	for off > 0 && bytes.Count(buf, []byte{'\n'}) <= n {
+		size := int64(chunk)
+		if off < size {
+			size = off
+		}
+		off -= size
+		b := make([]byte, size)
+		if _, err := f.ReadAt(b, off); err != nil {
+			return nil, err
+		}
+		buf = append(b, buf...)
+	}
+
+	text := strings.TrimRight(string(buf), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	raw := strings.Split(text, "\n")
+	if len(raw) > n {
+		raw = raw[len(raw)-n:]
+	}
+	lines := make([]string, len(raw))
+	for i, l := range raw {
+		_, lines[i] = parseLine(l)
+	}
+	return lines, nil
+}
+
+// parseLine splits a logged line into its timestamp and text. Lines without
+// a timestamp (e.g. supervisor errors) return the zero time.
+func parseLine(line string) (time.Time, string) {
+	i := strings.IndexByte(line, ' ')
+	if i < 0 {
+		return time.Time{}, line
+	}
+	ts, err := time.Parse(timeLayout, line[:i])
+	if err != nil {
+		return time.Time{}, line
+	}
+	return ts, line[i+1:]
+}
+
+// ParseSince parses a --since value: a duration before now ("10m", "2h") or
+// an absolute time (RFC 3339, "2006-01-02 15:04" or "2006-01-02" in local time).
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use a duration like 10m or a time like 2006-01-02 15:04)", s)
+}
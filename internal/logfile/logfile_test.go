@@ -0,0 +1,230 @@
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openAt opens a writer whose clock returns the times in ts, in order.
+func openAt(t *testing.T, path string, ts ...time.Time) *Writer {
+	t.Helper()
+	w, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time {
+		now := ts[0]
+		if len(ts) > 1 {
+			ts = ts[1:]
+		}
+		return now
+	}
+	return w
+}
+
+func TestWriterTimestampsLines(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	w := openAt(t, Path(dir, ""), base, base.Add(time.Second), base.Add(2*time.Second))
+
+	for _, chunk := range []string{"hel", "lo\r\nwor", "ld\n", "partial"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2026-01-02T03:04:05.000Z hello\n" +
+		"2026-01-02T03:04:06.000Z world\n" +
+		"2026-01-02T03:04:07.000Z partial\n"
+	if string(data) != want {
+		t.Errorf("log =\n%s\nwant\n%s", data, want)
+	}
+
+	out, err := Read(dir, "", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "hello\nworld\npartial\n" {
+		t.Errorf("Read = %q", out)
+	}
+}
+
+func TestReadFilters(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	w := openAt(t, Path(dir, "web"), base, base.Add(time.Minute), base.Add(2*time.Minute), base.Add(3*time.Minute))
+	_, _ = w.Write([]byte("GET /a 200\nGET /b 500\nGET /c 200\nGET /d 500\n"))
+	_ = w.Close()
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"all", Options{}, "GET /a 200\nGET /b 500\nGET /c 200\nGET /d 500\n"},
+		{"lines", Options{Lines: 2}, "GET /c 200\nGET /d 500\n"},
+		{"since", Options{Since: base.Add(2 * time.Minute)}, "GET /c 200\nGET /d 500\n"},
+		{"grep", Options{Grep: regexp.MustCompile(`500$`)}, "GET /b 500\nGET /d 500\n"},
+		{"grep and lines", Options{Grep: regexp.MustCompile(`500$`), Lines: 1}, "GET /d 500\n"},
+		{"none match", Options{Grep: regexp.MustCompile(`404`)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(dir, "web", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Read = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Read(dir, "worker", Options{}); err == nil {
+		t.Error("expected error for a process with no log")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	oldMax, oldKeep := MaxSize, Keep
+	MaxSize, Keep = 100, 2
+	defer func() { MaxSize, Keep = oldMax, oldKeep }()
+
+	dir := t.TempDir()
+	w, err := Open(Path(dir, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each entry is 25 (timestamp) + 1 + 9 + 1 = 36 bytes, so two fit per file.
+	for i := 1; i <= 10; i++ {
+		_, _ = fmt.Fprintf(w, "line %04d\n", i)
+	}
+	_ = w.Close()
+
+	path := Path(dir, "")
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("expected %s to exist", filepath.Base(p))
+		}
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("only Keep segments should be kept")
+	}
+
+	out, _ := Read(dir, "", Options{})
+	if out != "line 0005\nline 0006\nline 0007\nline 0008\nline 0009\nline 0010\n" {
+		t.Errorf("Read across segments = %q", out)
+	}
+	out, _ = Read(dir, "", Options{Lines: 3})
+	if out != "line 0008\nline 0009\nline 0010\n" {
+		t.Errorf("tail across segments = %q", out)
+	}
+}
+
+func TestStartRunKeepsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	write := func(text string) {
+		w, err := Open(Path(dir, "web"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(text))
+		_ = w.Close()
+	}
+
+	if err := StartRun(dir, "web"); err != nil {
+		t.Fatal(err)
+	}
+	write("first run\n")
+	if err := StartRun(dir, "web"); err != nil {
+		t.Fatal(err)
+	}
+	write("second run\n")
+
+	if out, _ := Read(dir, "web", Options{}); out != "second run\n" {
+		t.Errorf("current = %q", out)
+	}
+	if out, _ := Read(dir, "web", Options{Previous: true}); out != "first run\n" {
+		t.Errorf("previous = %q", out)
+	}
+	if names := Names(dir); strings.Join(names, ",") != "web" {
+		t.Errorf("Names = %v", names)
+	}
+}
+
+func TestReadUntimestampedLines(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(Path(dir, ""), []byte("supervisor error\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := Read(dir, "", Options{}); out != "supervisor error\n" {
+		t.Errorf("Read = %q", out)
+	}
+	if out, _ := Read(dir, "", Options{Since: time.Now().Add(-time.Hour)}); out != "" {
+		t.Errorf("untimestamped lines should be excluded by --since, got %q", out)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"10m", now.Add(-10 * time.Minute)},
+		{"2h", now.Add(-2 * time.Hour)},
+		{"2026-05-01 09:30", time.Date(2026, 5, 1, 9, 30, 0, 0, time.Local)},
+		{"2026-04-30", time.Date(2026, 4, 30, 0, 0, 0, 0, time.Local)},
+		{"2026-05-01T10:00:00Z", time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseSince(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	if _, err := ParseSince("yesterday", now); err == nil {
+		t.Error("expected error for invalid value")
+	}
+}
+
+func TestMoveAndRemove(t *testing.T) {
+	root := t.TempDir()
+	oldDir := filepath.Join(root, "repo", "old")
+	newDir := filepath.Join(root, "repo", "new")
+
+	if err := Move(oldDir, newDir); err != nil {
+		t.Errorf("Move of missing dir: %v", err)
+	}
+	if err := StartRun(oldDir, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := Move(oldDir, newDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(newDir); err != nil {
+		t.Errorf("new dir should exist: %v", err)
+	}
+	if err := Remove(newDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(newDir); !os.IsNotExist(err) {
+		t.Error("dir should be removed")
+	}
+}
@@ -0,0 +1,256 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+// DefaultPort is the port fr8 proxy serve listens on unless overridden.
+const DefaultPort = 7800
+
+// Route maps a workspace hostname to its dev server port.
+type Route struct {
+	Host      string `json:"host"`
+	Repo      string `json:"repo"`
+	Workspace string `json:"workspace"`
+	Port      int    `json:"port"`
+	// Conflict names the workspace whose hostname this one would have
+	// shared; the route then gets a numbered hostname instead.
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// Hostname returns the proxy hostname for a workspace:
+// <workspace>.<repo>.localhost, with each part reduced to a valid DNS label.
+func Hostname(repoName, wsName string) string {
+	return label(wsName) + "." + label(repoName) + ".localhost"
+}
+
+// label lowercases s and replaces anything that isn't a letter, digit or
+// hyphen with a hyphen, so it can be used as a DNS label.
+func label(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// Routes builds the hostname routing table for every workspace in the registry.
+// When names reduce to the same hostname (foo_bar and foo-bar), the one that
+// is already a valid hostname keeps it, otherwise the first in the registry,
+// and the others get a numbered one such as foo-bar-2.<repo>.localhost.
+func Routes(reg *registry.Registry) map[string]Route {
+	routes := make(map[string]Route)
+	// Names that are already DNS labels first, so they keep their hostname
+	for _, exact := range []bool{true, false} {
+		for _, repo := range reg.Repos {
+			for _, ws := range repo.Workspaces {
+				if (label(ws.Name) == ws.Name && label(repo.Name) == repo.Name) != exact {
+					continue
+				}
+				route := Route{Host: Hostname(repo.Name, ws.Name), Repo: repo.Name, Workspace: ws.Name, Port: ws.Port}
+				if taken, ok := routes[route.Host]; ok {
+					route.Conflict = taken.Repo + "/" + taken.Workspace
+					for n := 2; ok; n++ {
+						route.Host = Hostname(repo.Name, fmt.Sprintf("%s-%d", ws.Name, n))
+						_, ok = routes[route.Host]
+					}
+				}
+				routes[route.Host] = route
+			}
+		}
+	}
+	return routes
+}
+
+// HostFor returns the hostname routes assigns to a workspace, which differs
+// from Hostname when its name collides with another workspace's.
+func HostFor(routes map[string]Route, repoName, wsName string) string {
+	for _, r := range routes {
+		if r.Repo == repoName && r.Workspace == wsName {
+			return r.Host
+		}
+	}
+	return Hostname(repoName, wsName)
+}
+
+// Server is an HTTP reverse proxy that routes <workspace>.<repo>.localhost
+// to the workspace's port. The registry is re-read whenever its file changes.
+type Server struct {
+	regPath    string
+	listenPort int
+	proxy      *httputil.ReverseProxy
+
+	mu      sync.RWMutex
+	routes  map[string]Route
+	modTime time.Time
+	loadErr error
+}
+
+type routeKey struct{}
+
+// NewServer returns a proxy server backed by the registry at regPath.
+// listenPort is used to build links on the index and error pages.
+func NewServer(regPath string, listenPort int) *Server {
+	s := &Server{regPath: regPath, listenPort: listenPort}
+	s.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			route := pr.In.Context().Value(routeKey{}).(Route)
+			pr.SetURL(&url.URL{Scheme: "http", Host: fmt.Sprintf("localhost:%d", route.Port)})
+			pr.SetXForwarded()
+			// Keep the workspace hostname so dev servers can build absolute URLs.
+			pr.Out.Host = pr.In.Host
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			route, _ := r.Context().Value(routeKey{}).(Route)
+			s.writePage(w, http.StatusBadGateway,
+				fmt.Sprintf("%s/%s is not responding on port %d", route.Repo, route.Workspace, route.Port),
+				"Start it with: fr8 ws run "+route.Workspace)
+		},
+	}
+	s.reload()
+	return s
+}
+
+// reload re-reads the registry if its modification time has changed.
+// On a read error the previous routes are kept.
+func (s *Server) reload() {
+	info, err := os.Stat(s.regPath)
+	var modTime time.Time
+	if err == nil {
+		modTime = info.ModTime()
+	}
+
+	s.mu.RLock()
+	unchanged := s.routes != nil && modTime.Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	reg, err := registry.Load(s.regPath)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadErr = err
+	if err != nil {
+		return
+	}
+	s.routes = Routes(reg)
+	s.modTime = modTime
+}
+
+// Lookup returns the route for a request host (an optional port is ignored).
+func (s *Server) Lookup(host string) (Route, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	route, ok := s.routes[host]
+	return route, ok
+}
+
+// ServeHTTP proxies requests for known workspace hosts and serves an index
+// of workspaces for everything else.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.reload()
+
+	route, ok := s.Lookup(r.Host)
+	if !ok {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "localhost" || host == "127.0.0.1" || host == "::1" {
+			s.writePage(w, http.StatusOK, "fr8 workspaces", "")
+			return
+		}
+		s.writePage(w, http.StatusNotFound, fmt.Sprintf("No workspace matches %q", host),
+			"Workspace hostnames have the form <workspace>.<repo>.localhost")
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), routeKey{}, route)
+	s.proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+var pageTmpl = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; margin: 2rem; color: #222; }
+h1 { font-size: 1.3rem; }
+.hint { color: #666; }
+table { border-collapse: collapse; margin-top: 1rem; }
+td, th { text-align: left; padding: 0.25rem 1rem 0.25rem 0; }
+th { color: #666; font-weight: normal; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Hint}}<p class="hint">{{.Hint}}</p>{{end}}
+{{if .Error}}<p class="hint">Registry error: {{.Error}}</p>{{end}}
+{{if .Routes}}
+<table>
+<tr><th>Workspace</th><th>Repo</th><th>Port</th><th>URL</th><th></th></tr>
+{{range .Routes}}<tr><td>{{.Workspace}}</td><td>{{.Repo}}</td><td>{{.Port}}</td><td><a href="{{$.URL .Host}}">{{$.URL .Host}}</a></td><td class="hint">{{if .Conflict}}renamed: hostname collides with {{.Conflict}}{{end}}</td></tr>
+{{end}}</table>
+{{else}}
+<p class="hint">No workspaces registered. Create one with: fr8 ws new</p>
+{{end}}
+</body>
+</html>
+`))
+
+type pageData struct {
+	Title  string
+	Hint   string
+	Error  error
+	Routes []Route
+	port   int
+}
+
+// URL builds the proxied URL for a host on the page.
+func (p pageData) URL(host string) string {
+	return fmt.Sprintf("http://%s:%d/", host, p.port)
+}
+
+// writePage renders the workspace index with a title and hint.
+func (s *Server) writePage(w http.ResponseWriter, status int, title, hint string) {
+	s.mu.RLock()
+	routes := make([]Route, 0, len(s.routes))
+	for _, r := range s.routes {
+		routes = append(routes, r)
+	}
+	loadErr := s.loadErr
+	s.mu.RUnlock()
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Repo != routes[j].Repo {
+			return routes[i].Repo < routes[j].Repo
+		}
+		return routes[i].Workspace < routes[j].Workspace
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = pageTmpl.Execute(w, pageData{Title: title, Hint: hint, Error: loadErr, Routes: routes, port: s.listenPort})
+}
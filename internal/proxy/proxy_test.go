@@ -0,0 +1,218 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+func TestHostname(t *testing.T) {
+	tests := []struct {
+		repo, ws, want string
+	}{
+		{"myapp", "bright-berlin", "bright-berlin.myapp.localhost"},
+		{"My_App", "Feature.X", "feature-x.my-app.localhost"},
+		{"repo", "-edge-", "edge.repo.localhost"},
+	}
+	for _, tt := range tests {
+		if got := Hostname(tt.repo, tt.ws); got != tt.want {
+			t.Errorf("Hostname(%q, %q) = %q, want %q", tt.repo, tt.ws, got, tt.want)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	reg := &registry.Registry{Repos: []registry.Repo{
+		{Name: "myapp", Path: "/tmp/myapp", Workspaces: []registry.Workspace{
+			{Name: "alpha", Port: 5000},
+			{Name: "beta", Port: 5010},
+		}},
+	}}
+
+	routes := Routes(reg)
+	if len(routes) != 2 {
+		t.Fatalf("len(routes) = %d, want 2", len(routes))
+	}
+	r := routes["beta.myapp.localhost"]
+	if r.Port != 5010 || r.Repo != "myapp" || r.Workspace != "beta" {
+		t.Errorf("route = %+v", r)
+	}
+}
+
+func TestRoutesCollision(t *testing.T) {
+	reg := &registry.Registry{Repos: []registry.Repo{
+		{Name: "myapp", Path: "/tmp/myapp", Workspaces: []registry.Workspace{
+			{Name: "foo_bar", Port: 5000},
+			{Name: "foo-bar", Port: 5010},
+			{Name: "Foo.Bar", Port: 5020},
+		}},
+	}}
+
+	routes := Routes(reg)
+	if len(routes) != 3 {
+		t.Fatalf("len(routes) = %d, want 3", len(routes))
+	}
+	if r := routes["foo-bar.myapp.localhost"]; r.Workspace != "foo-bar" || r.Conflict != "" {
+		t.Errorf("plain hostname route = %+v, want foo-bar without conflict", r)
+	}
+	if r := routes["foo-bar-2.myapp.localhost"]; r.Workspace != "foo_bar" || r.Conflict != "myapp/foo-bar" {
+		t.Errorf("second route = %+v, want foo_bar conflicting with myapp/foo-bar", r)
+	}
+	if got := HostFor(routes, "myapp", "Foo.Bar"); got != "foo-bar-3.myapp.localhost" {
+		t.Errorf("HostFor(Foo.Bar) = %q, want foo-bar-3.myapp.localhost", got)
+	}
+}
+
+// writeRegistry saves a registry with one workspace pointing at port.
+func writeRegistry(t *testing.T, path, wsName string, port int) {
+	t.Helper()
+	reg := &registry.Registry{Repos: []registry.Repo{
+		{Name: "myapp", Path: "/tmp/myapp", Workspaces: []registry.Workspace{{Name: wsName, Port: port}}},
+	}}
+	if err := reg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, s *Server, host string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "http://"+host+"/hello", nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	resp := rec.Result()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestServerProxiesToWorkspace(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "host="+r.Host+" path="+r.URL.Path)
+	}))
+	defer backend.Close()
+
+	regPath := filepath.Join(t.TempDir(), "repos.json")
+	writeRegistry(t, regPath, "alpha", backend.Listener.Addr().(*net.TCPAddr).Port)
+
+	s := NewServer(regPath, DefaultPort)
+	resp, body := get(t, s, "alpha.myapp.localhost:7800")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body: %s)", resp.StatusCode, body)
+	}
+	if body != "host=alpha.myapp.localhost:7800 path=/hello" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestServerBackendDownReturns502(t *testing.T) {
+	// Grab a free port and close it so nothing is listening.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	regPath := filepath.Join(t.TempDir(), "repos.json")
+	writeRegistry(t, regPath, "alpha", port)
+
+	s := NewServer(regPath, DefaultPort)
+	resp, body := get(t, s, "alpha.myapp.localhost")
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", resp.StatusCode)
+	}
+	if !strings.Contains(body, "alpha.myapp.localhost") {
+		t.Error("502 page should list known workspaces")
+	}
+}
+
+func TestServerUnknownHost(t *testing.T) {
+	regPath := filepath.Join(t.TempDir(), "repos.json")
+	writeRegistry(t, regPath, "alpha", 5000)
+
+	s := NewServer(regPath, DefaultPort)
+	resp, body := get(t, s, "nope.myapp.localhost")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+	if !strings.Contains(body, "alpha") {
+		t.Error("404 page should list known workspaces")
+	}
+
+	resp, _ = get(t, s, "localhost:7800")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("index status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestServerReloadsRegistry(t *testing.T) {
+	regPath := filepath.Join(t.TempDir(), "repos.json")
+	writeRegistry(t, regPath, "alpha", 5000)
+
+	s := NewServer(regPath, DefaultPort)
+	if _, ok := s.Lookup("alpha.myapp.localhost"); !ok {
+		t.Fatal("alpha should be routed")
+	}
+
+	writeRegistry(t, regPath, "beta", 5010)
+	// Ensure the modification time differs on coarse-grained filesystems.
+	future := time.Now().Add(2 * time.Second)
+	if err := os.Chtimes(regPath, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	s.reload()
+	if _, ok := s.Lookup("alpha.myapp.localhost"); ok {
+		t.Error("alpha should no longer be routed after reload")
+	}
+	if r, ok := s.Lookup("BETA.myapp.localhost:7800"); !ok || r.Port != 5010 {
+		t.Errorf("beta route = %+v, %v", r, ok)
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+
+	path, err := StatePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Running() != nil {
+		t.Fatal("no proxy should be running without a state file")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	if err := WriteState(path, State{PID: os.Getpid(), Port: port, StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	st := Running()
+	if st == nil || st.Port != port {
+		t.Fatalf("Running() = %+v, want port %d", st, port)
+	}
+
+	ws := &registry.Workspace{Name: "alpha", Port: 5000}
+	want := "http://alpha.myapp.localhost:" + strings.TrimPrefix(ln.Addr().String(), "127.0.0.1:")
+	if got := BrowserURL("myapp", ws); got != want {
+		t.Errorf("BrowserURL = %q, want %q", got, want)
+	}
+
+	if err := RemoveState(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := BrowserURL("myapp", ws); got != "http://localhost:5000" {
+		t.Errorf("BrowserURL without proxy = %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/protocollar/fr8/internal/port"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+// State records a running fr8 proxy so other commands can link to it.
+type State struct {
+	PID       int       `json:"pid"`
+	Port      int       `json:"port"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// StatePath returns the path of the proxy state file, stored next to the
+// global registry (respects FR8_STATE_DIR).
+func StatePath() (string, error) {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(regPath), "proxy.json"), nil
+}
+
+// WriteState records the running proxy at path.
+func WriteState(path string, st State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing proxy state: %w", err)
+	}
+	return nil
+}
+
+// RemoveState deletes the proxy state file. Missing files are not an error.
+func RemoveState(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing proxy state: %w", err)
+	}
+	return nil
+}
+
+// Running returns the state of the running proxy, or nil if none is running.
+// A proxy is considered running when its process is alive and its port is
+// accepting connections.
+func Running() *State {
+	path, err := StatePath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil || st.PID <= 0 || st.Port <= 0 {
+		return nil
+	}
+	if !processAlive(st.PID) {
+		return nil
+	}
+	if port.IsFree(st.Port) {
+		return nil
+	}
+	return &st
+}
+
+// BrowserURL returns the URL to open for a workspace: its proxy hostname when
+// fr8 proxy serve is running, otherwise http://localhost:<port>.
+func BrowserURL(repoName string, ws *registry.Workspace) string {
+	if st := Running(); st != nil {
+		host := Hostname(repoName, ws.Name)
+		if regPath, err := registry.DefaultPath(); err == nil {
+			if reg, err := registry.Load(regPath); err == nil {
+				host = HostFor(Routes(reg), repoName, ws.Name)
+			}
+		}
+		return fmt.Sprintf("http://%s:%d", host, st.Port)
+	}
+	return fmt.Sprintf("http://localhost:%d", ws.Port)
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/protocollar/fr8/internal/flock"
 )
@@ -17,13 +19,119 @@ type Workspace struct {
 	Path      string    `json:"path"`
 	Port      int       `json:"port"`
 	CreatedAt time.Time `json:"created_at"`
+	Origin    *Origin   `json:"origin,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Labels    []string  `json:"labels,omitempty"`
+	Pinned    bool      `json:"pinned,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	EnvFile   string    `json:"env_file,omitempty"` // dotenv file written by fr8 ws env --write, relative to Path
+}
+
+// Expiry returns when fr8 ws gc may archive the workspace: ExpiresAt if it
+// was created with a TTL, otherwise CreatedAt plus defaultTTL (the ttl in
+// fr8.json). It returns the zero time if the workspace never expires.
+func (w *Workspace) Expiry(defaultTTL time.Duration) time.Time {
+	switch {
+	case !w.ExpiresAt.IsZero():
+		return w.ExpiresAt
+	case defaultTTL > 0 && !w.CreatedAt.IsZero():
+		return w.CreatedAt.Add(defaultTTL)
+	}
+	return time.Time{}
+}
+
+// HasLabels reports whether the workspace has every one of labels.
+func (w *Workspace) HasLabels(labels []string) bool {
+	for _, l := range labels {
+		if !slices.Contains(w.Labels, l) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddLabel adds label to the workspace, keeping labels sorted. It reports
+// whether the label was added (false if it was already there).
+func (w *Workspace) AddLabel(label string) bool {
+	i, found := slices.BinarySearch(w.Labels, label)
+	if found {
+		return false
+	}
+	w.Labels = slices.Insert(w.Labels, i, label)
+	return true
+}
+
+// RemoveLabel removes label from the workspace. It reports whether the
+// label was removed (false if the workspace didn't have it).
+func (w *Workspace) RemoveLabel(label string) bool {
+	i := slices.Index(w.Labels, label)
+	if i < 0 {
+		return false
+	}
+	w.Labels = slices.Delete(w.Labels, i, i+1)
+	if len(w.Labels) == 0 {
+		w.Labels = nil
+	}
+	return true
+}
+
+// ValidateLabel checks that label is usable as a workspace label: non-empty
+// and without whitespace or commas.
+func ValidateLabel(label string) error {
+	if label == "" {
+		return fmt.Errorf("label must not be empty")
+	}
+	if strings.ContainsFunc(label, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) {
+		return fmt.Errorf("invalid label %q: must not contain whitespace or commas", label)
+	}
+	return nil
+}
+
+// Workspace sources recorded in Origin.Source.
+const (
+	SourceNew     = "new"     // a new branch created for the workspace
+	SourceBranch  = "branch"  // an existing local branch
+	SourceRemote  = "remote"  // a local branch tracking an existing remote branch
+	SourcePR      = "pr"      // the head branch of a GitHub pull request
+	SourceAdopted = "adopted" // an existing worktree registered with fr8 ws adopt
+)
+
+// Origin records how a workspace was created. It is nil for workspaces
+// created before fr8 recorded it.
+type Origin struct {
+	Branch     string `json:"branch"`
+	BaseCommit string `json:"base_commit,omitempty"`
+	Source     string `json:"source"`
+	PR         int    `json:"pr,omitempty"`
+	CreatedBy  string `json:"created_by,omitempty"`
+	ConfigHash string `json:"config_hash,omitempty"`
+}
+
+// BranchSwitched reports whether current, the branch checked out in the
+// workspace, differs from the branch it was created on. It is false when
+// the origin or current branch is unknown.
+func (w *Workspace) BranchSwitched(current string) bool {
+	return w.Origin != nil && w.Origin.Branch != "" && current != "" && current != w.Origin.Branch
+}
+
+// ArchivedWorkspace is a workspace removed with fr8 ws archive --soft. Its
+// uncommitted changes are kept in the Snapshot directory so that fr8 ws
+// restore can bring it back.
+type ArchivedWorkspace struct {
+	Workspace
+	Branch     string    `json:"branch"`
+	HeadCommit string    `json:"head_commit,omitempty"`
+	Dirty      bool      `json:"dirty"`
+	ArchivedAt time.Time `json:"archived_at"`
+	Snapshot   string    `json:"snapshot"`
 }
 
 // Repo is a registered repository.
 type Repo struct {
-	Name       string      `json:"name"`
-	Path       string      `json:"path"`
-	Workspaces []Workspace `json:"workspaces,omitempty"`
+	Name       string              `json:"name"`
+	Path       string              `json:"path"`
+	Workspaces []Workspace         `json:"workspaces,omitempty"`
+	Archived   []ArchivedWorkspace `json:"archived,omitempty"`
 }
 
 // Registry holds all registered repositories.
@@ -74,31 +182,96 @@ func Load(path string) (*Registry, error) {
 }
 
 // Save writes the registry to path.
-// Uses advisory file locking to prevent concurrent modifications.
+// Uses advisory file locking to prevent concurrent modifications. Callers that
+// read, modify, and write the registry should use Update instead so the lock
+// covers the whole cycle.
 func (r *Registry) Save(path string) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return r.write(path)
+}
+
+// Update loads the registry at path, applies fn, and saves the result while
+// holding an exclusive lock for the entire load-modify-save cycle. If fn
+// returns an error, nothing is written and the error is returned as-is.
+func Update(path string, fn func(*Registry) error) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(r); err != nil {
+		return err
+	}
+	return r.write(path)
+}
+
+// lock acquires an exclusive advisory lock on path's sibling lock file and
+// returns a function that releases it. The lock file is left in place so that
+// every process contends on the same inode.
+func lock(path string) (func(), error) {
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return fmt.Errorf("creating registry directory: %w", err)
+		return nil, fmt.Errorf("creating registry directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("creating lock file: %w", err)
+	}
+	if err := flock.Lock(f.Fd()); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("acquiring lock: %w", err)
 	}
+	return func() {
+		_ = flock.Unlock(f.Fd())
+		_ = f.Close()
+	}, nil
+}
 
+// write atomically replaces the file at path with the marshaled registry by
+// writing to a temporary file in the same directory and renaming it over path.
+// The caller must hold the lock.
+func (r *Registry) write(path string) error {
 	data, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling registry: %w", err)
 	}
 	data = append(data, '\n')
 
-	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
 	if err != nil {
-		return fmt.Errorf("creating lock file: %w", err)
+		return fmt.Errorf("creating temp file: %w", err)
 	}
-	defer func() { _ = f.Close() }()
-	defer func() { _ = os.Remove(path + ".lock") }()
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
 
-	if err := flock.Lock(f.Fd()); err != nil {
-		return fmt.Errorf("acquiring lock: %w", err)
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing registry: %w", err)
 	}
-	defer func() { _ = flock.Unlock(f.Fd()) }()
-
-	return os.WriteFile(path, data, 0644)
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("syncing registry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing registry: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return fmt.Errorf("setting registry permissions: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("replacing registry: %w", err)
+	}
+	return nil
 }
 
 // Add appends a repo to the registry. Returns an error if the name already exists.
@@ -213,6 +386,44 @@ func (r *Repo) RenameWorkspace(oldName, newName string) error {
 	return nil
 }
 
+// FindArchived returns the archived workspace with the given name, or nil.
+func (r *Repo) FindArchived(name string) *ArchivedWorkspace {
+	for i := range r.Archived {
+		if r.Archived[i].Name == name {
+			return &r.Archived[i]
+		}
+	}
+	return nil
+}
+
+// AddArchived records an archived workspace. Returns an error if one with
+// the same name is already archived, so its saved changes are never
+// replaced.
+func (r *Repo) AddArchived(a ArchivedWorkspace) error {
+	if r.FindArchived(a.Name) != nil {
+		return ArchivedExistsError(a.Name)
+	}
+	r.Archived = append(r.Archived, a)
+	return nil
+}
+
+// ArchivedExistsError returns the error for soft-archiving a workspace whose
+// name is already taken by an archived one.
+func ArchivedExistsError(name string) error {
+	return fmt.Errorf("an archived workspace named %q already exists (restore it with: fr8 ws restore %s, or delete it with: fr8 ws archived purge %s)", name, name, name)
+}
+
+// RemoveArchived removes an archived workspace by name.
+func (r *Repo) RemoveArchived(name string) error {
+	for i, a := range r.Archived {
+		if a.Name == name {
+			r.Archived = append(r.Archived[:i], r.Archived[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("archived workspace %q not found (see available: fr8 ws archived list)", name)
+}
+
 // WorkspaceNames returns all workspace names in this repo.
 func (r *Repo) WorkspaceNames() []string {
 	names := make([]string, len(r.Workspaces))
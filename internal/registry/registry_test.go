@@ -1,8 +1,12 @@
 package registry
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 )
@@ -242,6 +246,37 @@ func TestWorkspaceCRUD(t *testing.T) {
 	}
 }
 
+func TestArchivedCRUD(t *testing.T) {
+	repo := &Repo{Name: "myapp", Path: "/home/user/myapp"}
+
+	first := ArchivedWorkspace{Workspace: Workspace{Name: "feature-1", Port: 5000}, Snapshot: "/state/archive/a"}
+	if err := repo.AddArchived(first); err != nil {
+		t.Fatalf("AddArchived: %v", err)
+	}
+	found := repo.FindArchived("feature-1")
+	if found == nil || found.Port != 5000 {
+		t.Fatalf("FindArchived() = %+v, want feature-1 on port 5000", found)
+	}
+
+	second := ArchivedWorkspace{Workspace: Workspace{Name: "feature-1", Port: 5010}, Snapshot: "/state/archive/b"}
+	if err := repo.AddArchived(second); err == nil {
+		t.Error("expected error archiving a second workspace with the same name")
+	}
+	if len(repo.Archived) != 1 || repo.FindArchived("feature-1").Snapshot != first.Snapshot {
+		t.Errorf("Archived = %+v, want only the first archive", repo.Archived)
+	}
+
+	if err := repo.RemoveArchived("feature-1"); err != nil {
+		t.Fatalf("RemoveArchived: %v", err)
+	}
+	if repo.FindArchived("feature-1") != nil {
+		t.Error("expected archived workspace to be removed")
+	}
+	if err := repo.RemoveArchived("feature-1"); err == nil {
+		t.Error("expected error for nonexistent archived workspace")
+	}
+}
+
 func TestWorkspaceRename(t *testing.T) {
 	repo := &Repo{Name: "myapp", Path: "/home/user/myapp"}
 	if err := repo.AddWorkspace(Workspace{Name: "alpha"}); err != nil {
@@ -448,3 +483,242 @@ func TestSaveAndLoadWithWorkspaces(t *testing.T) {
 		t.Errorf("workspace = %+v, want ws1/5000", ws)
 	}
 }
+
+func TestSaveAndLoadOrigin(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "repos.json")
+
+	origin := Origin{
+		Branch:     "feature/auth",
+		BaseCommit: "0123456789abcdef0123456789abcdef01234567",
+		Source:     SourcePR,
+		PR:         42,
+		CreatedBy:  "fr8 ws new -p 42",
+		ConfigHash: "a1b2c3d4e5f6",
+	}
+	r := &Registry{
+		Repos: []Repo{
+			{
+				Name: "myapp",
+				Path: "/home/user/myapp",
+				Workspaces: []Workspace{
+					{Name: "ws1", Path: "/tmp/ws1", Port: 5000, Origin: &origin},
+					{Name: "ws2", Path: "/tmp/ws2", Port: 5010},
+				},
+			},
+		},
+	}
+	if err := r.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	ws := loaded.Repos[0].Workspaces
+	if ws[0].Origin == nil || *ws[0].Origin != origin {
+		t.Errorf("origin = %+v, want %+v", ws[0].Origin, origin)
+	}
+	if ws[1].Origin != nil {
+		t.Errorf("origin = %+v, want nil for a workspace without one", ws[1].Origin)
+	}
+}
+
+func TestBranchSwitched(t *testing.T) {
+	ws := Workspace{Name: "ws1", Origin: &Origin{Branch: "feature/auth"}}
+	tests := []struct {
+		current string
+		want    bool
+	}{
+		{"feature/auth", false},
+		{"main", true},
+		{"HEAD", true},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ws.BranchSwitched(tt.current); got != tt.want {
+			t.Errorf("BranchSwitched(%q) = %v, want %v", tt.current, got, tt.want)
+		}
+	}
+
+	legacy := Workspace{Name: "ws2"}
+	if legacy.BranchSwitched("main") {
+		t.Error("BranchSwitched without an origin = true, want false")
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	ws := Workspace{Name: "ws1", CreatedAt: created}
+	if got := ws.Expiry(0); !got.IsZero() {
+		t.Errorf("Expiry(0) = %v, want zero", got)
+	}
+	if got, want := ws.Expiry(7*day), created.Add(7*day); !got.Equal(want) {
+		t.Errorf("Expiry(7d) = %v, want %v", got, want)
+	}
+
+	ws.ExpiresAt = created.Add(day)
+	if got := ws.Expiry(7 * day); !got.Equal(ws.ExpiresAt) {
+		t.Errorf("Expiry with ExpiresAt = %v, want %v", got, ws.ExpiresAt)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "repos.json")
+
+	err := Update(path, func(r *Registry) error {
+		return r.Add(Repo{Name: "myapp", Path: "/home/user/myapp"})
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	err = Update(path, func(r *Registry) error {
+		repo := r.Find("myapp")
+		if repo == nil {
+			t.Fatal("expected myapp to be loaded inside Update")
+		}
+		return repo.AddWorkspace(Workspace{Name: "ws1", Path: "/tmp/ws1", Port: 60000})
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if repo := loaded.Find("myapp"); repo == nil || repo.FindWorkspace("ws1") == nil {
+		t.Fatalf("expected myapp/ws1 after Update, got %+v", loaded.Repos)
+	}
+}
+
+func TestUpdateErrorDoesNotSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "repos.json")
+
+	r := &Registry{Repos: []Repo{{Name: "myapp", Path: "/home/user/myapp"}}}
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	sentinel := errors.New("abort")
+	err := Update(path, func(r *Registry) error {
+		r.Repos = nil
+		return sentinel
+	})
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("Update error = %v, want %v", err, sentinel)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Repos) != 1 {
+		t.Errorf("expected registry to be unchanged, got %d repos", len(loaded.Repos))
+	}
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "repos.json")
+
+	r := &Registry{Repos: []Repo{{Name: "myapp", Path: "/home/user/myapp"}}}
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := Update(path, func(r *Registry) error {
+				repo := r.Find("myapp")
+				return repo.AddWorkspace(Workspace{
+					Name: fmt.Sprintf("ws%d", i),
+					Path: fmt.Sprintf("/tmp/ws%d", i),
+					Port: 60000 + i*10,
+				})
+			})
+			if err != nil {
+				t.Errorf("Update %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(loaded.Find("myapp").Workspaces); got != n {
+		t.Errorf("expected %d workspaces after concurrent updates, got %d", n, got)
+	}
+}
+
+func TestSaveLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "repos.json")
+
+	r := &Registry{Repos: []Repo{{Name: "myapp", Path: "/home/user/myapp"}}}
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "repos.json" && e.Name() != "repos.json.lock" {
+			t.Errorf("unexpected file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestWorkspaceLabels(t *testing.T) {
+	var ws Workspace
+	if !ws.AddLabel("review") || !ws.AddLabel("api") {
+		t.Fatal("AddLabel returned false for a new label")
+	}
+	if ws.AddLabel("review") {
+		t.Error("AddLabel returned true for an existing label")
+	}
+	if want := []string{"api", "review"}; !slices.Equal(ws.Labels, want) {
+		t.Errorf("Labels = %v, want %v", ws.Labels, want)
+	}
+
+	if !ws.HasLabels([]string{"review", "api"}) || !ws.HasLabels(nil) {
+		t.Error("HasLabels = false, want true")
+	}
+	if ws.HasLabels([]string{"review", "blocked"}) {
+		t.Error("HasLabels with a missing label = true, want false")
+	}
+
+	if !ws.RemoveLabel("api") || ws.RemoveLabel("api") {
+		t.Error("RemoveLabel should remove a label exactly once")
+	}
+	if !ws.RemoveLabel("review") || ws.Labels != nil {
+		t.Errorf("Labels = %#v after removing the last label, want nil", ws.Labels)
+	}
+}
+
+func TestValidateLabel(t *testing.T) {
+	for _, label := range []string{"review", "needs-qa", "team/api"} {
+		if err := ValidateLabel(label); err != nil {
+			t.Errorf("ValidateLabel(%q) = %v, want nil", label, err)
+		}
+	}
+	for _, label := range []string{"", "two words", "a,b", "tab\there"} {
+		if err := ValidateLabel(label); err == nil {
+			t.Errorf("ValidateLabel(%q) = nil, want error", label)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/protocollar/fr8/internal/logfile"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+// SuperviseCommand is the hidden fr8 subcommand that runs a native process
+// supervisor. The native runner re-executes the fr8 binary with it.
+const SuperviseCommand = "__supervise"
+
+// defaultProcess is the file name used for a session's unnamed process.
+const defaultProcess = logfile.DefaultName
+
+// stopTimeout is how long Stop waits after SIGTERM before sending SIGKILL.
+var stopTimeout = 5 * time.Second
+
+// startTimeout is how long Start waits for a supervisor to launch its process.
+var startTimeout = 5 * time.Second
+
+// Native supervises processes directly, without tmux. Each process runs under
+// a PTY in its own process group, managed by a detached fr8 supervisor that
+// writes <name>.pid under <state dir>/run/<repo>/<workspace>/ and the
+// process's output to its logfile.
+type Native struct{}
+
+func (Native) Name() string { return NativeBackend }
+
+func (Native) Available() error {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		return fmt.Errorf("the native runner is not supported on %s", runtime.GOOS)
+	}
+	return nil
+}
+
+// RunDir returns the directory holding native runner pidfiles
+// (respects FR8_STATE_DIR).
+func RunDir() (string, error) {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(regPath), "run"), nil
+}
+
+// sessionDir returns the pidfile directory for a session.
+func sessionDir(session string) (string, error) {
+	s, ok := parseSession(session)
+	if !ok {
+		return "", fmt.Errorf("invalid session name %q", session)
+	}
+	dir, err := RunDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, s.Repo, s.Workspace), nil
+}
+
+// processFile maps a process name to its file name prefix.
+func processFile(name string) string {
+	if name == "" {
+		return defaultProcess
+	}
+	return name
+}
+
+func (n Native) Start(session, dir string, procs []Process, envVars []string) error {
+	if len(procs) == 0 {
+		return fmt.Errorf("no processes to start")
+	}
+	if n.IsRunning(session) {
+		return fmt.Errorf("session %q is already running (use fr8 ws logs to view output)", session)
+	}
+	if err := markStarted(session); err != nil {
+		return err
+	}
+	for _, p := range procs {
+		if err := n.launch(session, dir, p, envVars); err != nil {
+			_ = n.Stop(session)
+			return err
+		}
+	}
+	return nil
+}
+
+func (n Native) StartProcess(session, dir string, p Process, envVars []string) error {
+	if n.HasProcess(session, p.Name) {
+		return fmt.Errorf("service %q is already running in %q", p.Name, session)
+	}
+	if err := markStarted(session); err != nil {
+		return err
+	}
+	return n.launch(session, dir, p, envVars)
+}
+
+// launch starts a detached supervisor for p and waits until it has written
+// the process's pidfile.
+func (Native) launch(session, dir string, p Process, envVars []string) error {
+	sdir, err := sessionDir(session)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(sdir, 0755); err != nil {
+		return fmt.Errorf("creating run directory: %w", err)
+	}
+
+	logDir, err := LogDir(session)
+	if err != nil {
+		return err
+	}
+	name := processFile(p.Name)
+	if err := logfile.StartRun(logDir, name); err != nil {
+		return err
+	}
+	logPath := logfile.Path(logDir, name)
+
+	// Supervisor errors are appended to the process's log.
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	defer func() { _ = logFile.Close() }()
+
+	exitPath := filepath.Join(sdir, name+".exit")
+	clearExit(sdir, name)
+	_ = os.Remove(filepath.Join(sdir, name+".stop"))
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding fr8 executable: %w", err)
+	}
+
+	cmd := exec.Command(self, SuperviseCommand, sdir, name, dir, logPath, restartPolicy(p), p.Command)
+	cmd.Env = append(append(os.Environ(), envVars...), p.Env...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = detached()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting supervisor: %w", err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	pidPath := filepath.Join(sdir, name+".pid")
+	deadline := time.After(startTimeout)
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		if _, err := os.Stat(pidPath); err == nil {
+			return nil
+		}
+		select {
+		case <-exited:
+			// A process that started and exited quickly leaves an exit file
+			// behind; that is not a launch failure.
+			if _, err := os.Stat(exitPath); err == nil {
+				return nil
+			}
+			out, _ := logfile.Read(logDir, name, logfile.Options{Lines: 5})
+			return fmt.Errorf("starting %q: supervisor exited\n%s", name, strings.TrimSpace(out))
+		case <-deadline:
+			return fmt.Errorf("starting %q: timed out waiting for process to start", name)
+		case <-tick.C:
+		}
+	}
+}
+
+// readPID returns the pid recorded in a pidfile if that process is alive.
+func readPID(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, alive(pid)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place,
+// so readers never see a partial pidfile.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func (n Native) Stop(session string) error {
+	procs, err := n.Processes(session)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, p := range procs {
+		if err := n.StopProcess(session, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StopProcess marks the process as stopped, so its supervisor neither
+// records a crash nor restarts it, then sends SIGTERM to the process group
+// and SIGKILL if it is still alive after stopTimeout.
+func (Native) StopProcess(session, name string) error {
+	sdir, err := sessionDir(session)
+	if err != nil {
+		return err
+	}
+	pidPath := filepath.Join(sdir, processFile(name)+".pid")
+	pid, ok := readPID(pidPath)
+	if !ok {
+		_ = os.Remove(pidPath)
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(sdir, processFile(name)+".stop"), nil, 0644); err != nil {
+		return fmt.Errorf("stopping %q: %w", processFile(name), err)
+	}
+
+	if err := signalGroup(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("stopping %q: %w", processFile(name), err)
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for alive(pid) && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if alive(pid) {
+		if err := signalGroup(pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("killing %q: %w", processFile(name), err)
+		}
+	}
+	_ = os.Remove(pidPath)
+	return nil
+}
+
+func (n Native) IsRunning(session string) bool {
+	procs, _ := n.Processes(session)
+	return len(procs) > 0
+}
+
+// Processes returns live process names in start order.
+func (Native) Processes(session string) ([]string, error) {
+	sdir, err := sessionDir(session)
+	if err != nil {
+		return nil, err
+	}
+	matches, _ := filepath.Glob(filepath.Join(sdir, "*.pid"))
+
+	type proc struct {
+		name    string
+		started time.Time
+	}
+	var procs []proc
+	for _, m := range matches {
+		if _, ok := readPID(m); !ok {
+			continue
+		}
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		procs = append(procs, proc{name: strings.TrimSuffix(filepath.Base(m), ".pid"), started: info.ModTime()})
+	}
+	sort.SliceStable(procs, func(i, j int) bool {
+		if !procs[i].started.Equal(procs[j].started) {
+			return procs[i].started.Before(procs[j].started)
+		}
+		return procs[i].name < procs[j].name
+	})
+
+	var names []string
+	for _, p := range procs {
+		names = append(names, p.name)
+	}
+	return names, nil
+}
+
+func (Native) HasProcess(session, name string) bool {
+	sdir, err := sessionDir(session)
+	if err != nil {
+		return false
+	}
+	_, ok := readPID(filepath.Join(sdir, processFile(name)+".pid"))
+	return ok
+}
+
+// Capture returns the tail of the session's unnamed process log, or of its
+// first process when it only has named processes. Output from a process that
+// has exited remains available until the session is started again.
+func (n Native) Capture(session string, lines int) (string, error) {
+	logDir, err := LogDir(session)
+	if err != nil {
+		return "", err
+	}
+	name := defaultProcess
+	if _, err := os.Stat(logfile.Path(logDir, name)); err != nil {
+		procs, _ := n.Processes(session)
+		if len(procs) == 0 {
+			return "", fmt.Errorf("session %q is not running (start with: fr8 ws run)", session)
+		}
+		name = procs[0]
+	}
+	return logfile.Read(logDir, name, logfile.Options{Lines: lines})
+}
+
+func (Native) CaptureProcess(session, name string, lines int) (string, error) {
+	logDir, err := LogDir(session)
+	if err != nil {
+		return "", err
+	}
+	out, err := logfile.Read(logDir, processFile(name), logfile.Options{Lines: lines})
+	if err != nil {
+		return "", fmt.Errorf("service %q is not running in session %q", name, session)
+	}
+	return out, nil
+}
+
+func (Native) Attach(session string) error {
+	return fmt.Errorf("the native runner does not support attach (use fr8 ws logs -f to follow output)")
+}
+
+func (n Native) AttachRun(session string) error { return n.Attach(session) }
+
+// Rename moves a session's run directory. Logs are moved separately with
+// logfile.Move. A running session can't be renamed: its supervisors keep
+// writing pidfiles and exit records under the old directory.
+func (n Native) Rename(oldSession, newSession string) error {
+	if n.IsRunning(oldSession) {
+		return fmt.Errorf("session %s is running; the native runner can't rename it (stop it first with: fr8 ws stop)", oldSession)
+	}
+	oldDir, err := sessionDir(oldSession)
+	if err != nil {
+		return err
+	}
+	newDir, err := sessionDir(newSession)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(oldDir); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(newDir), 0755); err != nil {
+		return fmt.Errorf("creating run directory: %w", err)
+	}
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return fmt.Errorf("renaming run directory: %w", err)
+	}
+	return nil
+}
+
+// AllProcesses returns the live processes of every running session.
+func (n Native) AllProcesses() (map[string][]string, error) {
+	sessions, err := n.List()
+	if err != nil {
+		return nil, err
+	}
+	procs := make(map[string][]string, len(sessions))
+	for _, s := range sessions {
+		if p, _ := n.Processes(s.Name); len(p) > 0 {
+			procs[s.Name] = p
+		}
+	}
+	return procs, nil
+}
+
+func (n Native) List() ([]Session, error) {
+	dir, err := RunDir()
+	if err != nil {
+		return nil, err
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", "*"))
+	sort.Strings(matches)
+
+	var sessions []Session
+	for _, m := range matches {
+		rel, err := filepath.Rel(dir, m)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 2 {
+			continue
+		}
+		name := "fr8/" + parts[0] + "/" + parts[1]
+		if n.IsRunning(name) {
+			sessions = append(sessions, Session{Name: name, Repo: parts[0], Workspace: parts[1]})
+		}
+	}
+	return sessions, nil
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package runner
+
+import (
+	"errors"
+	"syscall"
+)
+
+// alive reports whether a process exists.
+func alive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// signalGroup sends sig to the process group led by pid.
+func signalGroup(pid int, sig syscall.Signal) error {
+	return syscall.Kill(-pid, sig)
+}
+
+// detached starts the supervisor in a new session so it outlives fr8 and
+// does not receive the terminal's signals.
+func detached() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package runner
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// alive always reports false; the native runner is unsupported on Windows.
+func alive(pid int) bool {
+	return false
+}
+
+// signalGroup is unsupported on Windows.
+func signalGroup(pid int, sig syscall.Signal) error {
+	return fmt.Errorf("process groups are not supported on windows")
+}
+
+// detached returns default attributes; the native runner is unsupported on Windows.
+func detached() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{}
+}
@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY allocates a pseudo-terminal pair via /dev/ptmx.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening pty: %w", err)
+	}
+
+	rc, err := master.SyscallConn()
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, fmt.Errorf("opening pty: %w", err)
+	}
+	name := make([]byte, 128)
+	var ioErr error
+	if err := rc.Control(func(fd uintptr) {
+		if ioErr = unix.IoctlSetInt(int(fd), unix.TIOCPTYGRANT, 0); ioErr != nil {
+			return
+		}
+		if ioErr = unix.IoctlSetInt(int(fd), unix.TIOCPTYUNLK, 0); ioErr != nil {
+			return
+		}
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(unix.TIOCPTYGNAME), uintptr(unsafe.Pointer(&name[0]))); errno != 0 {
+			ioErr = errno
+		}
+	}); err != nil {
+		ioErr = err
+	}
+	if ioErr != nil {
+		_ = master.Close()
+		return nil, nil, fmt.Errorf("unlocking pty: %w", ioErr)
+	}
+
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	slave, err = os.OpenFile(string(name), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, fmt.Errorf("opening pty: %w", err)
+	}
+	return master, slave, nil
+}
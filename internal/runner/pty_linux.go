@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY allocates a pseudo-terminal pair via /dev/ptmx.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening pty: %w", err)
+	}
+
+	rc, err := master.SyscallConn()
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, fmt.Errorf("opening pty: %w", err)
+	}
+	var n uint32
+	var ioErr error
+	if err := rc.Control(func(fd uintptr) {
+		if ioErr = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); ioErr != nil {
+			return
+		}
+		n, ioErr = unix.IoctlGetUint32(int(fd), unix.TIOCGPTN)
+	}); err != nil {
+		ioErr = err
+	}
+	if ioErr != nil {
+		_ = master.Close()
+		return nil, nil, fmt.Errorf("unlocking pty: %w", ioErr)
+	}
+
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, fmt.Errorf("opening pty: %w", err)
+	}
+	return master, slave, nil
+}
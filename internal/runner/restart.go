@@ -0,0 +1,281 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/protocollar/fr8/internal/config"
+)
+
+// WatchCommand is the hidden fr8 subcommand that runs a process in a tmux
+// window, restarting it according to its policy and recording how it exits.
+const WatchCommand = "__watch"
+
+// Restart backoff: the delay starts at restartMinDelay and doubles after each
+// restart up to restartMaxDelay. A process that ran for restartResetAfter
+// before exiting starts again from the minimum delay.
+var (
+	restartMinDelay   = time.Second
+	restartMaxDelay   = 30 * time.Second
+	restartResetAfter = time.Minute
+)
+
+// Exit records how a process last exited. It is written to
+// <state dir>/run/<repo>/<workspace>/<name>.exit each time the process exits
+// on its own, and cleared when fr8 starts it again.
+type Exit struct {
+	Name     string    `json:"name,omitempty"`
+	Code     int       `json:"code"`
+	Time     time.Time `json:"time"`
+	Restarts int       `json:"restarts,omitempty"`
+}
+
+// Crashed reports whether the process exited with a non-zero code.
+func (e *Exit) Crashed() bool { return e.Code != 0 }
+
+// State is "crashed" for a non-zero exit and "exited" otherwise.
+func (e *Exit) State() string {
+	if e.Crashed() {
+		return "crashed"
+	}
+	return "exited"
+}
+
+// Detail describes the exit code and time, e.g. "exit 1, 2m ago".
+func (e *Exit) Detail() string {
+	return fmt.Sprintf("exit %d, %s", e.Code, ago(e.Time))
+}
+
+// Summary describes the exit for display, e.g. "crashed (exit 1, 2m ago)".
+func (e *Exit) Summary() string {
+	return e.State() + " (" + e.Detail() + ")"
+}
+
+// ago formats the time since t, e.g. "just now" or "2m ago".
+func ago(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// LastExit returns how a session's process last exited, or nil if it has not
+// exited since fr8 last started it.
+func LastExit(session, name string) *Exit {
+	sdir, err := sessionDir(session)
+	if err != nil {
+		return nil
+	}
+	return readExit(filepath.Join(sdir, processFile(name)+".exit"))
+}
+
+// LastCrash returns the most recent crash among a session's processes, or
+// nil if none has crashed since fr8 last started it.
+func LastCrash(session string) *Exit {
+	sdir, err := sessionDir(session)
+	if err != nil {
+		return nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(sdir, "*.exit"))
+	sort.Strings(matches)
+
+	var last *Exit
+	for _, m := range matches {
+		e := readExit(m)
+		if e != nil && e.Crashed() && (last == nil || e.Time.After(last.Time)) {
+			last = e
+		}
+	}
+	return last
+}
+
+// Crashes returns the last crash of every session with one, keyed by session
+// name.
+func Crashes() map[string]*Exit {
+	dir, err := RunDir()
+	if err != nil {
+		return nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", "*"))
+	crashes := make(map[string]*Exit)
+	for _, m := range matches {
+		rel, err := filepath.Rel(dir, m)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 2 {
+			continue
+		}
+		session := "fr8/" + parts[0] + "/" + parts[1]
+		if e := LastCrash(session); e != nil {
+			crashes[session] = e
+		}
+	}
+	return crashes
+}
+
+func readExit(path string) *Exit {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var e Exit
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil
+	}
+	if e.Name == "" {
+		e.Name = strings.TrimSuffix(filepath.Base(path), ".exit")
+	}
+	return &e
+}
+
+// clearExit removes a process's exit record before it is started.
+func clearExit(sdir, name string) {
+	_ = os.Remove(filepath.Join(sdir, processFile(name)+".exit"))
+}
+
+// shouldRestart reports whether a process that exited with code is
+// restarted under policy.
+func shouldRestart(policy string, code int) bool {
+	switch policy {
+	case config.RestartAlways:
+		return true
+	case config.RestartOnFailure:
+		return code != 0
+	default:
+		return false
+	}
+}
+
+// exitCode returns a process's exit code from its Wait error. A process
+// killed by a signal reports 128 plus the signal number, as shells do.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return -1
+	}
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+// restarter runs a process until it exits and should not be restarted under
+// its policy, recording each exit in <run-dir>/<name>.exit.
+type restarter struct {
+	runDir  string
+	name    string
+	policy  string
+	stopped func() bool      // reports whether fr8 asked the process to stop
+	notify  func(msg string) // reports a restart in the process's output
+	backoff func()           // called before waiting to restart, if set
+}
+
+// run calls once to run the process, which returns its exit code. A process
+// stopped by fr8 is neither recorded nor restarted.
+func (r restarter) run(once func() (int, error)) error {
+	restarts := 0
+	delay := restartMinDelay
+	for {
+		started := time.Now()
+		code, err := once()
+		if err != nil {
+			return err
+		}
+		if r.stopped() {
+			return nil
+		}
+
+		e := Exit{Name: r.name, Code: code, Time: time.Now(), Restarts: restarts}
+		data, _ := json.Marshal(e)
+		if err := writeFileAtomic(filepath.Join(r.runDir, r.name+".exit"), append(data, '\n')); err != nil {
+			return err
+		}
+		if !shouldRestart(r.policy, code) {
+			return nil
+		}
+
+		if time.Since(started) >= restartResetAfter {
+			delay = restartMinDelay
+		}
+		r.notify(fmt.Sprintf("fr8: %s exited with code %d, restarting in %s", r.name, code, delay))
+		if r.backoff != nil {
+			r.backoff()
+		}
+		for end := time.Now().Add(delay); time.Now().Before(end); {
+			if r.stopped() {
+				return nil
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		restarts++
+		delay = min(delay*2, restartMaxDelay)
+	}
+}
+
+// Watch runs command in the foreground of the current terminal (a tmux
+// pane), restarting it according to policy. A hangup, interrupt or terminate
+// signal (e.g. fr8 ws stop killing the window) is passed on to the command
+// and stops it for good. It is the body of the hidden fr8 watch command.
+func Watch(args []string) error {
+	if len(args) != 4 {
+		return fmt.Errorf("usage: fr8 %s <run-dir> <name> <policy> <command>", WatchCommand)
+	}
+	runDir, name, policy, command := args[0], args[1], args[2], args[3]
+
+	var stopped atomic.Bool
+	var current atomic.Pointer[os.Process]
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		for s := range sig {
+			stopped.Store(true)
+			if p := current.Load(); p != nil {
+				_ = p.Signal(s)
+			}
+		}
+	}()
+
+	r := restarter{
+		runDir:  runDir,
+		name:    name,
+		policy:  policy,
+		stopped: stopped.Load,
+		notify:  func(msg string) { fmt.Fprintln(os.Stderr, msg) },
+	}
+	return r.run(func() (int, error) {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			return 0, fmt.Errorf("starting %q: %w", name, err)
+		}
+		current.Store(cmd.Process)
+		err := cmd.Wait()
+		current.Store(nil)
+		return exitCode(err), nil
+	})
+}
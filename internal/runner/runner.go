@@ -0,0 +1,188 @@
+// Package runner abstracts how fr8 runs a workspace's background processes.
+// The tmux backend keeps each workspace in a detached tmux session; the
+// native backend supervises processes directly under a PTY, with pidfiles in
+// the state directory. Both record output to disk via logfile.
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/logfile"
+	"github.com/protocollar/fr8/internal/tmux"
+	"github.com/protocollar/fr8/internal/userconfig"
+)
+
+// EnvVar selects the runner backend, overriding the user config.
+const EnvVar = "FR8_RUNNER"
+
+// Backend names accepted by FR8_RUNNER and the user config "runner" setting.
+const (
+	TmuxBackend   = "tmux"
+	NativeBackend = "native"
+)
+
+// Process is a named command run in a workspace session. A workspace without
+// services runs a single process with an empty name.
+type Process struct {
+	Name    string
+	Command string
+	Env     []string // extra KEY=VALUE pairs set only for this process
+	Restart string   // restart policy (see config.RestartPolicies); empty means never
+}
+
+// restartPolicy returns p's restart policy, defaulting to never.
+func restartPolicy(p Process) string {
+	if p.Restart == "" {
+		return config.RestartNever
+	}
+	return p.Restart
+}
+
+// Session is a running fr8 workspace session.
+type Session struct {
+	Name      string `json:"name"` // full session name, e.g. "fr8/myrepo/cool-workspace"
+	Repo      string `json:"repo"`
+	Workspace string `json:"workspace"`
+}
+
+// Runner starts, stops and inspects workspace sessions. Session names are
+// built with tmux.SessionName regardless of backend.
+type Runner interface {
+	// Name returns the backend name ("tmux" or "native").
+	Name() string
+	// Available reports whether the backend can be used on this machine.
+	Available() error
+
+	// Start launches procs in a new session, in order. envVars are set for
+	// every process, followed by the process's own Env.
+	Start(session, dir string, procs []Process, envVars []string) error
+	// StartProcess adds a process to a session, starting the session if needed.
+	StartProcess(session, dir string, p Process, envVars []string) error
+	// Stop terminates every process in a session. Returns nil if not running.
+	Stop(session string) error
+	// StopProcess terminates one named process. Returns nil if not running.
+	StopProcess(session, name string) error
+
+	// IsRunning reports whether any process in the session is alive.
+	IsRunning(session string) bool
+	// Processes returns the names of the live processes in a session.
+	Processes(session string) ([]string, error)
+	// HasProcess reports whether the named process is alive in a session.
+	HasProcess(session, name string) bool
+	// AllProcesses returns the live processes of every fr8 session, keyed
+	// by session name. Sessions without live processes are left out.
+	AllProcesses() (map[string][]string, error)
+
+	// Capture returns up to lines of recent output from a session.
+	Capture(session string, lines int) (string, error)
+	// CaptureProcess returns up to lines of recent output from one process.
+	CaptureProcess(session, name string, lines int) (string, error)
+
+	// Attach replaces the current process with an interactive view of the session.
+	Attach(session string) error
+	// AttachRun attaches as a child process and returns when it detaches.
+	AttachRun(session string) error
+
+	// Rename moves a session to a new name.
+	Rename(oldSession, newSession string) error
+	// List returns all running fr8 sessions.
+	List() ([]Session, error)
+}
+
+// New returns the runner for a backend name. An empty name selects tmux when
+// it is installed and the native backend otherwise.
+func New(name string) (Runner, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		if tmux.Available() == nil {
+			return Tmux{}, nil
+		}
+		return Native{}, nil
+	case TmuxBackend:
+		return Tmux{}, nil
+	case NativeBackend:
+		return Native{}, nil
+	default:
+		return nil, fmt.Errorf("unknown runner %q (use %q or %q)", name, TmuxBackend, NativeBackend)
+	}
+}
+
+// Default returns the runner selected by FR8_RUNNER, then the "runner"
+// setting in the user config, falling back to New(""). An invalid selection
+// yields a runner whose Available method reports the error.
+func Default() Runner {
+	name := os.Getenv(EnvVar)
+	if name == "" {
+		if path, err := userconfig.DefaultPath(); err == nil {
+			if cfg, err := userconfig.Load(path); err == nil {
+				name = cfg.Runner
+			}
+		}
+	}
+
+	r, err := New(name)
+	if err != nil {
+		return invalid{Runner: Native{}, err: err}
+	}
+	return r
+}
+
+// invalid wraps a runner for an unrecognised backend name so that callers,
+// which all check Available first, surface the configuration error.
+type invalid struct {
+	Runner
+	err error
+}
+
+func (r invalid) Available() error { return r.err }
+
+// LogDir returns the logfile directory for a session.
+func LogDir(session string) (string, error) {
+	s, ok := parseSession(session)
+	if !ok {
+		return "", fmt.Errorf("invalid session name %q", session)
+	}
+	return logfile.Dir(s.Repo, s.Workspace)
+}
+
+// StartedAt returns when fr8 last started a session or one of its processes,
+// or the zero time if it has no record.
+func StartedAt(session string) time.Time {
+	sdir, err := sessionDir(session)
+	if err != nil {
+		return time.Time{}
+	}
+	data, err := os.ReadFile(filepath.Join(sdir, "started"))
+	if err != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	return t
+}
+
+// markStarted records that fr8 is starting a session or one of its
+// processes, for StartedAt.
+func markStarted(session string) error {
+	sdir, err := sessionDir(session)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(sdir, 0755); err != nil {
+		return fmt.Errorf("creating run directory: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(sdir, "started"), []byte(time.Now().Format(time.RFC3339Nano)+"\n"))
+}
+
+// parseSession splits an fr8 session name "fr8/<repo>/<workspace>".
+func parseSession(name string) (Session, bool) {
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) != 3 || parts[0] != "fr8" || parts[1] == "" || parts[2] == "" {
+		return Session{}, false
+	}
+	return Session{Name: name, Repo: parts[1], Workspace: parts[2]}, true
+}
@@ -0,0 +1,353 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary act as the native supervisor and the tmux
+// watch command, since both runners re-execute os.Executable().
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == SuperviseCommand {
+		if err := Supervise(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if len(os.Args) > 1 && os.Args[1] == WatchCommand {
+		if err := Watch(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestNew(t *testing.T) {
+	if r, err := New("native"); err != nil || r.Name() != NativeBackend {
+		t.Errorf("New(native) = %v, %v", r, err)
+	}
+	if r, err := New(" TMUX "); err != nil || r.Name() != TmuxBackend {
+		t.Errorf("New(TMUX) = %v, %v", r, err)
+	}
+	if _, err := New("screen"); err == nil {
+		t.Error("expected error for unknown runner")
+	}
+}
+
+func TestDefaultSelection(t *testing.T) {
+	t.Setenv("FR8_CONFIG_DIR", t.TempDir())
+
+	t.Setenv(EnvVar, "native")
+	if r := Default(); r.Name() != NativeBackend {
+		t.Errorf("Default() with FR8_RUNNER=native = %q", r.Name())
+	}
+
+	t.Setenv(EnvVar, "bogus")
+	if err := Default().Available(); err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("Available() = %v, want unknown runner error", err)
+	}
+}
+
+func TestDefaultFromUserConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("FR8_CONFIG_DIR", dir)
+	t.Setenv(EnvVar, "")
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"runner": "native"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if r := Default(); r.Name() != NativeBackend {
+		t.Errorf("Default() = %q, want native from user config", r.Name())
+	}
+}
+
+func TestParseSession(t *testing.T) {
+	s, ok := parseSession("fr8/myapp/bright-berlin")
+	if !ok || s.Repo != "myapp" || s.Workspace != "bright-berlin" {
+		t.Errorf("parseSession = %+v, %v", s, ok)
+	}
+	for _, bad := range []string{"myapp/ws", "fr8/myapp", "other/myapp/ws", "fr8//ws"} {
+		if _, ok := parseSession(bad); ok {
+			t.Errorf("parseSession(%q) should fail", bad)
+		}
+	}
+}
+
+// waitFor polls cond until it is true or the timeout elapses.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestNativeLifecycle(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	n := Native{}
+	if err := n.Available(); err != nil {
+		t.Skip(err)
+	}
+
+	session := "fr8/myapp/alpha"
+	procs := []Process{
+		{Name: "web", Command: `echo "web on $FR8_PORT"; exec sleep 30`},
+		{Name: "worker", Command: `echo "worker $QUEUE"; exec sleep 30`, Env: []string{"QUEUE=default"}},
+	}
+	if err := n.Start(session, t.TempDir(), procs, []string{"FR8_PORT=5000"}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = n.Stop(session) }()
+
+	if !n.IsRunning(session) {
+		t.Fatal("session should be running")
+	}
+	if started := StartedAt(session); time.Since(started) > time.Minute {
+		t.Errorf("StartedAt = %v, want just now", started)
+	}
+	if err := n.Start(session, t.TempDir(), procs, nil); err == nil {
+		t.Error("starting a running session should fail")
+	}
+
+	got, err := n.Processes(session)
+	if err != nil || strings.Join(got, ",") != "web,worker" {
+		t.Errorf("Processes = %v, %v", got, err)
+	}
+
+	waitFor(t, func() bool {
+		out, _ := n.CaptureProcess(session, "worker", 10)
+		return strings.Contains(out, "worker default")
+	})
+	out, _ := n.CaptureProcess(session, "web", 10)
+	if out != "web on 5000\n" {
+		t.Errorf("web output = %q", out)
+	}
+
+	sessions, err := n.List()
+	if err != nil || len(sessions) != 1 || sessions[0].Name != session {
+		t.Errorf("List = %+v, %v", sessions, err)
+	}
+
+	if err := n.StopProcess(session, "worker"); err != nil {
+		t.Fatal(err)
+	}
+	if n.HasProcess(session, "worker") || !n.HasProcess(session, "web") {
+		t.Error("only worker should have stopped")
+	}
+
+	if err := n.Stop(session); err != nil {
+		t.Fatal(err)
+	}
+	if n.IsRunning(session) {
+		t.Error("session should be stopped")
+	}
+	if out, err := n.CaptureProcess(session, "web", 10); err != nil || !strings.Contains(out, "web on 5000") {
+		t.Errorf("output should remain after stop, got %q, %v", out, err)
+	}
+}
+
+func TestNativeStopKillsStubbornProcess(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	n := Native{}
+	if err := n.Available(); err != nil {
+		t.Skip(err)
+	}
+
+	old := stopTimeout
+	stopTimeout = 200 * time.Millisecond
+	defer func() { stopTimeout = old }()
+
+	session := "fr8/myapp/stubborn"
+	cmd := `trap "" TERM; echo ready; while :; do sleep 1; done`
+	if err := n.Start(session, t.TempDir(), []Process{{Command: cmd}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		out, _ := n.Capture(session, 5)
+		return strings.Contains(out, "ready")
+	})
+
+	if err := n.Stop(session); err != nil {
+		t.Fatal(err)
+	}
+	if n.IsRunning(session) {
+		t.Error("process ignoring SIGTERM should be killed")
+	}
+}
+
+func TestNativeRename(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	n := Native{}
+	if err := n.Available(); err != nil {
+		t.Skip(err)
+	}
+
+	if err := n.Start("fr8/myapp/old", t.TempDir(), []Process{{Command: "exec sleep 30"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = n.Stop("fr8/myapp/new") }()
+
+	defer func() { _ = n.Stop("fr8/myapp/old") }()
+
+	if err := n.Rename("fr8/myapp/old", "fr8/myapp/new"); err == nil {
+		t.Fatal("expected error renaming a running session")
+	}
+	if !n.IsRunning("fr8/myapp/old") {
+		t.Fatal("session should still be running under its old name")
+	}
+
+	if err := n.Stop("fr8/myapp/old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Rename("fr8/myapp/old", "fr8/myapp/new"); err != nil {
+		t.Fatal(err)
+	}
+	sdir, err := sessionDir("fr8/myapp/new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(sdir); err != nil {
+		t.Errorf("run directory not moved: %v", err)
+	}
+}
+
+func TestShouldRestart(t *testing.T) {
+	tests := []struct {
+		policy string
+		code   int
+		want   bool
+	}{
+		{"never", 1, false},
+		{"", 1, false},
+		{"on-failure", 1, true},
+		{"on-failure", 0, false},
+		{"always", 0, true},
+	}
+	for _, tt := range tests {
+		if got := shouldRestart(tt.policy, tt.code); got != tt.want {
+			t.Errorf("shouldRestart(%q, %d) = %v, want %v", tt.policy, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestNativeRecordsCrash(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	n := Native{}
+	if err := n.Available(); err != nil {
+		t.Skip(err)
+	}
+
+	session := "fr8/myapp/crashy"
+	if err := n.Start(session, t.TempDir(), []Process{{Name: "web", Command: "exit 2"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return LastExit(session, "web") != nil })
+
+	if n.IsRunning(session) {
+		t.Error("crashed process should not be running")
+	}
+	e := LastCrash(session)
+	if e == nil || e.Name != "web" || e.Code != 2 {
+		t.Fatalf("LastCrash = %+v, want web exit 2", e)
+	}
+	if !strings.HasPrefix(e.Summary(), "crashed (exit 2, ") {
+		t.Errorf("Summary = %q", e.Summary())
+	}
+	if c := Crashes()[session]; c == nil || c.Code != 2 {
+		t.Errorf("Crashes()[%q] = %+v", session, c)
+	}
+}
+
+func TestNativeRestartOnFailure(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	n := Native{}
+	if err := n.Available(); err != nil {
+		t.Skip(err)
+	}
+
+	old := restartMinDelay
+	restartMinDelay = 50 * time.Millisecond
+	defer func() { restartMinDelay = old }()
+
+	session := "fr8/myapp/flaky"
+	proc := Process{Name: "web", Command: "echo attempt; sleep 0.1; exit 3", Restart: "on-failure"}
+	if err := n.Start(session, t.TempDir(), []Process{proc}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = n.Stop(session) }()
+
+	waitFor(t, func() bool {
+		e := LastExit(session, "web")
+		return e != nil && e.Restarts >= 1
+	})
+	if e := LastExit(session, "web"); e.Code != 3 {
+		t.Errorf("LastExit code = %d, want 3", e.Code)
+	}
+	if !n.IsRunning(session) {
+		t.Error("process should count as running while it is being restarted")
+	}
+	out, _ := n.CaptureProcess(session, "web", 50)
+	if strings.Count(out, "attempt") < 2 || !strings.Contains(out, "restarting in") {
+		t.Errorf("output should show restarts, got %q", out)
+	}
+
+	if err := n.Stop(session); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if n.IsRunning(session) {
+		t.Error("stopped process should not be restarted")
+	}
+}
+
+func TestNativeStopIsNotACrash(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	n := Native{}
+	if err := n.Available(); err != nil {
+		t.Skip(err)
+	}
+
+	session := "fr8/myapp/steady"
+	if err := n.Start(session, t.TempDir(), []Process{{Command: "exec sleep 30", Restart: "always"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Stop(session); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if e := LastExit(session, ""); e != nil {
+		t.Errorf("LastExit after stop = %+v, want nil", e)
+	}
+	if n.IsRunning(session) {
+		t.Error("stopped process should not be restarted")
+	}
+}
+
+func TestTmuxRecordsCrash(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	tm := Tmux{}
+	if err := tm.Available(); err != nil {
+		t.Skip(err)
+	}
+
+	session := "fr8/test-repo/test-runner-crash"
+	_ = tm.Stop(session)
+	if err := tm.Start(session, t.TempDir(), []Process{{Name: "web", Command: "echo booting; exit 4"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tm.Stop(session) }()
+
+	waitFor(t, func() bool { return LastExit(session, "web") != nil })
+	if e := LastExit(session, "web"); e.Code != 4 {
+		t.Errorf("LastExit code = %d, want 4", e.Code)
+	}
+}
@@ -0,0 +1,136 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/protocollar/fr8/internal/logfile"
+	"golang.org/x/sys/unix"
+)
+
+// Supervise runs command under a PTY in its own session and process group,
+// recording its pid in <run-dir>/<name>.pid and its output in the log file.
+// Each time it exits on its own, its exit is recorded in <run-dir>/<name>.exit
+// and it is restarted if policy says so; while waiting to restart, the pidfile
+// holds the supervisor's own pid so the process still counts as running and
+// can be stopped. It is the body of the hidden fr8 supervisor command.
+func Supervise(args []string) error {
+	if len(args) != 6 {
+		return fmt.Errorf("usage: fr8 %s <run-dir> <name> <work-dir> <log-file> <policy> <command>", SuperviseCommand)
+	}
+	runDir, name, workDir, logPath, policy, command := args[0], args[1], args[2], args[3], args[4], args[5]
+
+	log, err := logfile.Open(logPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = log.Close() }()
+
+	var lastPID int
+	pidPath := filepath.Join(runDir, name+".pid")
+	stopPath := filepath.Join(runDir, name+".stop")
+	defer func() {
+		// The process may already have been started again by a new
+		// supervisor; only remove a pidfile this one wrote.
+		if data, err := os.ReadFile(pidPath); err == nil && strings.TrimSpace(string(data)) == strconv.Itoa(lastPID) {
+			_ = os.Remove(pidPath)
+		}
+	}()
+
+	r := restarter{
+		runDir: runDir,
+		name:   name,
+		policy: policy,
+		stopped: func() bool {
+			_, err := os.Stat(stopPath)
+			return err == nil
+		},
+		notify: func(msg string) { _, _ = fmt.Fprintln(log, msg) },
+		backoff: func() {
+			lastPID = os.Getpid()
+			_ = writeFileAtomic(pidPath, []byte(strconv.Itoa(lastPID)+"\n"))
+		},
+	}
+	return r.run(func() (int, error) {
+		return superviseOnce(workDir, name, command, pidPath, log, &lastPID)
+	})
+}
+
+// superviseOnce runs command once under a fresh PTY, copying its output to
+// log, and returns its exit code. The child's pid is stored in *pid and
+// written to pidPath.
+func superviseOnce(workDir, name, command, pidPath string, log io.Writer, pid *int) (int, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = master.Close() }()
+	if err := setWinsize(master, 50, 200); err != nil {
+		_ = slave.Close()
+		return 0, err
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = workDir
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	if err := cmd.Start(); err != nil {
+		_ = slave.Close()
+		return 0, fmt.Errorf("starting %q: %w", name, err)
+	}
+	_ = slave.Close()
+
+	*pid = cmd.Process.Pid
+	if err := writeFileAtomic(pidPath, []byte(strconv.Itoa(*pid)+"\n")); err != nil {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		return 0, err
+	}
+
+	copied := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(log, master)
+		close(copied)
+	}()
+
+	code := exitCode(cmd.Wait())
+
+	// Background children may still hold the PTY open; give the output a
+	// moment to drain, then stop copying.
+	select {
+	case <-copied:
+	case <-time.After(time.Second):
+		_ = master.Close()
+		<-copied
+	}
+	return code, nil
+}
+
+// setWinsize gives the PTY a fixed size so programs that query the terminal
+// lay out their output sensibly.
+func setWinsize(f *os.File, rows, cols uint16) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var ioErr error
+	if err := rc.Control(func(fd uintptr) {
+		ioErr = unix.IoctlSetWinsize(int(fd), unix.TIOCSWINSZ, &unix.Winsize{Row: rows, Col: cols})
+	}); err != nil {
+		return err
+	}
+	if ioErr != nil {
+		return fmt.Errorf("setting pty size: %w", ioErr)
+	}
+	return nil
+}
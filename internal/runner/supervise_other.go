@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package runner
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Supervise is unsupported on this platform.
+func Supervise(args []string) error {
+	return fmt.Errorf("the native runner is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,148 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/protocollar/fr8/internal/env"
+	"github.com/protocollar/fr8/internal/logfile"
+	"github.com/protocollar/fr8/internal/tmux"
+)
+
+// Tmux runs each workspace in a detached tmux session, with one window per
+// process.
+type Tmux struct{}
+
+func (Tmux) Name() string { return TmuxBackend }
+
+func (Tmux) Available() error { return tmux.Available() }
+
+func (Tmux) Start(session, dir string, procs []Process, envVars []string) error {
+	if err := markStarted(session); err != nil {
+		return err
+	}
+	for _, p := range procs {
+		if err := startLog(session, p.Name); err != nil {
+			return err
+		}
+	}
+
+	if len(procs) == 1 && procs[0].Name == "" {
+		p := procs[0]
+		return tmux.StartPiped(session, dir, watchCommand(session, p), logPipe(session, ""), append(append([]string(nil), envVars...), p.Env...))
+	}
+
+	windows := make([]tmux.Window, 0, len(procs))
+	for _, p := range procs {
+		windows = append(windows, tmux.Window{Name: p.Name, Command: watchCommand(session, p), Env: p.Env, Pipe: logPipe(session, p.Name)})
+	}
+	return tmux.StartWindows(session, dir, windows, envVars)
+}
+
+func (Tmux) StartProcess(session, dir string, p Process, envVars []string) error {
+	if tmux.IsRunning(session) && tmux.HasWindow(session, p.Name) {
+		return fmt.Errorf("service %q is already running in %q", p.Name, session)
+	}
+	if err := markStarted(session); err != nil {
+		return err
+	}
+	if err := startLog(session, p.Name); err != nil {
+		return err
+	}
+
+	w := tmux.Window{Name: p.Name, Command: watchCommand(session, p), Env: p.Env, Pipe: logPipe(session, p.Name)}
+	if !tmux.IsRunning(session) {
+		return tmux.StartWindows(session, dir, []tmux.Window{w}, envVars)
+	}
+	return tmux.StartWindow(session, dir, w, envVars)
+}
+
+// startLog moves a process's previous log aside and clears its last exit
+// before it starts.
+func startLog(session, name string) error {
+	if sdir, err := sessionDir(session); err == nil {
+		clearExit(sdir, name)
+	}
+	dir, err := LogDir(session)
+	if err != nil {
+		return err
+	}
+	return logfile.StartRun(dir, name)
+}
+
+// watchCommand wraps a process's command in the hidden fr8 watch command,
+// which applies its restart policy and records its exits. Falls back to the
+// bare command if the fr8 executable can't be found.
+func watchCommand(session string, p Process) string {
+	sdir, err := sessionDir(session)
+	if err != nil {
+		return p.Command
+	}
+	if err := os.MkdirAll(sdir, 0755); err != nil {
+		return p.Command
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return p.Command
+	}
+	return strings.Join([]string{
+		env.ShellQuote(self), WatchCommand, env.ShellQuote(sdir), env.ShellQuote(processFile(p.Name)),
+		env.ShellQuote(restartPolicy(p)), env.ShellQuote(p.Command),
+	}, " ")
+}
+
+// logPipe returns the shell command tmux pipes a process's output into: the
+// hidden fr8 log pipe command, appending to the process's log file. Returns
+// "" (no log) if the fr8 executable can't be found.
+func logPipe(session, name string) string {
+	dir, err := LogDir(session)
+	if err != nil {
+		return ""
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("exec %s %s %s", env.ShellQuote(self), logfile.PipeCommand, env.ShellQuote(logfile.Path(dir, name)))
+}
+
+func (Tmux) Stop(session string) error { return tmux.Stop(session) }
+
+func (Tmux) StopProcess(session, name string) error { return tmux.StopWindow(session, name) }
+
+func (Tmux) IsRunning(session string) bool { return tmux.IsRunning(session) }
+
+func (Tmux) Processes(session string) ([]string, error) { return tmux.ListWindows(session) }
+
+func (Tmux) AllProcesses() (map[string][]string, error) { return tmux.ListAllWindows() }
+
+func (Tmux) HasProcess(session, name string) bool { return tmux.HasWindow(session, name) }
+
+func (Tmux) Capture(session string, lines int) (string, error) {
+	return tmux.CapturePanes(session, lines)
+}
+
+func (Tmux) CaptureProcess(session, name string, lines int) (string, error) {
+	return tmux.CaptureWindow(session, name, lines)
+}
+
+func (Tmux) Attach(session string) error { return tmux.Attach(session) }
+
+func (Tmux) AttachRun(session string) error { return tmux.AttachRun(session) }
+
+func (Tmux) Rename(oldSession, newSession string) error {
+	return tmux.RenameSession(oldSession, newSession)
+}
+
+func (Tmux) List() ([]Session, error) {
+	sessions, err := tmux.ListFr8Sessions()
+	if err != nil {
+		return nil, err
+	}
+	var out []Session
+	for _, s := range sessions {
+		out = append(out, Session{Name: s.Name, Repo: s.Repo, Workspace: s.Workspace})
+	}
+	return out, nil
+}
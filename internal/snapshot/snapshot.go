@@ -0,0 +1,249 @@
+// Package snapshot saves the uncommitted changes in a worktree so that a
+// soft-archived workspace can be restored. A snapshot is a directory under
+// <state dir>/archive/<repo>/ holding the staged and unstaged changes as
+// patches and the untracked files as a gzipped tarball.
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/registry"
+)
+
+const (
+	stagedFile    = "staged.patch"
+	unstagedFile  = "unstaged.patch"
+	untrackedFile = "untracked.tar.gz"
+)
+
+// Dir returns a new snapshot directory for a workspace archived at t
+// (respects FR8_STATE_DIR).
+func Dir(repoName, wsName string, t time.Time) (string, error) {
+	regPath, err := registry.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	name := wsName + "-" + t.UTC().Format("20060102T150405")
+	return filepath.Join(filepath.Dir(regPath), "archive", repoName, name), nil
+}
+
+// Info summarizes what a snapshot holds.
+type Info struct {
+	Staged    bool `json:"staged"`
+	Unstaged  bool `json:"unstaged"`
+	Untracked int  `json:"untracked"`
+}
+
+// Empty reports whether the worktree had no uncommitted changes.
+func (i Info) Empty() bool {
+	return !i.Staged && !i.Unstaged && i.Untracked == 0
+}
+
+// Save writes the uncommitted changes in worktree to dir, creating it.
+func Save(dir, worktree string) (Info, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Info{}, fmt.Errorf("creating snapshot directory: %w", err)
+	}
+
+	var info Info
+	for _, p := range []struct {
+		file   string
+		cached bool
+		found  *bool
+	}{
+		{stagedFile, true, &info.Staged},
+		{unstagedFile, false, &info.Unstaged},
+	} {
+		patch, err := git.Diff(worktree, p.cached)
+		if err != nil {
+			return Info{}, err
+		}
+		if len(patch) == 0 {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, p.file), patch, 0644); err != nil {
+			return Info{}, fmt.Errorf("writing %s: %w", p.file, err)
+		}
+		*p.found = true
+	}
+
+	files, err := git.UntrackedFiles(worktree)
+	if err != nil {
+		return Info{}, err
+	}
+	if len(files) > 0 {
+		if err := writeTarball(filepath.Join(dir, untrackedFile), worktree, files); err != nil {
+			return Info{}, fmt.Errorf("saving untracked files: %w", err)
+		}
+	}
+	info.Untracked = len(files)
+	return info, nil
+}
+
+// Restore re-applies the changes saved in dir to worktree: the staged changes
+// to the index and worktree, the unstaged changes to the worktree, and the
+// untracked files. Untracked files replace files of the same name already in
+// worktree, such as those synced into it when it was recreated.
+func Restore(dir, worktree string) error {
+	for _, p := range []struct {
+		file  string
+		index bool
+	}{
+		{stagedFile, true},
+		{unstagedFile, false},
+	} {
+		path := filepath.Join(dir, p.file)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err := git.Apply(worktree, path, p.index); err != nil {
+			return fmt.Errorf("restoring %s: %w", p.file, err)
+		}
+	}
+
+	path := filepath.Join(dir, untrackedFile)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err := extractTarball(path, worktree); err != nil {
+		return fmt.Errorf("restoring untracked files: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes a snapshot directory.
+func Remove(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("removing snapshot: %w", err)
+	}
+	return nil
+}
+
+func writeTarball(path, root string, files []string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range files {
+		if err := addFile(tw, root, name); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addFile(tw *tar.Writer, root, name string) error {
+	path := filepath.Join(root, name)
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	var link string
+	if fi.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	} else if !fi.Mode().IsRegular() {
+		return nil
+	}
+
+	hdr, err := tar.FileInfoHeader(fi, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.ToSlash(name)
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if link != "" {
+		return nil
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+func extractTarball(path, root string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("invalid path %q in snapshot", hdr.Name)
+		}
+		dest := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if fi, err := os.Lstat(dest); err == nil && !fi.IsDir() {
+			if err := os.Remove(dest); err != nil {
+				return err
+			}
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, dest); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, dest, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractFile(r io.Reader, dest string, perm fs.FileMode) (err error) {
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	_, err = io.Copy(f, r)
+	return err
+}
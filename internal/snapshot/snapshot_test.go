@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %s", args, out)
+	}
+	return string(out)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveRestore(t *testing.T) {
+	dir := t.TempDir()
+	gitRun(t, dir, "init")
+	gitRun(t, dir, "config", "user.email", "test@test.com")
+	gitRun(t, dir, "config", "user.name", "Test")
+	writeFile(t, filepath.Join(dir, "a.txt"), "one\n")
+	writeFile(t, filepath.Join(dir, ".gitignore"), "*.log\n")
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-m", "init")
+
+	writeFile(t, filepath.Join(dir, "a.txt"), "two\n")
+	gitRun(t, dir, "add", "a.txt")
+	writeFile(t, filepath.Join(dir, "a.txt"), "three\n")
+	writeFile(t, filepath.Join(dir, "notes", "todo.md"), "- finish\n")
+	writeFile(t, filepath.Join(dir, "debug.log"), "ignored\n")
+	wantStatus := gitRun(t, dir, "status", "--porcelain")
+
+	snap := filepath.Join(t.TempDir(), "snap")
+	info, err := Save(snap, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Staged || !info.Unstaged || info.Untracked != 1 {
+		t.Errorf("Save() = %+v, want staged, unstaged and 1 untracked file", info)
+	}
+
+	gitRun(t, dir, "reset", "--hard")
+	gitRun(t, dir, "clean", "-fd")
+	if err := Restore(snap, dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := gitRun(t, dir, "status", "--porcelain"); got != wantStatus {
+		t.Errorf("status after Restore = %q, want %q", got, wantStatus)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "notes", "todo.md")); string(got) != "- finish\n" {
+		t.Errorf("notes/todo.md after Restore = %q", got)
+	}
+}
+
+func TestRestoreReplacesExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	gitRun(t, dir, "init")
+	gitRun(t, dir, "config", "user.email", "test@test.com")
+	gitRun(t, dir, "config", "user.name", "Test")
+	gitRun(t, dir, "commit", "--allow-empty", "-m", "init")
+	writeFile(t, filepath.Join(dir, ".env"), "PORT=5000\n")
+
+	snap := filepath.Join(t.TempDir(), "snap")
+	if _, err := Save(snap, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file synced into the recreated worktree before the snapshot is applied
+	writeFile(t, filepath.Join(dir, ".env"), "PORT=6000\n")
+	if err := Restore(snap, dir); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, ".env")); string(got) != "PORT=5000\n" {
+		t.Errorf(".env after Restore = %q, want the snapshot's copy", got)
+	}
+}
+
+func TestSaveClean(t *testing.T) {
+	dir := t.TempDir()
+	gitRun(t, dir, "init")
+	gitRun(t, dir, "-c", "user.email=t@t", "-c", "user.name=T", "commit", "--allow-empty", "-m", "init")
+
+	snap := filepath.Join(t.TempDir(), "snap")
+	info, err := Save(snap, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Empty() {
+		t.Errorf("Save() = %+v, want empty", info)
+	}
+	if err := Restore(snap, dir); err != nil {
+		t.Errorf("Restore() of an empty snapshot = %v", err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", "/tmp/fr8-state")
+	at := time.Date(2026, 3, 1, 14, 5, 9, 0, time.UTC)
+	got, err := Dir("myapp", "bright-berlin", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join("/tmp/fr8-state", "archive", "myapp", "bright-berlin-20260301T140509")
+	if got != want {
+		t.Errorf("Dir() = %q, want %q", got, want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"syscall"
 )
 
@@ -44,32 +45,184 @@ func RepoName(rootPath string) string {
 // envVars should be only the FR8_*/CONDUCTOR_* key=value pairs to export;
 // the user's shell environment is inherited by tmux automatically.
 func Start(name, dir, command string, envVars []string) error {
+	return StartPiped(name, dir, command, "", envVars)
+}
+
+// StartPiped is like Start, but also pipes the session's output into pipe
+// (see Window.Pipe).
+func StartPiped(name, dir, command, pipe string, envVars []string) error {
 	if IsRunning(name) {
 		return fmt.Errorf("session %q is already running (use fr8 ws attach to connect)", name)
 	}
 
-	// Build export commands for FR8/CONDUCTOR env vars only
-	var exports []string
-	for _, e := range envVars {
-		exports = append(exports, fmt.Sprintf("export %s", shellescape(e)))
+	out, err := newPane([]string{"new-session", "-d", "-s", name, "-c", dir}, name, shellCommand(command, envVars), pipe)
+	if err != nil {
+		return fmt.Errorf("starting tmux session: %w\n%s", err, strings.TrimSpace(string(out)))
 	}
+	return nil
+}
 
-	// Combine exports + exec into a single shell command
-	var sessionCmd string
-	if len(exports) > 0 {
-		sessionCmd = strings.Join(exports, "; ") + "; exec " + command
-	} else {
-		sessionCmd = "exec " + command
+// Window is a named tmux window running a single command.
+type Window struct {
+	Name    string
+	Command string
+	Env     []string // extra KEY=VALUE pairs exported only in this window
+	Pipe    string   // optional shell command that receives the window's output
+}
+
+// StartWindows creates a new detached tmux session with one window per entry,
+// started in order. envVars are exported in every window, followed by the
+// window's own Env. If a later window fails to start, the session is killed.
+func StartWindows(name, dir string, windows []Window, envVars []string) error {
+	if len(windows) == 0 {
+		return fmt.Errorf("no windows to start")
+	}
+	if IsRunning(name) {
+		return fmt.Errorf("session %q is already running (use fr8 ws attach to connect)", name)
 	}
 
-	cmd := exec.Command("tmux", "new-session", "-d", "-s", name, "-c", dir, sessionCmd)
-	out, err := cmd.CombinedOutput()
+	first := windows[0]
+	out, err := newPane([]string{"new-session", "-d", "-s", name, "-n", first.Name, "-c", dir}, name+":"+first.Name,
+		shellCommand(first.Command, append(append([]string(nil), envVars...), first.Env...)), first.Pipe)
 	if err != nil {
 		return fmt.Errorf("starting tmux session: %w\n%s", err, strings.TrimSpace(string(out)))
 	}
+
+	for _, w := range windows[1:] {
+		if err := StartWindow(name, dir, w, envVars); err != nil {
+			_ = Stop(name)
+			return err
+		}
+	}
+	return nil
+}
+
+// StartWindow adds a window to an existing tmux session.
+func StartWindow(name, dir string, w Window, envVars []string) error {
+	out, err := newPane([]string{"new-window", "-d", "-t", name + ":", "-n", w.Name, "-c", dir}, name+":"+w.Name,
+		shellCommand(w.Command, append(append([]string(nil), envVars...), w.Env...)), w.Pipe)
+	if err != nil {
+		return fmt.Errorf("starting window %q: %w\n%s", w.Name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ListWindows returns the names of the windows in a tmux session.
+// Returns nil if the session doesn't exist.
+func ListWindows(name string) ([]string, error) {
+	if !IsRunning(name) {
+		return nil, nil
+	}
+	cmd := exec.Command("tmux", "list-windows", "-t", name, "-F", "#{window_name}")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing windows: %w", err)
+	}
+
+	var windows []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			windows = append(windows, line)
+		}
+	}
+	return windows, nil
+}
+
+// ListAllWindows returns the window names of every fr8 session, keyed by
+// session name, with a single tmux call. Returns nil if no server is running.
+func ListAllWindows() (map[string][]string, error) {
+	cmd := exec.Command("tmux", "list-windows", "-a", "-F", "#{session_name}\t#{window_name}")
+	out, err := cmd.Output()
+	if err != nil {
+		// tmux returns error when no server is running — that's fine, no windows
+		return nil, nil
+	}
+
+	windows := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		session, window, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok || !strings.HasPrefix(session, "fr8/") {
+			continue
+		}
+		windows[session] = append(windows[session], window)
+	}
+	return windows, nil
+}
+
+// HasWindow returns true if the session has a window with the given name.
+func HasWindow(name, window string) bool {
+	windows, _ := ListWindows(name)
+	for _, w := range windows {
+		if w == window {
+			return true
+		}
+	}
+	return false
+}
+
+// StopWindow kills a single window in a tmux session. Returns nil if the
+// window doesn't exist. Killing the last window ends the session.
+func StopWindow(name, window string) error {
+	if !HasWindow(name, window) {
+		return nil
+	}
+	cmd := exec.Command("tmux", "kill-window", "-t", name+":"+window)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("stopping window %q: %w\n%s", window, err, strings.TrimSpace(string(out)))
+	}
 	return nil
 }
 
+// CaptureWindow captures recent output from one window of a tmux session.
+func CaptureWindow(name, window string, lines int) (string, error) {
+	if !HasWindow(name, window) {
+		return "", fmt.Errorf("service %q is not running in session %q", window, name)
+	}
+
+	startLine := fmt.Sprintf("-%d", lines)
+	cmd := exec.Command("tmux", "capture-pane", "-t", name+":"+window, "-p", "-S", startLine)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("capturing pane output: %w", err)
+	}
+	return string(out), nil
+}
+
+// pipeChannels counts the wait-for channels used by newPane.
+var pipeChannels atomic.Int64
+
+// newPane runs a tmux command (args plus command) that creates the pane
+// target. With a pipe, command first waits on a tmux wait-for channel until
+// pipe-pane is attached, so none of its output is missed. Attaching the pipe
+// is best effort: the channel is always signalled so command still runs.
+func newPane(args []string, target, command, pipe string) ([]byte, error) {
+	if pipe == "" {
+		return exec.Command("tmux", append(args, command)...).CombinedOutput()
+	}
+
+	channel := fmt.Sprintf("fr8-pipe-%d-%d", os.Getpid(), pipeChannels.Add(1))
+	out, err := exec.Command("tmux", append(args, "tmux wait-for "+channel+"; "+command)...).CombinedOutput()
+	if err != nil {
+		return out, err
+	}
+	_ = exec.Command("tmux", "pipe-pane", "-o", "-t", target, pipe).Run()
+	_ = exec.Command("tmux", "wait-for", "-S", channel).Run()
+	return out, nil
+}
+
+// shellCommand combines FR8/CONDUCTOR env var exports with an exec of command.
+func shellCommand(command string, envVars []string) string {
+	var exports []string
+	for _, e := range envVars {
+		exports = append(exports, fmt.Sprintf("export %s", shellescape(e)))
+	}
+	if len(exports) > 0 {
+		return strings.Join(exports, "; ") + "; exec " + command
+	}
+	return "exec " + command
+}
+
 // Stop kills a tmux session. Returns nil if the session doesn't exist.
 func Stop(name string) error {
 	if !IsRunning(name) {
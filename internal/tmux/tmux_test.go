@@ -3,6 +3,8 @@ package tmux
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -494,3 +496,123 @@ func TestAttachNotRunning(t *testing.T) {
 		t.Error("expected error when attaching to nonexistent session")
 	}
 }
+
+func TestShellCommand(t *testing.T) {
+	if got := shellCommand("sleep 1", nil); got != "exec sleep 1" {
+		t.Errorf("shellCommand without env = %q", got)
+	}
+	got := shellCommand("sleep 1", []string{"A=1", "B=two words"})
+	want := "export A='1'; export B='two words'; exec sleep 1"
+	if got != want {
+		t.Errorf("shellCommand = %q, want %q", got, want)
+	}
+}
+
+func TestStartWindowsLifecycle(t *testing.T) {
+	if !tmuxInstalled() {
+		t.Skip("tmux not installed")
+	}
+
+	name := "fr8/test-repo/test-windows-ws"
+	if err := Stop(name); err != nil {
+		t.Fatal(err)
+	}
+
+	windows := []Window{
+		{Name: "web", Command: "sleep 60", Env: []string{"FR8_SERVICE=web"}},
+		{Name: "worker", Command: "sleep 60", Env: []string{"FR8_SERVICE=worker"}},
+	}
+	if err := StartWindows(name, "/tmp", windows, []string{"FR8_PORT=5000"}); err != nil {
+		t.Fatalf("StartWindows failed: %v", err)
+	}
+	defer func() { _ = Stop(name) }()
+
+	got, err := ListWindows(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "web" || got[1] != "worker" {
+		t.Fatalf("ListWindows = %v, want [web worker]", got)
+	}
+
+	all, err := ListAllWindows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := all[name]; len(got) != 2 || got[0] != "web" || got[1] != "worker" {
+		t.Fatalf("ListAllWindows()[%q] = %v, want [web worker]", name, got)
+	}
+
+	if err := StartWindows(name, "/tmp", windows, nil); err == nil {
+		t.Error("expected error when starting already-running session")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if _, err := CaptureWindow(name, "worker", 50); err != nil {
+		t.Errorf("CaptureWindow failed: %v", err)
+	}
+	if _, err := CaptureWindow(name, "missing", 50); err == nil {
+		t.Error("expected error capturing a missing window")
+	}
+
+	if err := StopWindow(name, "worker"); err != nil {
+		t.Fatalf("StopWindow failed: %v", err)
+	}
+	if HasWindow(name, "worker") {
+		t.Error("worker window should be gone after StopWindow")
+	}
+	if !HasWindow(name, "web") {
+		t.Error("web window should still be running")
+	}
+
+	if err := StartWindow(name, "/tmp", windows[1], nil); err != nil {
+		t.Fatalf("StartWindow failed: %v", err)
+	}
+	if !HasWindow(name, "worker") {
+		t.Error("worker window should be running after StartWindow")
+	}
+}
+
+func TestListWindowsNotRunning(t *testing.T) {
+	if !tmuxInstalled() {
+		t.Skip("tmux not installed")
+	}
+
+	windows, err := ListWindows("fr8/nonexistent/no-such-session")
+	if err != nil || windows != nil {
+		t.Errorf("ListWindows on nonexistent session = %v, %v; want nil, nil", windows, err)
+	}
+}
+
+func TestStartWindowsPipe(t *testing.T) {
+	if !tmuxInstalled() {
+		t.Skip("tmux not installed")
+	}
+
+	name := "fr8/test-repo/test-pipe-ws"
+	if err := Stop(name); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "web.log")
+	windows := []Window{
+		{Name: "web", Command: "sh -c 'echo first line; sleep 60'", Pipe: "cat >> " + out},
+	}
+	if err := StartWindows(name, "/tmp", windows, nil); err != nil {
+		t.Fatalf("StartWindows failed: %v", err)
+	}
+	defer func() { _ = Stop(name) }()
+
+	// The command waits for the pipe, so even its first line is captured.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(out)
+		if strings.Contains(string(data), "first line") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("piped output = %q, want it to contain %q", data, "first line")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
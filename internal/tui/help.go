@@ -17,7 +17,7 @@ func renderHelp(m model) string {
 	sections.WriteString(formatHelpLine("k/↑", "Move up"))
 	sections.WriteString(formatHelpLine("enter", "Select / drill down"))
 	sections.WriteString(formatHelpLine("esc", "Back / cancel / clear selection"))
-	sections.WriteString(formatHelpLine("/", "Filter list"))
+	sections.WriteString(formatHelpLine("/", "Filter list (label:<name> for labels)"))
 	sections.WriteString(formatHelpLine("ctrl+r", "Refresh data"))
 	sections.WriteString(formatHelpLine("ctrl+l", "Redraw screen"))
 	sections.WriteString(formatHelpLine("?", "Toggle this help"))
@@ -4,8 +4,9 @@ import (
 	"github.com/protocollar/fr8/internal/gh"
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/registry"
-	"github.com/protocollar/fr8/internal/tmux"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/userconfig"
+	"github.com/protocollar/fr8/internal/workspace"
 )
 
 type viewState int
@@ -31,17 +32,20 @@ type repoItem struct {
 // workspaceItem is a workspace with live git status.
 type workspaceItem struct {
 	Workspace     registry.Workspace
-	Branch        string          // live branch from git (not stored in state)
-	DirtyCount    git.DirtyCount  // staged/modified/untracked counts
+	Branch        string         // live branch from git (not stored in state)
+	DirtyCount    git.DirtyCount // staged/modified/untracked counts
 	Merged        bool
-	Ahead         int             // ahead of upstream tracking branch
-	Behind        int             // behind upstream tracking branch
-	DefaultAhead  int             // ahead of default branch
-	DefaultBehind int             // behind default branch
-	LastCommit    *git.CommitInfo // nil if unavailable
-	PR            *gh.PRInfo      // nil if no PR / gh unavailable
-	PortFree      bool            // true when nothing is listening on the workspace port
-	Running       bool            // true when a tmux session is active for this workspace
+	Ahead         int                      // ahead of upstream tracking branch
+	Behind        int                      // behind upstream tracking branch
+	DefaultAhead  int                      // ahead of default branch
+	DefaultBehind int                      // behind default branch
+	LastCommit    *git.CommitInfo          // nil if unavailable
+	PR            *gh.PRInfo               // nil if no PR / gh unavailable
+	PortFree      bool                     // true when nothing is listening on the workspace port
+	Running       bool                     // true when a tmux session is active for this workspace
+	LastCrash     *runner.Exit             // last crash when not running, nil if none
+	Health        string                   // healthcheck state when running, "" otherwise
+	Services      []workspace.ServiceState // per-service state when fr8.json defines services
 	StatusErr     error
 }
 
@@ -61,8 +65,9 @@ type workspacesLoadedMsg struct {
 }
 
 type archiveResultMsg struct {
-	name string
-	err  error
+	name    string
+	err     error
+	warning error // env variables that failed to expand for the archive script
 }
 
 type shellRequestMsg struct {
@@ -116,6 +121,7 @@ type batchArchiveResultMsg struct {
 	archived []string
 	failed   []string
 	err      error
+	warning  error // env variables that failed to expand for the archive script
 }
 
 type createRequestMsg struct {
@@ -141,6 +147,8 @@ type batchStopResultMsg struct {
 type autoRefreshTickMsg struct{}
 
 type autoRefreshResultMsg struct {
-	sessions []tmux.Session
+	sessions []runner.Session
+	crashes  map[string]*runner.Exit // last crash by session name
+	health   map[string]string       // healthcheck state by session name, for the current repo
 	err      error
 }
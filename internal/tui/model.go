@@ -2,10 +2,14 @@ package tui
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -16,10 +20,14 @@ import (
 	"github.com/protocollar/fr8/internal/env"
 	"github.com/protocollar/fr8/internal/gh"
 	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/logfile"
 	"github.com/protocollar/fr8/internal/port"
+	"github.com/protocollar/fr8/internal/proxy"
 	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/tmux"
 	"github.com/protocollar/fr8/internal/userconfig"
+	"github.com/protocollar/fr8/internal/workspace"
 )
 
 type model struct {
@@ -159,6 +167,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.err = nil
 		m.toast = fmt.Sprintf("archived %s", msg.name)
 		m.toastIsError = false
+		if msg.warning != nil {
+			m.err = fmt.Errorf("archive script env: %w", msg.warning)
+			m.toast = fmt.Sprintf("archived %s with warnings", msg.name)
+			m.toastIsError = true
+		}
 		m.toastExpiry = time.Now().Add(3 * time.Second)
 		m.view = viewWorkspaceList
 		return m, toastTickCmd()
@@ -173,6 +186,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			for i, ws := range m.workspaces {
 				if ws.Workspace.Name == msg.name {
 					m.workspaces[i].Running = true
+					m.workspaces[i].LastCrash = nil
+					m.workspaces[i].Health = ""
 					break
 				}
 			}
@@ -193,6 +208,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			for i, ws := range m.workspaces {
 				if ws.Workspace.Name == msg.name {
 					m.workspaces[i].Running = false
+					m.workspaces[i].Health = ""
 					break
 				}
 			}
@@ -281,6 +297,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.toast = fmt.Sprintf("archived %d workspaces", len(msg.archived))
 			m.toastIsError = false
 		}
+		if msg.warning != nil {
+			m.err = errors.Join(m.err, fmt.Errorf("archive script env: %w", msg.warning))
+			if len(msg.failed) == 0 {
+				m.toast = fmt.Sprintf("archived %d workspaces with warnings", len(msg.archived))
+				m.toastIsError = true
+			}
+		}
 		m.toastExpiry = time.Now().Add(3 * time.Second)
 		m.view = viewWorkspaceList
 		return m, toastTickCmd()
@@ -365,7 +388,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.loading {
 			return m, tea.Batch(autoRefreshTickCmd(), tea.WindowSize())
 		}
-		return m, tea.Batch(autoRefreshCmd(), autoRefreshTickCmd(), tea.WindowSize())
+		return m, tea.Batch(autoRefreshCmd(m.rootPath, m.repoName, m.workspaces), autoRefreshTickCmd(), tea.WindowSize())
 
 	case autoRefreshResultMsg:
 		if msg.err != nil {
@@ -382,6 +405,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			for i, ws := range m.workspaces {
 				sessionName := tmux.SessionName(repoName, ws.Workspace.Name)
 				m.workspaces[i].Running = runningSessions[sessionName]
+				m.workspaces[i].LastCrash = nil
+				m.workspaces[i].Health = msg.health[sessionName]
+				if !m.workspaces[i].Running {
+					m.workspaces[i].LastCrash = msg.crashes[sessionName]
+				}
 			}
 		}
 		// Update repo running counts
@@ -588,7 +616,7 @@ func (m model) handleWorkspaceKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if len(m.workspaces) > 0 {
 			var names []string
 			for _, ws := range m.workspaces {
-				if ws.Merged && !ws.DirtyCount.Dirty() {
+				if ws.Merged && !ws.DirtyCount.Dirty() && !ws.Workspace.Pinned {
 					names = append(names, ws.Workspace.Name)
 				}
 			}
@@ -614,7 +642,7 @@ func (m model) handleWorkspaceKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if len(m.selected) > 0 {
 				m.loading = true
 				m.err = nil
-				return m, tea.Batch(startSelectedCmd(m.workspaces, m.selected, m.rootPath), m.spinner.Tick)
+				return m, tea.Batch(startSelectedCmd(m.workspaces, m.selected, m.rootPath, m.repoName), m.spinner.Tick)
 			}
 			ws := resolveWs()
 			if ws.Running {
@@ -623,12 +651,12 @@ func (m model) handleWorkspaceKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			m.loading = true
 			m.err = nil
-			return m, tea.Batch(startWorkspaceCmd(ws.Workspace, m.rootPath), m.spinner.Tick)
+			return m, tea.Batch(startWorkspaceCmd(ws.Workspace, m.rootPath, m.repoName), m.spinner.Tick)
 		}
 	case key.Matches(msg, keys.Browser):
 		if len(filtered) > 0 {
 			ws := resolveWs()
-			return m, openBrowserCmd(ws.Workspace)
+			return m, openBrowserCmd(ws.Workspace, m.repoName)
 		}
 	case key.Matches(msg, keys.Stop):
 		if len(filtered) > 0 {
@@ -774,7 +802,7 @@ func (m model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		ws := m.workspaces[m.archiveIdx]
 		m.loading = true
 		m.view = viewWorkspaceList
-		return m, tea.Batch(archiveWorkspaceCmd(ws.Workspace, m.rootPath), m.spinner.Tick)
+		return m, tea.Batch(archiveWorkspaceCmd(ws.Workspace, m.rootPath, m.repoName), m.spinner.Tick)
 	case key.Matches(msg, keys.No):
 		m.view = viewWorkspaceList
 	}
@@ -826,9 +854,9 @@ func loadReposCmd() tea.Msg {
 		items[i] = repoItem{Repo: repo, WorkspaceCount: len(repo.Workspaces)}
 	}
 
-	// Enrich with running counts from tmux sessions.
-	if tmux.Available() == nil {
-		sessions, _ := tmux.ListFr8Sessions()
+	// Enrich with running counts from runner sessions.
+	if rn := runner.Default(); rn.Available() == nil {
+		sessions, _ := rn.List()
 		runCounts := make(map[string]int)
 		for _, s := range sessions {
 			runCounts[s.Repo]++
@@ -850,16 +878,23 @@ func loadWorkspacesCmd(repo registry.Repo) tea.Cmd {
 
 		defaultBranch, _ := git.DefaultBranch(rootPath)
 
-		hasTmux := tmux.Available() == nil
+		rn := runner.Default()
+		hasRunner := rn.Available() == nil
 		repoName := tmux.RepoName(rootPath)
 
-		// Build running session lookup map (one subprocess instead of N)
+		cfg, _ := config.Load(rootPath, repo.Name)
+
+		// Build running session lookup maps (one subprocess each instead of N)
 		runningSessions := make(map[string]bool)
-		if hasTmux {
-			sessions, _ := tmux.ListFr8Sessions()
+		var sessionProcs map[string][]string
+		if hasRunner {
+			sessions, _ := rn.List()
 			for _, s := range sessions {
 				runningSessions[s.Name] = true
 			}
+			if cfg != nil && len(cfg.Services) > 0 {
+				sessionProcs, _ = rn.AllProcesses()
+			}
 		}
 
 		items := make([]workspaceItem, len(repo.Workspaces))
@@ -876,9 +911,18 @@ func loadWorkspacesCmd(repo registry.Repo) tea.Cmd {
 				item := workspaceItem{Workspace: ws, Branch: branch}
 				item.PortFree = port.IsFree(ws.Port)
 
-				if hasTmux {
+				if hasRunner {
 					sessionName := tmux.SessionName(repoName, ws.Name)
 					item.Running = runningSessions[sessionName]
+					if !item.Running {
+						item.LastCrash = runner.LastCrash(sessionName)
+					}
+				}
+				if cfg != nil {
+					item.Services = workspace.ServiceStatesOf(sessionProcs[tmux.SessionName(repoName, ws.Name)], cfg, &ws, rootPath)
+					if item.Running {
+						item.Health = workspace.Health(context.Background(), rn, cfg, &ws, rootPath)
+					}
 				}
 
 				dc, err := git.DirtyStatus(ws.Path)
@@ -952,27 +996,24 @@ func loadWorkspacesCmd(repo registry.Repo) tea.Cmd {
 	}
 }
 
-func startWorkspaceCmd(ws registry.Workspace, rootPath string) tea.Cmd {
+func startWorkspaceCmd(ws registry.Workspace, rootPath, registeredName string) tea.Cmd {
 	return func() tea.Msg {
-		if err := tmux.Available(); err != nil {
+		rn := runner.Default()
+		if err := rn.Available(); err != nil {
 			return startResultMsg{name: ws.Name, err: err}
 		}
 
-		cfg, err := config.Load(rootPath)
+		cfg, err := config.Load(rootPath, registeredName)
 		if err != nil {
 			return startResultMsg{name: ws.Name, err: fmt.Errorf("loading config: %w", err)}
 		}
 
-		if cfg.Scripts.Run == "" {
+		if !cfg.HasRun() {
 			return startResultMsg{name: ws.Name, err: fmt.Errorf("no run script configured")}
 		}
 
 		defaultBranch, _ := git.DefaultBranch(rootPath)
-		envVars := env.BuildFr8Only(&ws, rootPath, defaultBranch)
-
-		repoName := tmux.RepoName(rootPath)
-		sessionName := tmux.SessionName(repoName, ws.Name)
-		if err := tmux.Start(sessionName, ws.Path, cfg.Scripts.Run, envVars); err != nil {
+		if err := workspace.Start(rn, cfg, &ws, rootPath, defaultBranch); err != nil {
 			return startResultMsg{name: ws.Name, err: err}
 		}
 
@@ -982,13 +1023,14 @@ func startWorkspaceCmd(ws registry.Workspace, rootPath string) tea.Cmd {
 
 func stopWorkspaceCmd(ws registry.Workspace, rootPath string) tea.Cmd {
 	return func() tea.Msg {
-		if err := tmux.Available(); err != nil {
+		rn := runner.Default()
+		if err := rn.Available(); err != nil {
 			return stopResultMsg{name: ws.Name, err: err}
 		}
 
 		repoName := tmux.RepoName(rootPath)
 		sessionName := tmux.SessionName(repoName, ws.Name)
-		if err := tmux.Stop(sessionName); err != nil {
+		if err := rn.Stop(sessionName); err != nil {
 			return stopResultMsg{name: ws.Name, err: err}
 		}
 
@@ -996,9 +1038,9 @@ func stopWorkspaceCmd(ws registry.Workspace, rootPath string) tea.Cmd {
 	}
 }
 
-func openBrowserCmd(ws registry.Workspace) tea.Cmd {
+func openBrowserCmd(ws registry.Workspace, repoName string) tea.Cmd {
 	return func() tea.Msg {
-		url := fmt.Sprintf("http://localhost:%d", ws.Port)
+		url := proxy.BrowserURL(repoName, &ws)
 		err := openURL(url)
 		return browserResultMsg{name: ws.Name, err: err}
 	}
@@ -1022,7 +1064,8 @@ func openURL(url string) error {
 func runAllCmd(item repoItem) tea.Cmd {
 	return func() tea.Msg {
 		repo := item.Repo
-		if err := tmux.Available(); err != nil {
+		rn := runner.Default()
+		if err := rn.Available(); err != nil {
 			return runAllResultMsg{repoName: repo.Name, err: err}
 		}
 
@@ -1031,11 +1074,11 @@ func runAllCmd(item repoItem) tea.Cmd {
 			return runAllResultMsg{repoName: repo.Name, err: err}
 		}
 
-		cfg, err := config.Load(rootPath)
+		cfg, err := config.Load(rootPath, repo.Name)
 		if err != nil {
 			return runAllResultMsg{repoName: repo.Name, err: err}
 		}
-		if cfg.Scripts.Run == "" {
+		if !cfg.HasRun() {
 			return runAllResultMsg{repoName: repo.Name, err: fmt.Errorf("no run script configured for %s", repo.Name)}
 		}
 
@@ -1044,7 +1087,7 @@ func runAllCmd(item repoItem) tea.Cmd {
 
 		// Build running session lookup map (one subprocess instead of N)
 		runningSessions := make(map[string]bool)
-		sessions, _ := tmux.ListFr8Sessions()
+		sessions, _ := rn.List()
 		for _, s := range sessions {
 			runningSessions[s.Name] = true
 		}
@@ -1055,8 +1098,7 @@ func runAllCmd(item repoItem) tea.Cmd {
 			if runningSessions[sessionName] {
 				continue
 			}
-			envVars := env.BuildFr8Only(&ws, rootPath, defaultBranch)
-			if err := tmux.Start(sessionName, ws.Path, cfg.Scripts.Run, envVars); err != nil {
+			if err := workspace.Start(rn, cfg, &ws, rootPath, defaultBranch); err != nil {
 				return runAllResultMsg{repoName: repo.Name, started: started, err: err}
 			}
 			started++
@@ -1069,11 +1111,12 @@ func runAllCmd(item repoItem) tea.Cmd {
 func stopAllCmd(item repoItem) tea.Cmd {
 	return func() tea.Msg {
 		repo := item.Repo
-		if err := tmux.Available(); err != nil {
+		rn := runner.Default()
+		if err := rn.Available(); err != nil {
 			return stopAllResultMsg{repoName: repo.Name, err: err}
 		}
 
-		sessions, err := tmux.ListFr8Sessions()
+		sessions, err := rn.List()
 		if err != nil {
 			return stopAllResultMsg{repoName: repo.Name, err: err}
 		}
@@ -1084,7 +1127,7 @@ func stopAllCmd(item repoItem) tea.Cmd {
 			if s.Repo != repoName {
 				continue
 			}
-			if err := tmux.Stop(s.Name); err != nil {
+			if err := rn.Stop(s.Name); err != nil {
 				return stopAllResultMsg{repoName: repo.Name, stopped: stopped, err: err}
 			}
 			stopped++
@@ -1096,23 +1139,24 @@ func stopAllCmd(item repoItem) tea.Cmd {
 
 func runAllGlobalCmd(items []repoItem) tea.Cmd {
 	return func() tea.Msg {
-		if err := tmux.Available(); err != nil {
+		rn := runner.Default()
+		if err := rn.Available(); err != nil {
 			return runAllResultMsg{err: err}
 		}
 
 		// Build running session lookup map (one subprocess instead of N)
 		runningSessions := make(map[string]bool)
-		sessions, _ := tmux.ListFr8Sessions()
+		sessions, _ := rn.List()
 		for _, s := range sessions {
 			runningSessions[s.Name] = true
 		}
 
 		// Collect all start jobs
 		type startJob struct {
-			sessionName string
-			dir         string
-			runScript   string
-			envVars     []string
+			cfg           *config.Config
+			ws            registry.Workspace
+			rootPath      string
+			defaultBranch string
 		}
 		var jobs []startJob
 
@@ -1127,8 +1171,8 @@ func runAllGlobalCmd(items []repoItem) tea.Cmd {
 				continue
 			}
 
-			cfg, err := config.Load(rootPath)
-			if err != nil || cfg.Scripts.Run == "" {
+			cfg, err := config.Load(rootPath, repo.Name)
+			if err != nil || !cfg.HasRun() {
 				continue
 			}
 
@@ -1140,17 +1184,16 @@ func runAllGlobalCmd(items []repoItem) tea.Cmd {
 				if runningSessions[sessionName] {
 					continue
 				}
-				envVars := env.BuildFr8Only(&ws, rootPath, defaultBranch)
 				jobs = append(jobs, startJob{
-					sessionName: sessionName,
-					dir:         ws.Path,
-					runScript:   cfg.Scripts.Run,
-					envVars:     envVars,
+					cfg:           cfg,
+					ws:            ws,
+					rootPath:      rootPath,
+					defaultBranch: defaultBranch,
 				})
 			}
 		}
 
-		// Fan out tmux starts with bounded concurrency
+		// Fan out starts with bounded concurrency
 		const maxConcurrent = 5
 		sem := make(chan struct{}, maxConcurrent)
 		results := make(chan bool, len(jobs))
@@ -1158,7 +1201,7 @@ func runAllGlobalCmd(items []repoItem) tea.Cmd {
 			sem <- struct{}{}
 			go func(j startJob) {
 				defer func() { <-sem }()
-				err := tmux.Start(j.sessionName, j.dir, j.runScript, j.envVars)
+				err := workspace.Start(rn, j.cfg, &j.ws, j.rootPath, j.defaultBranch)
 				results <- (err == nil)
 			}(job)
 		}
@@ -1175,18 +1218,19 @@ func runAllGlobalCmd(items []repoItem) tea.Cmd {
 
 func stopAllGlobalCmd() tea.Cmd {
 	return func() tea.Msg {
-		if err := tmux.Available(); err != nil {
+		rn := runner.Default()
+		if err := rn.Available(); err != nil {
 			return stopAllResultMsg{err: err}
 		}
 
-		sessions, err := tmux.ListFr8Sessions()
+		sessions, err := rn.List()
 		if err != nil {
 			return stopAllResultMsg{err: err}
 		}
 
 		var stopped int
 		for _, s := range sessions {
-			if err := tmux.Stop(s.Name); err != nil {
+			if err := rn.Stop(s.Name); err != nil {
 				continue
 			}
 			stopped++
@@ -1237,23 +1281,40 @@ func filteredRepos(repos []repoItem, query string) []repoItem {
 	return result
 }
 
-// filteredWorkspaces returns workspaces matching the query (case-insensitive
-// substring match on name or branch).
+// filteredWorkspaces returns workspaces matching every word of the query.
+// A "label:<name>" word matches workspaces with that label; any other word
+// is a case-insensitive substring match on name, branch or note.
 func filteredWorkspaces(workspaces []workspaceItem, query string) []workspaceItem {
-	if query == "" {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
 		return workspaces
 	}
-	q := strings.ToLower(query)
 	var result []workspaceItem
 	for _, ws := range workspaces {
-		if strings.Contains(strings.ToLower(ws.Workspace.Name), q) ||
-			strings.Contains(strings.ToLower(ws.Branch), q) {
+		if workspaceMatches(ws, terms) {
 			result = append(result, ws)
 		}
 	}
 	return result
 }
 
+func workspaceMatches(ws workspaceItem, terms []string) bool {
+	for _, t := range terms {
+		if label, ok := strings.CutPrefix(t, "label:"); ok {
+			if !slices.ContainsFunc(ws.Workspace.Labels, func(l string) bool { return strings.EqualFold(l, label) }) {
+				return false
+			}
+			continue
+		}
+		if !strings.Contains(strings.ToLower(ws.Workspace.Name), t) &&
+			!strings.Contains(strings.ToLower(ws.Branch), t) &&
+			!strings.Contains(strings.ToLower(ws.Workspace.Note), t) {
+			return false
+		}
+	}
+	return true
+}
+
 // resolveOriginalRepoIndex maps a cursor index in the filtered list back to
 // the original repos slice index.
 func resolveOriginalRepoIndex(cursor int, filtered, original []repoItem) int {
@@ -1298,34 +1359,84 @@ func autoRefreshTickCmd() tea.Cmd {
 	})
 }
 
-func autoRefreshCmd() tea.Cmd {
+func autoRefreshCmd(rootPath, registeredName string, items []workspaceItem) tea.Cmd {
+	workspaces := make([]registry.Workspace, len(items))
+	for i, item := range items {
+		workspaces[i] = item.Workspace
+	}
 	return func() tea.Msg {
-		if tmux.Available() != nil {
+		rn := runner.Default()
+		if rn.Available() != nil {
 			return autoRefreshResultMsg{}
 		}
-		sessions, err := tmux.ListFr8Sessions()
-		return autoRefreshResultMsg{sessions: sessions, err: err}
+		sessions, err := rn.List()
+		return autoRefreshResultMsg{
+			sessions: sessions,
+			crashes:  runner.Crashes(),
+			health:   workspaceHealth(rootPath, registeredName, workspaces, sessions),
+			err:      err,
+		}
+	}
+}
+
+// workspaceHealth probes the healthcheck of each running workspace in the
+// repo at rootPath, in parallel, keyed by session name. Without a healthcheck
+// in fr8.json, FR8_PORT is probed, as fr8 ws wait does.
+func workspaceHealth(rootPath, registeredName string, workspaces []registry.Workspace, sessions []runner.Session) map[string]string {
+	if rootPath == "" {
+		return nil
+	}
+	cfg, err := config.Load(rootPath, registeredName)
+	if err != nil {
+		return nil
 	}
+
+	running := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		running[s.Name] = true
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	states := make(map[string]string)
+	repoName := tmux.RepoName(rootPath)
+	for i := range workspaces {
+		ws := &workspaces[i]
+		sessionName := tmux.SessionName(repoName, ws.Name)
+		if !running[sessionName] {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state := workspace.HealthCheck(cfg, ws).State(context.Background(), runner.StartedAt(sessionName))
+			mu.Lock()
+			states[sessionName] = state
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return states
 }
 
 // --- Multi-select batch commands ---
 
-func startSelectedCmd(workspaces []workspaceItem, selected map[int]bool, rootPath string) tea.Cmd {
+func startSelectedCmd(workspaces []workspaceItem, selected map[int]bool, rootPath, registeredName string) tea.Cmd {
 	return func() tea.Msg {
-		if err := tmux.Available(); err != nil {
+		rn := runner.Default()
+		if err := rn.Available(); err != nil {
 			return batchStartResultMsg{err: err}
 		}
 
-		cfg, err := config.Load(rootPath)
+		cfg, err := config.Load(rootPath, registeredName)
 		if err != nil {
 			return batchStartResultMsg{err: fmt.Errorf("loading config: %w", err)}
 		}
-		if cfg.Scripts.Run == "" {
+		if !cfg.HasRun() {
 			return batchStartResultMsg{err: fmt.Errorf("no run script configured")}
 		}
 
 		defaultBranch, _ := git.DefaultBranch(rootPath)
-		repoName := tmux.RepoName(rootPath)
 
 		var started int
 		for idx := range selected {
@@ -1336,9 +1447,7 @@ func startSelectedCmd(workspaces []workspaceItem, selected map[int]bool, rootPat
 			if ws.Running {
 				continue
 			}
-			sessionName := tmux.SessionName(repoName, ws.Workspace.Name)
-			envVars := env.BuildFr8Only(&ws.Workspace, rootPath, defaultBranch)
-			if err := tmux.Start(sessionName, ws.Workspace.Path, cfg.Scripts.Run, envVars); err != nil {
+			if err := workspace.Start(rn, cfg, &ws.Workspace, rootPath, defaultBranch); err != nil {
 				return batchStartResultMsg{started: started, err: err}
 			}
 			started++
@@ -1350,7 +1459,8 @@ func startSelectedCmd(workspaces []workspaceItem, selected map[int]bool, rootPat
 
 func stopSelectedCmd(workspaces []workspaceItem, selected map[int]bool, rootPath string) tea.Cmd {
 	return func() tea.Msg {
-		if err := tmux.Available(); err != nil {
+		rn := runner.Default()
+		if err := rn.Available(); err != nil {
 			return batchStopResultMsg{err: err}
 		}
 
@@ -1366,7 +1476,7 @@ func stopSelectedCmd(workspaces []workspaceItem, selected map[int]bool, rootPath
 				continue
 			}
 			sessionName := tmux.SessionName(repoName, ws.Workspace.Name)
-			if err := tmux.Stop(sessionName); err != nil {
+			if err := rn.Stop(sessionName); err != nil {
 				return batchStopResultMsg{stopped: stopped, err: err}
 			}
 			stopped++
@@ -1376,15 +1486,16 @@ func stopSelectedCmd(workspaces []workspaceItem, selected map[int]bool, rootPath
 	}
 }
 
-// refreshRunningCounts re-derives RunningCount on all repos from tmux sessions.
+// refreshRunningCounts re-derives RunningCount on all repos from runner sessions.
 func refreshRunningCounts(repos []repoItem) {
-	if tmux.Available() != nil {
+	rn := runner.Default()
+	if rn.Available() != nil {
 		for i := range repos {
 			repos[i].RunningCount = 0
 		}
 		return
 	}
-	sessions, _ := tmux.ListFr8Sessions()
+	sessions, _ := rn.List()
 	counts := make(map[string]int)
 	for _, s := range sessions {
 		counts[s.Repo]++
@@ -1409,7 +1520,7 @@ func batchArchiveCmd(names []string, rootPath string) tea.Cmd {
 			return batchArchiveResultMsg{err: fmt.Errorf("repo not found for path %s", rootPath)}
 		}
 
-		cfg, err := config.Load(rootPath)
+		cfg, err := config.Load(rootPath, repo.Name)
 		if err != nil {
 			return batchArchiveResultMsg{err: fmt.Errorf("loading config: %w", err)}
 		}
@@ -1418,6 +1529,7 @@ func batchArchiveCmd(names []string, rootPath string) tea.Cmd {
 		repoName := tmux.RepoName(rootPath)
 
 		var archived, failed []string
+		var warning error
 		for _, name := range names {
 			ws := repo.FindWorkspace(name)
 			if ws == nil {
@@ -1425,15 +1537,18 @@ func batchArchiveCmd(names []string, rootPath string) tea.Cmd {
 				continue
 			}
 
-			// Stop tmux session
-			if tmux.Available() == nil {
+			// Stop running session
+			if rn := runner.Default(); rn.Available() == nil {
 				sessionName := tmux.SessionName(repoName, ws.Name)
-				_ = tmux.Stop(sessionName)
+				_ = rn.Stop(sessionName)
 			}
 
 			// Run archive script
 			if cfg.Scripts.Archive != "" {
-				envVars := env.Build(ws, rootPath, defaultBranch)
+				envVars, envErr := env.Build(ws, rootPath, defaultBranch, cfg)
+				if envErr != nil && warning == nil {
+					warning = envErr
+				}
 				cmd := exec.Command("sh", "-c", cfg.Scripts.Archive)
 				cmd.Dir = ws.Path
 				cmd.Env = envVars
@@ -1452,43 +1567,58 @@ func batchArchiveCmd(names []string, rootPath string) tea.Cmd {
 				continue
 			}
 
+			// Purge session logs
+			if logDir, err := logfile.Dir(repoName, ws.Name); err == nil {
+				_ = logfile.Remove(logDir)
+			}
+
 			archived = append(archived, name)
 		}
 
 		// Batch registry update
-		for _, name := range archived {
-			_ = repo.RemoveWorkspace(name)
-		}
-		if err := reg.Save(regPath); err != nil {
+		err = registry.Update(regPath, func(reg *registry.Registry) error {
+			if r := reg.FindByPath(rootPath); r != nil {
+				for _, name := range archived {
+					_ = r.RemoveWorkspace(name)
+				}
+			}
+			return nil
+		})
+		if err != nil {
 			return batchArchiveResultMsg{err: fmt.Errorf("saving state: %w", err)}
 		}
 
-		return batchArchiveResultMsg{archived: archived, failed: failed}
+		return batchArchiveResultMsg{archived: archived, failed: failed, warning: warning}
 	}
 }
 
-func archiveWorkspaceCmd(ws registry.Workspace, rootPath string) tea.Cmd {
+func archiveWorkspaceCmd(ws registry.Workspace, rootPath, registeredName string) tea.Cmd {
 	return func() tea.Msg {
-		// Auto-stop tmux session before archiving
-		if tmux.Available() == nil {
+		// Auto-stop running session before archiving
+		if rn := runner.Default(); rn.Available() == nil {
 			repoName := tmux.RepoName(rootPath)
 			sessionName := tmux.SessionName(repoName, ws.Name)
-			_ = tmux.Stop(sessionName) // best-effort, ignore errors
+			_ = rn.Stop(sessionName) // best-effort, ignore errors
 		}
 
-		cfg, err := config.Load(rootPath)
+		cfg, err := config.Load(rootPath, registeredName)
 		if err != nil {
 			return archiveResultMsg{name: ws.Name, err: fmt.Errorf("loading config: %w", err)}
 		}
 
 		// Run archive script with captured output
 		defaultBranch, _ := git.DefaultBranch(rootPath)
+		var warning error
 		if cfg.Scripts.Archive != "" {
-			envVars := env.Build(&ws, rootPath, defaultBranch)
+			envVars, envErr := env.Build(&ws, rootPath, defaultBranch, cfg)
+			warning = envErr
 			cmd := exec.Command("sh", "-c", cfg.Scripts.Archive)
 			cmd.Dir = ws.Path
 			cmd.Env = envVars
 			var buf bytes.Buffer
+			if envErr != nil {
+				fmt.Fprintf(&buf, "Warning: %v\n", envErr)
+			}
 			cmd.Stdout = &buf
 			cmd.Stderr = &buf
 			if err := cmd.Run(); err != nil {
@@ -1504,23 +1634,26 @@ func archiveWorkspaceCmd(ws registry.Workspace, rootPath string) tea.Cmd {
 			return archiveResultMsg{name: ws.Name, err: fmt.Errorf("removing worktree: %w", err)}
 		}
 
+		// Purge session logs
+		if logDir, err := logfile.Dir(tmux.RepoName(rootPath), ws.Name); err == nil {
+			_ = logfile.Remove(logDir)
+		}
+
 		// Update registry
 		regPath, err := registry.DefaultPath()
 		if err != nil {
 			return archiveResultMsg{name: ws.Name, err: fmt.Errorf("finding state path: %w", err)}
 		}
-		reg, err := registry.Load(regPath)
-		if err != nil {
-			return archiveResultMsg{name: ws.Name, err: fmt.Errorf("loading registry: %w", err)}
-		}
-		repo := reg.FindByPath(rootPath)
-		if repo != nil {
-			_ = repo.RemoveWorkspace(ws.Name)
-			if err := reg.Save(regPath); err != nil {
-				return archiveResultMsg{name: ws.Name, err: fmt.Errorf("saving state: %w", err)}
+		err = registry.Update(regPath, func(reg *registry.Registry) error {
+			if repo := reg.FindByPath(rootPath); repo != nil {
+				_ = repo.RemoveWorkspace(ws.Name)
 			}
+			return nil
+		})
+		if err != nil {
+			return archiveResultMsg{name: ws.Name, err: fmt.Errorf("saving state: %w", err)}
 		}
 
-		return archiveResultMsg{name: ws.Name}
+		return archiveResultMsg{name: ws.Name, warning: warning}
 	}
 }
@@ -1,14 +1,17 @@
 package tui
 
 import (
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/protocollar/fr8/internal/git"
+	"github.com/protocollar/fr8/internal/health"
 	"github.com/protocollar/fr8/internal/registry"
-	"github.com/protocollar/fr8/internal/tmux"
+	"github.com/protocollar/fr8/internal/runner"
 	"github.com/protocollar/fr8/internal/userconfig"
 )
 
@@ -414,6 +417,30 @@ func TestArchiveResultWithError(t *testing.T) {
 	}
 }
 
+func TestArchiveResultWithWarning(t *testing.T) {
+	m := seedWorkspaceModel()
+	m.loading = true
+
+	m = updateModel(m, archiveResultMsg{name: "ws-one", warning: errStub{}})
+
+	if len(m.workspaces) != 2 {
+		t.Errorf("workspaces count = %d, want 2 (archived despite the warning)", len(m.workspaces))
+	}
+	if m.err == nil || !m.toastIsError || !strings.Contains(m.toast, "warnings") {
+		t.Errorf("expected the warning in the status line, got err=%v toast=%q", m.err, m.toast)
+	}
+}
+
+func TestBatchArchiveResultWithWarning(t *testing.T) {
+	m := seedWorkspaceModel()
+
+	m = updateModel(m, batchArchiveResultMsg{archived: []string{"ws-one"}, warning: errStub{}})
+
+	if m.err == nil || !m.toastIsError || !strings.Contains(m.toast, "warnings") {
+		t.Errorf("expected the warning in the status line, got err=%v toast=%q", m.err, m.toast)
+	}
+}
+
 func TestArchiveResultCursorClamp(t *testing.T) {
 	m := seedWorkspaceModel()
 	m.cursor = 2 // Last item
@@ -1348,6 +1375,38 @@ func TestFilteredWorkspaces(t *testing.T) {
 	}
 }
 
+func TestFilteredWorkspacesLabelsAndNotes(t *testing.T) {
+	workspaces := []workspaceItem{
+		{Workspace: registry.Workspace{Name: "ws-one", Labels: []string{"review"}}, Branch: "feat-1"},
+		{Workspace: registry.Workspace{Name: "ws-two", Labels: []string{"api", "review"}, Note: "Job queue spike"}, Branch: "feat-2"},
+		{Workspace: registry.Workspace{Name: "ws-three"}, Branch: "main"},
+	}
+
+	names := func(items []workspaceItem) []string {
+		var out []string
+		for _, it := range items {
+			out = append(out, it.Workspace.Name)
+		}
+		return out
+	}
+
+	if got := names(filteredWorkspaces(workspaces, "label:review")); !slices.Equal(got, []string{"ws-one", "ws-two"}) {
+		t.Errorf("filter 'label:review' = %v", got)
+	}
+	if got := names(filteredWorkspaces(workspaces, "label:review label:api")); !slices.Equal(got, []string{"ws-two"}) {
+		t.Errorf("filter 'label:review label:api' = %v", got)
+	}
+	if got := names(filteredWorkspaces(workspaces, "label:review one")); !slices.Equal(got, []string{"ws-one"}) {
+		t.Errorf("filter 'label:review one' = %v", got)
+	}
+	if got := names(filteredWorkspaces(workspaces, "queue")); !slices.Equal(got, []string{"ws-two"}) {
+		t.Errorf("filter 'queue' (note) = %v", got)
+	}
+	if got := filteredWorkspaces(workspaces, "label:blocked"); len(got) != 0 {
+		t.Errorf("filter 'label:blocked' = %v, want none", names(got))
+	}
+}
+
 func TestFilteredRepoResolvesCorrectIndex(t *testing.T) {
 	m := seedRepoModel()
 	m.filterInput = textinput.New()
@@ -1495,7 +1554,7 @@ func TestAutoRefreshResultUpdatesRunning(t *testing.T) {
 
 	// ws-two should become running
 	result, _ := m.Update(autoRefreshResultMsg{
-		sessions: []tmux.Session{
+		sessions: []runner.Session{
 			{Name: "fr8/a/ws-two", Repo: "a", Workspace: "ws-two"},
 		},
 	})
@@ -1508,6 +1567,27 @@ func TestAutoRefreshResultUpdatesRunning(t *testing.T) {
 	// In test, tmux.RepoName("/a") depends on actual implementation
 }
 
+func TestAutoRefreshResultUpdatesHealth(t *testing.T) {
+	m := seedWorkspaceModel()
+	m.rootPath = "/a"
+	m.workspaces[0].Health = health.Starting
+
+	result, _ := m.Update(autoRefreshResultMsg{
+		sessions: []runner.Session{
+			{Name: "fr8/a/ws-one", Repo: "a", Workspace: "ws-one"},
+		},
+		health: map[string]string{"fr8/a/ws-one": health.Healthy},
+	})
+	m = result.(model)
+
+	if got := m.workspaces[0].Health; got != health.Healthy {
+		t.Errorf("ws-one health = %q, want %q", got, health.Healthy)
+	}
+	if got := m.workspaces[1].Health; got != "" {
+		t.Errorf("ws-two health = %q, want empty when not running", got)
+	}
+}
+
 // --- Toast on Stop/Archive Result ---
 
 func TestToastSetOnStopResult(t *testing.T) {
@@ -9,6 +9,7 @@ import (
 	"github.com/protocollar/fr8/internal/gh"
 	"github.com/protocollar/fr8/internal/git"
 	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/workspace"
 )
 
 func TestFormatStatus(t *testing.T) {
@@ -644,3 +645,29 @@ func TestWorkspaceRowEmptySelectionMap(t *testing.T) {
 		t.Errorf("empty selection map should have no markers, got: %q", row)
 	}
 }
+
+func TestFormatServices(t *testing.T) {
+	got := formatServices([]workspace.ServiceState{
+		{Name: "web", Port: 5000, Running: true},
+		{Name: "worker", Port: 5000},
+	})
+	if !strings.Contains(got, "● web :5000") {
+		t.Errorf("formatServices missing running web: %q", got)
+	}
+	if !strings.Contains(got, "○ worker :5000") {
+		t.Errorf("formatServices missing stopped worker: %q", got)
+	}
+}
+
+func TestWorkspaceDetailShowsServices(t *testing.T) {
+	t.Setenv("TERMINAL_EMULATOR", "")
+	m := seedWorkspaceModel()
+	m.width = 160
+	m.height = 40
+	m.workspaces[0].Services = []workspace.ServiceState{{Name: "worker", Port: 5000, Running: true}}
+
+	output := renderWorkspaceList(m)
+	if !strings.Contains(output, "Services") || !strings.Contains(output, "worker") {
+		t.Error("detail pane should list configured services")
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/protocollar/fr8/internal/gh"
+	"github.com/protocollar/fr8/internal/health"
+	"github.com/protocollar/fr8/internal/workspace"
 )
 
 func renderWorkspaceList(m model) string {
@@ -123,12 +125,34 @@ func renderWorkspaceList(m model) string {
 		detail.WriteString("\n")
 		detail.WriteString(renderDetailRow("Path", shortenPath(item.Workspace.Path)))
 		detail.WriteString("\n")
+		if len(item.Workspace.Labels) > 0 {
+			detail.WriteString(renderDetailRow("Labels", strings.Join(item.Workspace.Labels, ", ")))
+			detail.WriteString("\n")
+		}
+		if item.Workspace.Note != "" {
+			detail.WriteString(renderDetailRow("Note", item.Workspace.Note))
+			detail.WriteString("\n")
+		}
+		if item.Workspace.Pinned {
+			detail.WriteString(renderDetailRow("Pinned", "yes"))
+			detail.WriteString("\n")
+		}
 		if item.Running {
 			detail.WriteString(renderDetailRow("Process", statusCleanStyle.Render("● running")))
+		} else if item.LastCrash != nil {
+			detail.WriteString(renderDetailRow("Process", statusErrorStyle.Render("✗ "+item.LastCrash.Summary())))
 		} else {
 			detail.WriteString(renderDetailRow("Process", dimStyle.Render("not running")))
 		}
 		detail.WriteString("\n")
+		if item.Health != "" {
+			detail.WriteString(renderDetailRow("Health", formatHealth(item.Health)))
+			detail.WriteString("\n")
+		}
+		if len(item.Services) > 0 {
+			detail.WriteString(renderDetailRow("Services", formatServices(item.Services)))
+			detail.WriteString("\n")
+		}
 		detail.WriteString(renderDetailRow("Status", formatStatus(item)))
 		if item.LastCommit != nil {
 			commitStr := truncate(item.LastCommit.Subject, 40) + " " + dimStyle.Render("("+relativeTime(item.LastCommit.Time)+")")
@@ -191,6 +215,35 @@ func renderWorkspaceList(m model) string {
 	return b.String()
 }
 
+// formatServices renders per-service running state, e.g.
+// "● web :60000  ✗ worker :60001 (exit 1)  ○ mail :60002".
+func formatServices(services []workspace.ServiceState) string {
+	parts := make([]string, 0, len(services))
+	for _, svc := range services {
+		label := fmt.Sprintf("%s :%d", svc.Name, svc.Port)
+		if svc.Running {
+			parts = append(parts, statusCleanStyle.Render("● "+label))
+		} else if svc.LastExit != nil && svc.LastExit.Crashed() {
+			parts = append(parts, statusErrorStyle.Render(fmt.Sprintf("✗ %s (exit %d)", label, svc.LastExit.Code)))
+		} else {
+			parts = append(parts, dimStyle.Render("○ "+label))
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// formatHealth renders a workspace's healthcheck state.
+func formatHealth(state string) string {
+	switch state {
+	case health.Healthy:
+		return statusCleanStyle.Render("● healthy")
+	case health.Starting:
+		return statusDirtyStyle.Render("◌ starting")
+	default:
+		return statusErrorStyle.Render("✗ " + state)
+	}
+}
+
 // renderWorkspaceRow renders a single workspace row with optional selection marker,
 // branch name, and compact time.
 func renderWorkspaceRow(item workspaceItem, displayIdx, cursor, origIdx int, selected map[int]bool, width int) string {
@@ -200,7 +253,16 @@ func renderWorkspaceRow(item workspaceItem, displayIdx, cursor, origIdx int, sel
 
 	runBadge := "  "
 	if item.Running {
-		runBadge = statusCleanStyle.Render("▶ ")
+		switch item.Health {
+		case health.Unhealthy:
+			runBadge = statusErrorStyle.Render("▶ ")
+		case health.Starting:
+			runBadge = statusDirtyStyle.Render("▶ ")
+		default:
+			runBadge = statusCleanStyle.Render("▶ ")
+		}
+	} else if item.LastCrash != nil {
+		runBadge = statusErrorStyle.Render("✗ ")
 	}
 
 	// Selection marker
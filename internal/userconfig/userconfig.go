@@ -20,6 +20,12 @@ type Opener struct {
 // Config holds user-level preferences stored in ~/.config/fr8/config.json.
 type Config struct {
 	Openers []Opener `json:"openers,omitempty"`
+	Runner  string   `json:"runner,omitempty"` // "tmux" or "native"; empty picks tmux when installed
+
+	// Defaults holds fr8.json settings applied under every repo's fr8.json,
+	// and Repos the same per repo, keyed by registry name. See config.Load.
+	Defaults json.RawMessage            `json:"defaults,omitempty"`
+	Repos    map[string]json.RawMessage `json:"repos,omitempty"`
 }
 
 // DefaultPath returns the path to the user config file (~/.config/fr8/config.json).
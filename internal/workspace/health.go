@@ -0,0 +1,58 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/health"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
+	"github.com/protocollar/fr8/internal/tmux"
+)
+
+// HealthCheck returns the workspace's healthcheck. Without a healthcheck in
+// fr8.json, it checks that the workspace's base port (FR8_PORT) accepts TCP
+// connections.
+func HealthCheck(cfg *config.Config, ws *registry.Workspace) health.Check {
+	return health.New(cfg.Healthcheck, ws.Port)
+}
+
+// Health probes a running workspace once, with the same check fr8 ws wait
+// uses (see HealthCheck), and returns health.Healthy, health.Starting or
+// health.Unhealthy. Returns "" if the workspace is not running.
+func Health(ctx context.Context, rn runner.Runner, cfg *config.Config, ws *registry.Workspace, rootPath string) string {
+	if rn.Available() != nil {
+		return ""
+	}
+	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
+	if !rn.IsRunning(sessionName) {
+		return ""
+	}
+	return HealthCheck(cfg, ws).State(ctx, runner.StartedAt(sessionName))
+}
+
+// WaitHealthy blocks until a running workspace passes its healthcheck or ctx
+// is done (health.ErrTimeout once its deadline passes). It fails early if
+// the workspace stops or one of its processes crashes without restarting.
+func WaitHealthy(ctx context.Context, rn runner.Runner, cfg *config.Config, ws *registry.Workspace, rootPath string) error {
+	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
+	started := runner.StartedAt(sessionName)
+	alive := func() error {
+		if rn.IsRunning(sessionName) {
+			crash := runner.LastCrash(sessionName)
+			if crash == nil || crash.Time.Before(started) || len(cfg.Services) == 0 || rn.HasProcess(sessionName, crash.Name) {
+				return nil
+			}
+			return fmt.Errorf("service %q %s before workspace %q was healthy (see: fr8 ws logs %s --service %s)", crash.Name, crash.Summary(), ws.Name, ws.Name, crash.Name)
+		}
+		if crash := runner.LastCrash(sessionName); crash != nil && !crash.Time.Before(started) {
+			return fmt.Errorf("workspace %q is not running: %s (see: fr8 ws logs %s)", ws.Name, crash.Summary(), ws.Name)
+		}
+		return fmt.Errorf("workspace %q is not running (start with: fr8 ws run)", ws.Name)
+	}
+	if err := alive(); err != nil {
+		return err
+	}
+	return HealthCheck(cfg, ws).Wait(ctx, alive)
+}
@@ -0,0 +1,50 @@
+package workspace
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/health"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
+)
+
+func TestHealthNotRunning(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	ws := &registry.Workspace{Name: "alpha", Port: 5000}
+	if got := Health(context.Background(), runner.Native{}, &config.Config{}, ws, "/tmp/repo"); got != "" {
+		t.Errorf("Health() = %q, want empty when not running", got)
+	}
+}
+
+// runningRunner reports every session as running.
+type runningRunner struct{ runner.Native }
+
+func (runningRunner) Available() error        { return nil }
+func (runningRunner) IsRunning(s string) bool { return true }
+
+func TestHealthWithoutHealthcheckProbesPort(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	ws := &registry.Workspace{Name: "alpha", Port: ln.Addr().(*net.TCPAddr).Port}
+
+	if got := Health(context.Background(), runningRunner{}, &config.Config{}, ws, "/tmp/repo"); got != health.Healthy {
+		t.Errorf("Health() = %q, want %q from the FR8_PORT probe", got, health.Healthy)
+	}
+}
+
+func TestWaitHealthyNotRunning(t *testing.T) {
+	t.Setenv("FR8_STATE_DIR", t.TempDir())
+	ws := &registry.Workspace{Name: "alpha", Port: 5000}
+	err := WaitHealthy(context.Background(), runner.Native{}, &config.Config{}, ws, "/tmp/repo")
+	if err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("WaitHealthy() = %v, want not running error", err)
+	}
+}
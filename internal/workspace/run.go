@@ -0,0 +1,141 @@
+package workspace
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/env"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
+	"github.com/protocollar/fr8/internal/tmux"
+)
+
+// ServiceState is the live state of one configured service.
+type ServiceState struct {
+	Name     string       `json:"name"`
+	Port     int          `json:"port"`
+	Running  bool         `json:"running"`
+	LastExit *runner.Exit `json:"last_exit,omitempty"` // how the service last exited, if it has since it was started
+}
+
+// Start launches the workspace in the background with rn. When services are
+// configured, each one runs as its own process, started in dependency order;
+// otherwise scripts.run is started as a single process. Nothing is started
+// if a variable in the env section of fr8.json fails to expand.
+func Start(rn runner.Runner, cfg *config.Config, ws *registry.Workspace, rootPath, defaultBranch string) error {
+	if !cfg.HasRun() {
+		return fmt.Errorf("no run script configured (add \"scripts.run\" or \"services\" to fr8.json)")
+	}
+
+	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
+	envVars, err := env.BuildFr8Only(ws, rootPath, defaultBranch, cfg)
+	if err != nil {
+		return fmt.Errorf("%w (see: fr8 config doctor)", err)
+	}
+
+	if len(cfg.Services) == 0 {
+		return rn.Start(sessionName, ws.Path, []runner.Process{{Command: cfg.Scripts.Run, Restart: cfg.RestartPolicy("")}}, envVars)
+	}
+
+	order, err := cfg.ServiceOrder()
+	if err != nil {
+		return err
+	}
+	procs := make([]runner.Process, 0, len(order))
+	for _, name := range order {
+		p := serviceProcess(name, cfg.Services[name], ws)
+		p.Restart = cfg.RestartPolicy(name)
+		procs = append(procs, p)
+	}
+	return rn.Start(sessionName, ws.Path, procs, envVars)
+}
+
+// StartService launches a single configured service, adding it to the
+// workspace session if it is already running or starting a new session if not.
+// Dependencies are not started automatically. As with Start, nothing is
+// started if the env section fails to expand.
+func StartService(rn runner.Runner, cfg *config.Config, ws *registry.Workspace, rootPath, defaultBranch, name string) error {
+	svc, ok := cfg.Services[name]
+	if !ok {
+		return fmt.Errorf("service %q not found in fr8.json", name)
+	}
+
+	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
+	envVars, err := env.BuildFr8Only(ws, rootPath, defaultBranch, cfg)
+	if err != nil {
+		return fmt.Errorf("%w (see: fr8 config doctor)", err)
+	}
+	p := serviceProcess(name, svc, ws)
+	p.Restart = cfg.RestartPolicy(name)
+	return rn.StartProcess(sessionName, ws.Path, p, envVars)
+}
+
+// ServiceStates reports the port, running state and last exit of each
+// configured service, in dependency order (or by name if the order can't be
+// resolved).
+// A service is running when rn reports its process alive in the workspace session.
+func ServiceStates(rn runner.Runner, cfg *config.Config, ws *registry.Workspace, rootPath string) []ServiceState {
+	if len(cfg.Services) == 0 {
+		return nil
+	}
+	var procs []string
+	if rn.Available() == nil {
+		procs, _ = rn.Processes(tmux.SessionName(tmux.RepoName(rootPath), ws.Name))
+	}
+	return ServiceStatesOf(procs, cfg, ws, rootPath)
+}
+
+// ServiceStatesOf is ServiceStates given the live processes of the
+// workspace session, for callers that list every session's processes at once
+// (see runner.Runner.AllProcesses).
+func ServiceStatesOf(procs []string, cfg *config.Config, ws *registry.Workspace, rootPath string) []ServiceState {
+	if len(cfg.Services) == 0 {
+		return nil
+	}
+
+	order, err := cfg.ServiceOrder()
+	if err != nil {
+		order = order[:0]
+		for name := range cfg.Services {
+			order = append(order, name)
+		}
+		sort.Strings(order)
+	}
+
+	sessionName := tmux.SessionName(tmux.RepoName(rootPath), ws.Name)
+	running := make(map[string]bool)
+	for _, p := range procs {
+		running[p] = true
+	}
+
+	states := make([]ServiceState, 0, len(order))
+	for _, name := range order {
+		states = append(states, ServiceState{
+			Name:     name,
+			Port:     ws.Port + cfg.Services[name].PortOffset,
+			Running:  running[name],
+			LastExit: runner.LastExit(sessionName, name),
+		})
+	}
+	return states
+}
+
+// serviceProcess builds the runner process for a service. Each service sees
+// FR8_SERVICE and FR8_SERVICE_PORT in addition to the workspace variables,
+// followed by its own env entries.
+func serviceProcess(name string, svc config.Service, ws *registry.Workspace) runner.Process {
+	vars := []string{
+		"FR8_SERVICE=" + name,
+		fmt.Sprintf("FR8_SERVICE_PORT=%d", ws.Port+svc.PortOffset),
+	}
+	keys := make([]string, 0, len(svc.Env))
+	for k := range svc.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		vars = append(vars, k+"="+svc.Env[k])
+	}
+	return runner.Process{Name: name, Command: svc.Command, Env: vars}
+}
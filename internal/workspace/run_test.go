@@ -0,0 +1,78 @@
+package workspace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/protocollar/fr8/internal/config"
+	"github.com/protocollar/fr8/internal/registry"
+	"github.com/protocollar/fr8/internal/runner"
+)
+
+func TestServiceProcess(t *testing.T) {
+	ws := &registry.Workspace{Name: "alpha", Path: "/tmp/alpha", Port: 5000}
+	svc := config.Service{
+		Command:    "redis-server",
+		PortOffset: 2,
+		Env:        map[string]string{"B": "2", "A": "1"},
+	}
+
+	p := serviceProcess("redis", svc, ws)
+	if p.Name != "redis" || p.Command != "redis-server" {
+		t.Errorf("process = %+v", p)
+	}
+	want := []string{"FR8_SERVICE=redis", "FR8_SERVICE_PORT=5002", "A=1", "B=2"}
+	if strings.Join(p.Env, ",") != strings.Join(want, ",") {
+		t.Errorf("Env = %v, want %v", p.Env, want)
+	}
+}
+
+func TestServiceStatesNotRunning(t *testing.T) {
+	ws := &registry.Workspace{Name: "no-such-workspace-12345", Path: "/tmp/x", Port: 5000}
+	cfg := &config.Config{Services: map[string]config.Service{
+		"web":   {Command: "web", DependsOn: []string{"redis"}},
+		"redis": {Command: "redis", PortOffset: 1},
+	}}
+
+	states := ServiceStates(runner.Tmux{}, cfg, ws, "/tmp/no-such-repo")
+	if len(states) != 2 {
+		t.Fatalf("len(states) = %d, want 2", len(states))
+	}
+	if states[0].Name != "redis" || states[0].Port != 5001 {
+		t.Errorf("states[0] = %+v, want redis on 5001", states[0])
+	}
+	if states[1].Name != "web" || states[1].Port != 5000 {
+		t.Errorf("states[1] = %+v, want web on 5000", states[1])
+	}
+	for _, s := range states {
+		if s.Running {
+			t.Errorf("service %q should not be running", s.Name)
+		}
+	}
+}
+
+func TestServiceStatesNoServices(t *testing.T) {
+	ws := &registry.Workspace{Name: "alpha", Port: 5000}
+	if states := ServiceStates(runner.Tmux{}, &config.Config{}, ws, "/tmp/repo"); states != nil {
+		t.Errorf("ServiceStates = %v, want nil", states)
+	}
+}
+
+func TestStartNoRunScript(t *testing.T) {
+	ws := &registry.Workspace{Name: "alpha", Path: "/tmp/alpha", Port: 5000}
+	if err := Start(runner.Tmux{}, &config.Config{}, ws, "/tmp/repo", "main"); err == nil {
+		t.Error("expected error when nothing is configured to run")
+	}
+}
+
+func TestStartEnvFailsToExpand(t *testing.T) {
+	ws := &registry.Workspace{Name: "alpha", Path: "/tmp/alpha", Port: 5000}
+	cfg := &config.Config{
+		Scripts: config.Scripts{Run: "true"},
+		Env:     map[string]string{"BAD": "{{.Ports.missing}}"},
+	}
+	err := Start(runner.Tmux{}, cfg, ws, "/tmp/repo", "main")
+	if err == nil || !strings.Contains(err.Error(), "env.BAD") {
+		t.Errorf("Start() = %v, want an error naming env.BAD", err)
+	}
+}